@@ -0,0 +1,236 @@
+package goparquet
+
+import (
+	"encoding/binary"
+	"io"
+	"math"
+
+	"github.com/cespare/xxhash/v2"
+	"github.com/fraugster/parquet-go/parquet"
+	"github.com/pkg/errors"
+)
+
+const (
+	// bloomFilterBlockSize is the size of a single block of a split block Bloom filter in bytes.
+	bloomFilterBlockSize = 32
+
+	minBloomFilterSize = bloomFilterBlockSize
+	maxBloomFilterSize = 128 * 1024 * 1024
+
+	// DefaultBloomFilterFPP is the false positive probability that is used for Bloom filters
+	// if no other probability is provided.
+	DefaultBloomFilterFPP = 0.01
+)
+
+// bloomFilterSalt are the salt values used to determine the bits to set within a block,
+// as defined in the parquet format specification.
+var bloomFilterSalt = [8]uint32{
+	0x47b6137b, 0x44974d91, 0x8824ad5b, 0xa2b7289d,
+	0x705495c7, 0x2df1424b, 0x9efc4947, 0x5c6bfb31,
+}
+
+// bloomFilter is a split block Bloom filter as defined in the parquet format specification.
+// The filter consists of blocks of 256 bits, and every value sets one bit in every 32 bit
+// word of a single block.
+type bloomFilter struct {
+	blocks [][8]uint32
+}
+
+// newBloomFilter creates an empty Bloom filter with a size of numBytes, which needs to be a
+// multiple of the block size.
+func newBloomFilter(numBytes int) *bloomFilter {
+	return &bloomFilter{
+		blocks: make([][8]uint32, numBytes/bloomFilterBlockSize),
+	}
+}
+
+// optimalBloomFilterSize returns the size in bytes of a Bloom filter that holds ndv distinct
+// values with a false positive probability of fpp.
+func optimalBloomFilterSize(ndv int64, fpp float64) int {
+	if ndv < 1 {
+		ndv = 1
+	}
+	if fpp <= 0 || fpp >= 1 {
+		fpp = DefaultBloomFilterFPP
+	}
+
+	numBits := -8 * float64(ndv) / math.Log(1-math.Pow(fpp, 1.0/8))
+	if numBits >= maxBloomFilterSize*8 {
+		return maxBloomFilterSize
+	}
+
+	size := minBloomFilterSize
+	for float64(size*8) < numBits {
+		size *= 2
+	}
+	return size
+}
+
+func (bf *bloomFilter) blockMask(hash uint64) (int, [8]uint32) {
+	idx := int(((hash >> 32) * uint64(len(bf.blocks))) >> 32)
+	key := uint32(hash)
+
+	var mask [8]uint32
+	for i := range mask {
+		mask[i] = 1 << ((key * bloomFilterSalt[i]) >> 27)
+	}
+	return idx, mask
+}
+
+func (bf *bloomFilter) insert(hash uint64) {
+	idx, mask := bf.blockMask(hash)
+	for i := range mask {
+		bf.blocks[idx][i] |= mask[i]
+	}
+}
+
+func (bf *bloomFilter) check(hash uint64) bool {
+	idx, mask := bf.blockMask(hash)
+	for i := range mask {
+		if bf.blocks[idx][i]&mask[i] == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// bloomFilterHash returns the hash of a non-null value, which is the XXH64 hash of its plain encoding.
+func bloomFilterHash(v interface{}) uint64 {
+	return xxhash.Sum64(encodeStatValue(v))
+}
+
+// write writes the Bloom filter header and the bitset, which are encrypted if enc is not nil.
+func (bf *bloomFilter) write(w io.Writer, enc *columnEncryptor) error {
+	header := &parquet.BloomFilterHeader{
+		NumBytes: int32(len(bf.blocks) * bloomFilterBlockSize),
+		Algorithm: &parquet.BloomFilterAlgorithm{
+			BLOCK: parquet.NewSplitBlockAlgorithm(),
+		},
+		Hash: &parquet.BloomFilterHash{
+			XXHASH: parquet.NewXxHash(),
+		},
+		Compression: &parquet.BloomFilterCompression{
+			UNCOMPRESSED: parquet.NewUncompressed(),
+		},
+	}
+	if err := enc.writeThrift(header, w, moduleBloomFilterHeader, -1); err != nil {
+		return err
+	}
+
+	buf := make([]byte, len(bf.blocks)*bloomFilterBlockSize)
+	for i := range bf.blocks {
+		for j, word := range bf.blocks[i] {
+			binary.LittleEndian.PutUint32(buf[i*bloomFilterBlockSize+j*4:], word)
+		}
+	}
+
+	buf, err := enc.encrypt(moduleBloomFilterBitset, -1, buf)
+	if err != nil {
+		return err
+	}
+	return writeFull(w, buf)
+}
+
+// readBloomFilter reads the Bloom filter header and the bitset, which are decrypted if dec is not nil.
+func readBloomFilter(r io.Reader, dec *columnDecryptor) (*bloomFilter, error) {
+	header := &parquet.BloomFilterHeader{}
+	if err := dec.readThrift(header, r, moduleBloomFilterHeader, -1); err != nil {
+		return nil, err
+	}
+
+	if header.Algorithm == nil || header.Algorithm.BLOCK == nil {
+		return nil, errors.New("unsupported bloom filter algorithm")
+	}
+	if header.Hash == nil || header.Hash.XXHASH == nil {
+		return nil, errors.New("unsupported bloom filter hash")
+	}
+	if header.Compression == nil || header.Compression.UNCOMPRESSED == nil {
+		return nil, errors.New("unsupported bloom filter compression")
+	}
+	if header.NumBytes < minBloomFilterSize || header.NumBytes > maxBloomFilterSize || header.NumBytes%bloomFilterBlockSize != 0 {
+		return nil, errors.Errorf("invalid bloom filter size %d", header.NumBytes)
+	}
+
+	buf, err := readBloomFilterBitset(r, dec, int(header.NumBytes))
+	if err != nil {
+		return nil, errors.Wrap(err, "reading bloom filter bitset failed")
+	}
+
+	bf := newBloomFilter(int(header.NumBytes))
+	for i := range bf.blocks {
+		for j := range bf.blocks[i] {
+			bf.blocks[i][j] = binary.LittleEndian.Uint32(buf[i*bloomFilterBlockSize+j*4:])
+		}
+	}
+
+	return bf, nil
+}
+
+// readBloomFilterBitset reads the bitset of a Bloom filter of the provided size.
+func readBloomFilterBitset(r io.Reader, dec *columnDecryptor, size int) ([]byte, error) {
+	if dec == nil {
+		buf := make([]byte, size)
+		if _, err := io.ReadFull(r, buf); err != nil {
+			return nil, err
+		}
+		return buf, nil
+	}
+
+	data, err := readModule(r)
+	if err != nil {
+		return nil, err
+	}
+	if data, err = dec.decrypt(moduleBloomFilterBitset, -1, data); err != nil {
+		return nil, err
+	}
+	if len(data) != size {
+		return nil, errors.Errorf("invalid bitset size %d, expected %d", len(data), size)
+	}
+	return data, nil
+}
+
+// bloomFilterOptions describes the Bloom filter to write for a column.
+type bloomFilterOptions struct {
+	ndv int64
+	fpp float64
+}
+
+// writeBloomFilters writes a Bloom filter for every column chunk of the row group for which a
+// Bloom filter was configured, and sets the Bloom filter offset in the column chunk meta data.
+func (fw *FileWriter) writeBloomFilters(chunks []*parquet.ColumnChunk, encs []*columnEncryptor) error {
+	if len(fw.bloomFilters) == 0 {
+		return nil
+	}
+
+	for name := range fw.bloomFilters {
+		if fw.SchemaWriter.GetColumnByName(name) == nil {
+			return errors.Errorf("bloom filter column %q not found", name)
+		}
+	}
+
+	for i, col := range fw.SchemaWriter.Columns() {
+		opts, ok := fw.bloomFilters[col.FlatName()]
+		if !ok {
+			continue
+		}
+
+		values := col.data.values.values
+		ndv := opts.ndv
+		if ndv <= 0 {
+			ndv = int64(len(values))
+		}
+
+		bf := newBloomFilter(optimalBloomFilterSize(ndv, opts.fpp))
+		for _, v := range values {
+			bf.insert(bloomFilterHash(v))
+		}
+
+		pos := fw.w.Pos()
+		if err := bf.write(fw.w, columnEncryptorAt(encs, i)); err != nil {
+			return err
+		}
+		chunks[i].MetaData.BloomFilterOffset = &pos
+	}
+
+	return nil
+}
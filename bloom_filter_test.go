@@ -0,0 +1,156 @@
+package goparquet
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+
+	"github.com/fraugster/parquet-go/parquetschema"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOptimalBloomFilterSize(t *testing.T) {
+	require.Equal(t, 32, optimalBloomFilterSize(0, 0))
+	require.Equal(t, 32, optimalBloomFilterSize(1, 0.01))
+	require.Equal(t, 2048, optimalBloomFilterSize(1000, 0.01))
+	require.Equal(t, 4096, optimalBloomFilterSize(1000, 0.0001))
+	require.Equal(t, 2048, optimalBloomFilterSize(1000, 2))
+	require.Equal(t, maxBloomFilterSize, optimalBloomFilterSize(1<<40, 0.01))
+}
+
+func TestBloomFilterReadWrite(t *testing.T) {
+	bf := newBloomFilter(optimalBloomFilterSize(1000, 0.01))
+	for i := 0; i < 1000; i++ {
+		bf.insert(bloomFilterHash([]byte(fmt.Sprintf("value %d", i))))
+	}
+
+	buf := &bytes.Buffer{}
+	require.NoError(t, bf.write(buf, nil))
+
+	bf2, err := readBloomFilter(bytes.NewReader(buf.Bytes()), nil)
+	require.NoError(t, err)
+	require.Equal(t, bf, bf2)
+
+	for i := 0; i < 1000; i++ {
+		require.True(t, bf2.check(bloomFilterHash([]byte(fmt.Sprintf("value %d", i)))))
+	}
+
+	falsePositives := 0
+	for i := 1000; i < 11000; i++ {
+		if bf2.check(bloomFilterHash([]byte(fmt.Sprintf("value %d", i)))) {
+			falsePositives++
+		}
+	}
+	require.Less(t, falsePositives, 300)
+
+	_, err = readBloomFilter(bytes.NewReader(buf.Bytes()[:100]), nil)
+	require.Error(t, err)
+}
+
+func TestWriteBloomFilter(t *testing.T) {
+	sd, err := parquetschema.ParseSchemaDefinition(
+		`message test_msg {
+			required int64 id;
+			optional binary name (STRING);
+		}`)
+	require.NoError(t, err)
+
+	buf := &bytes.Buffer{}
+	w := NewFileWriter(buf, WithSchemaDefinition(sd), WithBloomFilter("id", 0, 0), WithBloomFilter("name", 1000, 0.001))
+
+	for i := 0; i < 200; i++ {
+		data := map[string]interface{}{"id": int64(i * 2)}
+		if i%2 == 0 {
+			data["name"] = []byte(fmt.Sprintf("name %d", i))
+		}
+		require.NoError(t, w.AddData(data))
+		if i == 99 {
+			require.NoError(t, w.FlushRowGroup())
+		}
+	}
+	require.NoError(t, w.Close())
+
+	r, err := NewFileReader(bytes.NewReader(buf.Bytes()))
+	require.NoError(t, err)
+
+	_, err = r.MightContain("id", 2)
+	require.EqualError(t, err, "no row group loaded, call PreLoad first")
+
+	// the Bloom filters of all row groups can be checked without loading them.
+	ok, err := r.RowGroupMightContain(0, "id", 2)
+	require.NoError(t, err)
+	require.True(t, ok)
+	ok, err = r.RowGroupMightContain(1, "id", 2)
+	require.NoError(t, err)
+	require.False(t, ok)
+	_, err = r.RowGroupMightContain(2, "id", 2)
+	require.EqualError(t, err, "row group 2 out of range")
+
+	for _, rg := range r.meta.RowGroups {
+		require.NotNil(t, rg.Columns[0].MetaData.BloomFilterOffset)
+		require.NotNil(t, rg.Columns[1].MetaData.BloomFilterOffset)
+	}
+
+	require.NoError(t, r.PreLoad())
+
+	falsePositives := 0
+	for i := 0; i < 200; i++ {
+		ok, err := r.MightContain("id", int64(i*2))
+		require.NoError(t, err)
+		if i < 100 {
+			require.True(t, ok, "id %d", i*2)
+		} else if ok {
+			falsePositives++
+		}
+	}
+	require.Less(t, falsePositives, 5)
+
+	ok, err = r.MightContain("name", "name 42")
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	ok, err = r.MightContain("name", "name 43")
+	require.NoError(t, err)
+	require.False(t, ok)
+
+	_, err = r.MightContain("unknown", 1)
+	require.Error(t, err)
+
+	_, err = r.MightContain("id", "foo")
+	require.Error(t, err)
+
+	r, err = NewFileReader(bytes.NewReader(buf.Bytes()))
+	require.NoError(t, err)
+	invalidOffset := int64(-1)
+	r.meta.RowGroups[0].Columns[0].MetaData.BloomFilterOffset = &invalidOffset
+	_, err = r.RowGroupMightContain(0, "id", 2)
+	require.EqualError(t, err, "invalid bloom filter offset -1")
+
+	r, err = NewFileReaderWithOptions(bytes.NewReader(buf.Bytes()), WithPredicate(ColumnPredicate("id", Equal, 303)))
+	require.NoError(t, err)
+	require.Len(t, readAllRows(t, r), 0)
+
+	r, err = NewFileReaderWithOptions(bytes.NewReader(buf.Bytes()), WithPredicate(ColumnPredicate("id", Equal, 302)))
+	require.NoError(t, err)
+	rows := readAllRows(t, r)
+	require.Len(t, rows, 100)
+	require.Equal(t, int64(200), rows[0]["id"])
+
+	r, err = NewFileReaderWithOptions(bytes.NewReader(buf.Bytes()), WithPredicate(Not(ColumnPredicate("name", Equal, "name 43"))))
+	require.NoError(t, err)
+	require.Len(t, readAllRows(t, r), 200)
+}
+
+func TestWriteBloomFilterUnknownColumn(t *testing.T) {
+	sd, err := parquetschema.ParseSchemaDefinition(
+		`message test_msg {
+			required int64 id;
+		}`)
+	require.NoError(t, err)
+
+	_, err = NewFileWriterWithError(&bytes.Buffer{}, WithSchemaDefinition(sd), WithBloomFilter("foo", 0, 0))
+	require.EqualError(t, err, `bloom filter column "foo" not found in schema definition`)
+
+	w := NewFileWriter(&bytes.Buffer{}, WithBloomFilter("foo", 0, 0))
+	require.EqualError(t, w.SetSchemaDefinition(sd), `bloom filter column "foo" not found in schema definition`)
+}
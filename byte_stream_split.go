@@ -0,0 +1,79 @@
+package goparquet
+
+import (
+	"io"
+	"io/ioutil"
+
+	"github.com/pkg/errors"
+)
+
+// byteStreamSplitDecoder decodes data in the BYTE_STREAM_SPLIT encoding. The encoding splits the
+// plain encoded values of width bytes into width streams, the k-th stream contains the k-th byte
+// of every value. The number of values is not part of the encoded data, it's derived from the
+// size of the data.
+type byteStreamSplitDecoder struct {
+	width int
+
+	data      []byte
+	numValues int
+	pos       int
+}
+
+func (d *byteStreamSplitDecoder) init(r io.Reader) error {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	if len(data)%d.width != 0 {
+		return errors.Errorf("byte stream split: data size %d is not a multiple of %d", len(data), d.width)
+	}
+
+	d.data = data
+	d.numValues = len(data) / d.width
+	d.pos = 0
+	return nil
+}
+
+// next copies the bytes of the next value into buf, which needs to be width bytes long.
+func (d *byteStreamSplitDecoder) next(buf []byte) error {
+	if d.pos >= d.numValues {
+		return io.EOF
+	}
+	for k := range buf {
+		buf[k] = d.data[k*d.numValues+d.pos]
+	}
+	d.pos++
+	return nil
+}
+
+// byteStreamSplitEncoder encodes data in the BYTE_STREAM_SPLIT encoding. Since every stream
+// contains one byte of every value, the values are buffered and only written on Close.
+type byteStreamSplitEncoder struct {
+	w     io.Writer
+	width int
+
+	// the plain encoded values.
+	data []byte
+}
+
+func (e *byteStreamSplitEncoder) init(w io.Writer) error {
+	e.w = w
+	e.data = e.data[:0]
+	return nil
+}
+
+// add appends the plain encoding of a value, which needs to be width bytes long.
+func (e *byteStreamSplitEncoder) add(buf []byte) {
+	e.data = append(e.data, buf...)
+}
+
+func (e *byteStreamSplitEncoder) Close() error {
+	numValues := len(e.data) / e.width
+	out := make([]byte, len(e.data))
+	for i := 0; i < numValues; i++ {
+		for k := 0; k < e.width; k++ {
+			out[k*numValues+i] = e.data[i*e.width+k]
+		}
+	}
+	return writeFull(e.w, out)
+}
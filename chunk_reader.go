@@ -1,9 +1,10 @@
 package goparquet
 
 import (
-	"fmt"
 	"io"
+	"math"
 	"math/bits"
+	"sync"
 
 	"github.com/pkg/errors"
 
@@ -162,6 +163,8 @@ func getValuesDecoder(pageEncoding parquet.Encoding, typ *parquet.SchemaElement,
 		switch pageEncoding {
 		case parquet.Encoding_PLAIN:
 			return &floatPlainDecoder{}, nil
+		case parquet.Encoding_BYTE_STREAM_SPLIT:
+			return newFloatByteStreamSplitDecoder(), nil
 		case parquet.Encoding_RLE_DICTIONARY:
 			return &dictDecoder{values: dictValues}, nil
 		}
@@ -170,6 +173,8 @@ func getValuesDecoder(pageEncoding parquet.Encoding, typ *parquet.SchemaElement,
 		switch pageEncoding {
 		case parquet.Encoding_PLAIN:
 			return &doublePlainDecoder{}, nil
+		case parquet.Encoding_BYTE_STREAM_SPLIT:
+			return newDoubleByteStreamSplitDecoder(), nil
 		case parquet.Encoding_RLE_DICTIONARY:
 			return &dictDecoder{values: dictValues}, nil
 		}
@@ -203,7 +208,56 @@ func createDataReader(r io.Reader, codec parquet.CompressionCodec, compressedSiz
 	return newBlockReader(r, codec, compressedSize, uncompressedSize)
 }
 
-func readPages(r *offsetReader, col *Column, chunkMeta *parquet.ColumnMetaData, dDecoder, rDecoder getLevelDecoder) ([]pageReader, error) {
+func readDictPage(r io.Reader, col *Column, ph *parquet.PageHeader, codec parquet.CompressionCodec) (*dictPageReader, error) {
+	p := &dictPageReader{}
+	de, err := getDictValuesDecoder(col.Element())
+	if err != nil {
+		return nil, err
+	}
+	if err := p.init(de); err != nil {
+		return nil, err
+	}
+
+	if err := p.read(r, ph, codec); err != nil {
+		return nil, err
+	}
+
+	return p, nil
+}
+
+func readDataPage(r io.Reader, col *Column, ph *parquet.PageHeader, codec parquet.CompressionCodec, dictPage *dictPageReader, dDecoder, rDecoder getLevelDecoder) (pageReader, error) {
+	var p pageReader
+	switch ph.Type {
+	case parquet.PageType_DATA_PAGE:
+		p = &dataPageReaderV1{
+			ph: ph,
+		}
+	case parquet.PageType_DATA_PAGE_V2:
+		p = &dataPageReaderV2{
+			ph: ph,
+		}
+	default:
+		return nil, errors.Errorf("DATA_PAGE or DATA_PAGE_V2 type supported, but was %s", ph.Type)
+	}
+	var dictValue []interface{}
+	if dictPage != nil {
+		dictValue = dictPage.values
+	}
+	var fn = func(typ parquet.Encoding) (valuesDecoder, error) {
+		return getValuesDecoder(typ, col.Element(), dictValue)
+	}
+	if err := p.init(dDecoder, rDecoder, fn); err != nil {
+		return nil, err
+	}
+
+	if err := p.read(r, ph, codec); err != nil {
+		return nil, err
+	}
+
+	return p, nil
+}
+
+func readPages(r *offsetReader, col *Column, chunkMeta *parquet.ColumnMetaData, dDecoder, rDecoder getLevelDecoder, crc *pageVerifier, dec *columnDecryptor) ([]pageReader, error) {
 	var (
 		dictPage *dictPageReader
 		pages    []pageReader
@@ -213,8 +267,14 @@ func readPages(r *offsetReader, col *Column, chunkMeta *parquet.ColumnMetaData,
 		if chunkMeta.TotalCompressedSize-r.Count() <= 0 {
 			break
 		}
-		ph := &parquet.PageHeader{}
-		if err := readThrift(ph, r); err != nil {
+		offset := r.offset
+		// an encrypted page header can only be decrypted if it's known whether it belongs to the dictionary page.
+		page := len(pages)
+		if page == 0 && dictPage == nil && hasDictionaryPage(chunkMeta) {
+			page = -1
+		}
+		ph, err := dec.readPageHeader(r, page)
+		if err != nil {
 			return nil, err
 		}
 
@@ -222,18 +282,12 @@ func readPages(r *offsetReader, col *Column, chunkMeta *parquet.ColumnMetaData,
 			if dictPage != nil {
 				return nil, errors.New("there should be only one dictionary")
 			}
-			p := &dictPageReader{}
-			de, err := getDictValuesDecoder(col.Element())
+			data, err := pageData(r, col, ph, -1, offset, crc, dec)
 			if err != nil {
 				return nil, err
 			}
-			if err := p.init(de); err != nil {
-				return nil, err
-			}
-
-			// re-use the value dictionary store
-			p.values = col.getColumnStore().values.values
-			if err := p.read(r, ph, chunkMeta.Codec); err != nil {
+			p, err := readDictPage(data, col, ph, chunkMeta.Codec)
+			if err != nil {
 				return nil, err
 			}
 
@@ -250,31 +304,12 @@ func readPages(r *offsetReader, col *Column, chunkMeta *parquet.ColumnMetaData,
 			continue // go to next page
 		}
 
-		var p pageReader
-		switch ph.Type {
-		case parquet.PageType_DATA_PAGE:
-			p = &dataPageReaderV1{
-				ph: ph,
-			}
-		case parquet.PageType_DATA_PAGE_V2:
-			p = &dataPageReaderV2{
-				ph: ph,
-			}
-		default:
-			return nil, errors.Errorf("DATA_PAGE or DATA_PAGE_V2 type supported, but was %s", ph.Type)
-		}
-		var dictValue []interface{}
-		if dictPage != nil {
-			dictValue = dictPage.values
-		}
-		var fn = func(typ parquet.Encoding) (valuesDecoder, error) {
-			return getValuesDecoder(typ, col.Element(), dictValue)
-		}
-		if err := p.init(dDecoder, rDecoder, fn); err != nil {
+		data, err := pageData(r, col, ph, len(pages), offset, crc, dec)
+		if err != nil {
 			return nil, err
 		}
-
-		if err := p.read(r, ph, chunkMeta.Codec); err != nil {
+		p, err := readDataPage(data, col, ph, chunkMeta.Codec, dictPage, dDecoder, rDecoder)
+		if err != nil {
 			return nil, err
 		}
 		pages = append(pages, p)
@@ -283,68 +318,169 @@ func readPages(r *offsetReader, col *Column, chunkMeta *parquet.ColumnMetaData,
 	return pages, nil
 }
 
-func skipChunk(r io.Seeker, col *Column, chunk *parquet.ColumnChunk) error {
-	if chunk.FilePath != nil {
-		return fmt.Errorf("nyi: data is in another file: '%s'", *chunk.FilePath)
+// readIndexedPages uses the offset index of a column chunk to only read the dictionary page and
+// the data pages that contain rows from the provided row ranges. It returns the pages that were
+// read and the index of their first row within the row group.
+func readIndexedPages(r io.ReadSeeker, col *Column, chunkMeta *parquet.ColumnMetaData, oi *parquet.OffsetIndex, ranges rowRanges, dDecoder, rDecoder getLevelDecoder, crc *pageVerifier, dec *columnDecryptor) ([]pageReader, []int64, error) {
+	var (
+		dictPage  *dictPageReader
+		pages     []pageReader
+		firstRows []int64
+	)
+
+	if len(oi.PageLocations) == 0 {
+		return nil, nil, nil
 	}
 
-	c := col.Index()
-	// chunk.FileOffset is useless so ChunkMetaData is required here
-	// as we cannot read it from r
-	// see https://issues.apache.org/jira/browse/PARQUET-291
-	if chunk.MetaData == nil {
-		return errors.Errorf("missing meta data for Column %c", c)
+	// The dictionary page is not part of the offset index, it's always located before the first data page.
+	dictOffset := int64(-1)
+	if chunkMeta.DictionaryPageOffset != nil && *chunkMeta.DictionaryPageOffset > 0 {
+		dictOffset = *chunkMeta.DictionaryPageOffset
+	} else if chunkMeta.DataPageOffset < oi.PageLocations[0].Offset {
+		dictOffset = chunkMeta.DataPageOffset
 	}
 
-	if typ := *col.Element().Type; chunk.MetaData.Type != typ {
-		return errors.Errorf("wrong type in Column chunk metadata, expected %s was %s",
-			typ, chunk.MetaData.Type)
+	if dictOffset >= 0 {
+		if _, err := r.Seek(dictOffset, io.SeekStart); err != nil {
+			return nil, nil, err
+		}
+		ph, err := dec.readPageHeader(r, -1)
+		if err != nil {
+			return nil, nil, err
+		}
+		if ph.Type != parquet.PageType_DICTIONARY_PAGE {
+			return nil, nil, errors.Errorf("expected DICTIONARY_PAGE at offset %d, but was %s", dictOffset, ph.Type)
+		}
+		data, err := pageData(r, col, ph, -1, dictOffset, crc, dec)
+		if err != nil {
+			return nil, nil, err
+		}
+		p, err := readDictPage(data, col, ph, chunkMeta.Codec)
+		if err != nil {
+			return nil, nil, err
+		}
+		dictPage = p
+	}
+
+	for i, loc := range oi.PageLocations {
+		pageRows := rowRange{from: loc.FirstRowIndex, to: math.MaxInt64}
+		if i+1 < len(oi.PageLocations) {
+			pageRows.to = oi.PageLocations[i+1].FirstRowIndex
+		}
+		if !ranges.overlaps(pageRows) {
+			continue
+		}
+
+		if _, err := r.Seek(loc.Offset, io.SeekStart); err != nil {
+			return nil, nil, err
+		}
+		ph, err := dec.readPageHeader(r, i)
+		if err != nil {
+			return nil, nil, err
+		}
+		data, err := pageData(r, col, ph, i, loc.Offset, crc, dec)
+		if err != nil {
+			return nil, nil, err
+		}
+		p, err := readDataPage(data, col, ph, chunkMeta.Codec, dictPage, dDecoder, rDecoder)
+		if err != nil {
+			return nil, nil, err
+		}
+		pages = append(pages, p)
+		firstRows = append(firstRows, loc.FirstRowIndex)
 	}
 
-	offset := chunk.MetaData.DataPageOffset
-	if chunk.MetaData.DictionaryPageOffset != nil {
-		offset = *chunk.MetaData.DictionaryPageOffset
+	return pages, firstRows, nil
+}
+
+// pageData returns a reader for the data of the page, which is located in r right after its header at
+// offset. page is the index of the data page within the column chunk, or -1 for the dictionary page. The
+// checksum of the page is verified before the page is decrypted, since it covers the encrypted data.
+func pageData(r io.Reader, col *Column, ph *parquet.PageHeader, page int, offset int64, crc *pageVerifier, dec *columnDecryptor) (io.Reader, error) {
+	data, err := crc.verify(r, col, ph, page, offset)
+	if err != nil {
+		return nil, err
 	}
+	return dec.decryptPage(data, ph, page)
+}
+
+// hasDictionaryPage returns whether the column chunk starts with a dictionary page according to its meta data.
+func hasDictionaryPage(chunkMeta *parquet.ColumnMetaData) bool {
+	return chunkMeta.DictionaryPageOffset != nil && *chunkMeta.DictionaryPageOffset > 0
+}
 
-	offset += chunk.MetaData.TotalCompressedSize
-	_, err := r.Seek(offset, io.SeekStart)
-	return err
+// rowGroupSelection describes the rows of a row group that need to be read.
+type rowGroupSelection struct {
+	ranges rowRanges
+
+	// the offset indexes per column chunk, nil if they are not available.
+	offsetIndexes []*parquet.OffsetIndex
 }
 
-func readChunk(r io.ReadSeeker, col *Column, chunk *parquet.ColumnChunk) ([]pageReader, error) {
-	if chunk.FilePath != nil {
-		return nil, fmt.Errorf("nyi: data is in another file: '%s'", *chunk.FilePath)
+// readChunk reads the pages of a column chunk. If sel is not nil and an offset index is available for the
+// column chunk, only the pages that contain selected rows are read. The index of the first row of every
+// page is returned alongside the pages; it is nil if all pages were read. If dec is not nil, the pages
+// are decrypted.
+func readChunk(r io.ReaderAt, col *Column, chunk *parquet.ColumnChunk, sel *rowGroupSelection, crc *pageVerifier, dec *columnDecryptor) ([]pageReader, []int64, error) {
+	if err := checkChunk(col, chunk); err != nil {
+		return nil, nil, err
 	}
 
+	dDecoder, rDecoder := levelDecoders(col)
+
+	// every column chunk is read using its own cursor, all offsets are relative to the start of the file.
+	rs := io.NewSectionReader(r, 0, math.MaxInt64)
+
+	c := col.Index()
+	if sel != nil && sel.offsetIndexes != nil && sel.offsetIndexes[c] != nil {
+		return readIndexedPages(rs, col, chunk.MetaData, sel.offsetIndexes[c], sel.ranges, dDecoder, rDecoder, crc, dec)
+	}
+
+	offset := chunkOffset(chunk.MetaData)
+	// Seek to the beginning of the first Page
+	_, err := rs.Seek(offset, io.SeekStart)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	reader := &offsetReader{
+		inner:  rs,
+		offset: offset,
+		count:  0,
+	}
+
+	pages, err := readPages(reader, col, chunk.MetaData, dDecoder, rDecoder, crc, dec)
+	return pages, nil, err
+}
+
+// checkChunk verifies that the column chunk can be read for the column.
+func checkChunk(col *Column, chunk *parquet.ColumnChunk) error {
 	c := col.Index()
 	// chunk.FileOffset is useless so ChunkMetaData is required here
 	// as we cannot read it from r
 	// see https://issues.apache.org/jira/browse/PARQUET-291
 	if chunk.MetaData == nil {
-		return nil, errors.Errorf("missing meta data for Column %c", c)
+		return errors.Errorf("missing meta data for Column %c", c)
 	}
 
 	if typ := *col.Element().Type; chunk.MetaData.Type != typ {
-		return nil, errors.Errorf("wrong type in Column chunk metadata, expected %s was %s",
+		return errors.Errorf("wrong type in Column chunk metadata, expected %s was %s",
 			typ, chunk.MetaData.Type)
 	}
 
-	offset := chunk.MetaData.DataPageOffset
-	if chunk.MetaData.DictionaryPageOffset != nil {
-		offset = *chunk.MetaData.DictionaryPageOffset
-	}
-	// Seek to the beginning of the first Page
-	_, err := r.Seek(offset, io.SeekStart)
-	if err != nil {
-		return nil, err
-	}
+	return nil
+}
 
-	reader := &offsetReader{
-		inner:  r,
-		offset: offset,
-		count:  0,
+// chunkOffset returns the offset of the first page of the column chunk.
+func chunkOffset(chunkMeta *parquet.ColumnMetaData) int64 {
+	if chunkMeta.DictionaryPageOffset != nil {
+		return *chunkMeta.DictionaryPageOffset
 	}
+	return chunkMeta.DataPageOffset
+}
 
+// levelDecoders returns the functions to create the definition and repetition level decoders of the column.
+func levelDecoders(col *Column) (getLevelDecoder, getLevelDecoder) {
 	rDecoder := func(enc parquet.Encoding) (levelDecoder, error) {
 		if enc != parquet.Encoding_RLE {
 			return nil, errors.Errorf("%q is not supported for definition and repetition level", enc)
@@ -374,54 +510,180 @@ func readChunk(r io.ReadSeeker, col *Column, chunk *parquet.ColumnChunk) ([]page
 			return &levelDecoderWrapper{decoder: constDecoder(0), max: col.MaxDefinitionLevel()}, nil
 		}
 	}
-	return readPages(reader, col, chunk.MetaData, dDecoder, rDecoder)
+
+	return dDecoder, rDecoder
 }
 
-func readPageData(col *Column, pages []pageReader) error {
-	s := col.getColumnStore()
+// readPageData decodes the pages into the column store. If ranges is not nil, only the rows within these
+// ranges are kept. firstRows contains the index of the first row of every page, if it is nil the pages
+// are expected to start with the first row of the row group and to follow each other without gaps.
+func readPageData(col *Column, pages []pageReader, firstRows []int64, ranges rowRanges) error {
+	row := int64(-1)
 	for i := range pages {
-		data := make([]interface{}, pages[i].numValues())
-		n, dl, rl, err := pages[i].readValues(data)
-		if err != nil {
+		if firstRows != nil {
+			row = firstRows[i] - 1
+		}
+		var err error
+		if row, err = appendPageData(col, pages[i], row, ranges); err != nil {
 			return err
 		}
+	}
 
-		if int32(n) != pages[i].numValues() {
-			return errors.Errorf("expect %d value but read %d", pages[i].numValues(), n)
-		}
+	return nil
+}
 
-		// using append to make sure we handle the multiple data page correctly
-		s.rLevels.appendArray(rl)
-		s.dLevels.appendArray(dl)
+// appendPageData decodes a page and appends it to the column store. If ranges is not nil, only the rows
+// within these ranges are kept. row is the index of the row before the first row of the page, the index
+// of the last row of the page is returned. It is only maintained if ranges is not nil.
+func appendPageData(col *Column, page pageReader, row int64, ranges rowRanges) (int64, error) {
+	s := col.getColumnStore()
+	data := make([]interface{}, page.numValues())
+	n, dl, rl, err := page.readValues(data)
+	if err != nil {
+		return 0, err
+	}
 
-		s.values.values = append(s.values.values, data...)
-		s.values.noDictMode = true
+	if int32(n) != page.numValues() {
+		return 0, errors.Errorf("expect %d value but read %d", page.numValues(), n)
 	}
 
-	return nil
+	if n == 0 {
+		return row, nil
+	}
+
+	if ranges != nil {
+		return appendSelectedRows(col, ranges, row, data, dl, rl)
+	}
+
+	// using append to make sure we handle the multiple data page correctly
+	s.rLevels.appendArray(rl)
+	s.dLevels.appendArray(dl)
+
+	// only the non-null values are decoded into data, the rest of it is empty and must
+	// not end up in between the values of two pages.
+	notNull := 0
+	for j := 0; j < dl.count; j++ {
+		if v, _ := dl.at(j); v == int32(col.MaxDefinitionLevel()) {
+			notNull++
+		}
+	}
+	s.values.values = append(s.values.values, data[:notNull]...)
+	s.values.noDictMode = true
+
+	return row, nil
 }
 
-func readRowGroup(r io.ReadSeeker, schema SchemaReader, rowGroups *parquet.RowGroup) error {
+// appendSelectedRows appends the levels and values of a page that belong to rows within ranges to
+// the column store. row is the index of the row before the first row of the page, the index of the
+// last row of the page is returned.
+func appendSelectedRows(col *Column, ranges rowRanges, row int64, data []interface{}, dl, rl *packedArray) (int64, error) {
+	s := col.getColumnStore()
+	maxD := int32(col.MaxDefinitionLevel())
+	valueIdx := 0
+	for j := 0; j < dl.count; j++ {
+		r, err := rl.at(j)
+		if err != nil {
+			return 0, err
+		}
+		d, err := dl.at(j)
+		if err != nil {
+			return 0, err
+		}
+		if r == 0 {
+			row++
+		}
+		if !ranges.contains(row) {
+			if d == maxD {
+				valueIdx++
+			}
+			continue
+		}
+		s.rLevels.appendSingle(r)
+		s.dLevels.appendSingle(d)
+		if d == maxD {
+			s.values.values = append(s.values.values, data[valueIdx])
+			valueIdx++
+		}
+	}
+	s.values.noDictMode = true
+	return row, nil
+}
+
+// readRowGroup reads the row group into the schema's column stores. If sel is not nil, only the
+// selected rows are read. If concurrency is larger than 1, up to concurrency column chunks are
+// read and decoded in parallel. If crc is not nil, the checksums of all pages are verified. decs contains
+// the decryptors of the column chunks, or is nil if the file isn't encrypted. files contains the files
+// that contain the selected column chunks.
+func readRowGroup(files []io.ReaderAt, schema SchemaReader, rowGroups *parquet.RowGroup, sel *rowGroupSelection, concurrency int, crc *pageVerifier, decs []*columnDecryptor) error {
 	dataCols := schema.Columns()
 	schema.resetData()
-	schema.setNumRecords(rowGroups.NumRows)
+	if sel != nil {
+		schema.setNumRecords(sel.ranges.numRows())
+	} else {
+		schema.setNumRecords(rowGroups.NumRows)
+	}
+
+	if concurrency > 1 {
+		return readColumnsConcurrently(files, schema, rowGroups, sel, concurrency, crc, decs)
+	}
+
 	for _, c := range dataCols {
-		chunk := rowGroups.Columns[c.Index()]
 		if !schema.isSelected(c.flatName) {
-			if err := skipChunk(r, c, chunk); err != nil {
-				return err
-			}
 			c.data.skipped = true
 			continue
 		}
-		pages, err := readChunk(r, c, chunk)
-		if err != nil {
-			return err
-		}
-		if err := readPageData(c, pages); err != nil {
+		if err := readColumn(files[c.Index()], c, rowGroups.Columns[c.Index()], sel, crc, columnDecryptorAt(decs, c.Index())); err != nil {
 			return err
 		}
 	}
 
 	return nil
 }
+
+// readColumn reads the column chunk into the column store of the column.
+func readColumn(r io.ReaderAt, col *Column, chunk *parquet.ColumnChunk, sel *rowGroupSelection, crc *pageVerifier, dec *columnDecryptor) error {
+	pages, firstRows, err := readChunk(r, col, chunk, sel, crc, dec)
+	if err != nil {
+		return err
+	}
+	var ranges rowRanges
+	if sel != nil {
+		ranges = sel.ranges
+	}
+	return readPageData(col, pages, firstRows, ranges)
+}
+
+// readColumnsConcurrently reads the selected column chunks of the row group using up to concurrency
+// goroutines.
+func readColumnsConcurrently(files []io.ReaderAt, schema SchemaReader, rowGroup *parquet.RowGroup, sel *rowGroupSelection, concurrency int, crc *pageVerifier, decs []*columnDecryptor) error {
+	var (
+		wg       sync.WaitGroup
+		errOnce  sync.Once
+		firstErr error
+	)
+
+	sem := make(chan struct{}, concurrency)
+	for _, c := range schema.Columns() {
+		if !schema.isSelected(c.flatName) {
+			c.data.skipped = true
+			continue
+		}
+
+		sem <- struct{}{}
+		wg.Add(1)
+		go func(r io.ReaderAt, c *Column, chunk *parquet.ColumnChunk, dec *columnDecryptor) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			if err := readColumn(r, c, chunk, sel, crc, dec); err != nil {
+				errOnce.Do(func() {
+					firstErr = err
+				})
+			}
+		}(files[c.Index()], c, rowGroup.Columns[c.Index()], columnDecryptorAt(decs, c.Index()))
+	}
+	wg.Wait()
+
+	return firstErr
+}
@@ -0,0 +1,273 @@
+package goparquet
+
+import (
+	"io"
+	"math"
+	"math/bits"
+
+	"github.com/fraugster/parquet-go/parquet"
+	"github.com/pkg/errors"
+)
+
+// chunkStream reads the pages of a column chunk one at a time. It is used by the column store
+// when reading in streaming mode to only keep a single page per column in memory.
+type chunkStream struct {
+	r     io.ReadSeeker
+	col   *Column
+	meta  *parquet.ColumnMetaData
+	codec parquet.CompressionCodec
+
+	dDecoder, rDecoder getLevelDecoder
+
+	dictPage *dictPageReader
+	crc      *pageVerifier
+	dec      *columnDecryptor
+
+	// the offset of the next page, and the offset of the end of the column chunk.
+	offset, end int64
+	// the index of the next data page within the column chunk.
+	page int
+
+	// the offset index and the indexes of the selected pages within it if an offset index is used.
+	indexed     bool
+	dictRead    bool
+	offsetIndex *parquet.OffsetIndex
+	selected    []int
+
+	// the selected rows, nil if all rows are read, and the index of the last row that was read.
+	ranges rowRanges
+	row    int64
+
+	err error
+}
+
+// newChunkStream creates a stream for the column chunk. If sel is not nil and an offset index is
+// available for the column chunk, only the pages that contain selected rows are read. If crc is
+// not nil, the checksums of the pages are verified. If dec is not nil, the pages are decrypted.
+func newChunkStream(r io.ReaderAt, col *Column, chunk *parquet.ColumnChunk, sel *rowGroupSelection, crc *pageVerifier, dec *columnDecryptor) (*chunkStream, error) {
+	if err := checkChunk(col, chunk); err != nil {
+		return nil, err
+	}
+
+	s := &chunkStream{
+		r:     io.NewSectionReader(r, 0, math.MaxInt64),
+		col:   col,
+		meta:  chunk.MetaData,
+		codec: chunk.MetaData.Codec,
+		crc:   crc,
+		dec:   dec,
+		row:   -1,
+	}
+	s.dDecoder, s.rDecoder = levelDecoders(col)
+	s.offset = chunkOffset(chunk.MetaData)
+	s.end = s.offset + chunk.MetaData.TotalCompressedSize
+
+	if sel == nil {
+		return s, nil
+	}
+
+	s.ranges = sel.ranges
+	if sel.offsetIndexes == nil || sel.offsetIndexes[col.Index()] == nil {
+		return s, nil
+	}
+
+	s.indexed = true
+	oi := sel.offsetIndexes[col.Index()]
+	s.offsetIndex = oi
+	for i, loc := range oi.PageLocations {
+		pageRows := rowRange{from: loc.FirstRowIndex, to: math.MaxInt64}
+		if i+1 < len(oi.PageLocations) {
+			pageRows.to = oi.PageLocations[i+1].FirstRowIndex
+		}
+		if sel.ranges.overlaps(pageRows) {
+			s.selected = append(s.selected, i)
+		}
+	}
+
+	// The dictionary page is not part of the offset index, it's always located before the first data page.
+	s.offset = -1
+	if chunk.MetaData.DictionaryPageOffset != nil && *chunk.MetaData.DictionaryPageOffset > 0 {
+		s.offset = *chunk.MetaData.DictionaryPageOffset
+	} else if len(oi.PageLocations) > 0 && chunk.MetaData.DataPageOffset < oi.PageLocations[0].Offset {
+		s.offset = chunk.MetaData.DataPageOffset
+	}
+
+	return s, nil
+}
+
+// readPageHeader seeks to offset and reads the page header located there. page is the index of the
+// data page within the column chunk, or -1 for the dictionary page. The returned reader is
+// positioned at the beginning of the page data.
+func (s *chunkStream) readPageHeader(offset int64, page int) (*offsetReader, *parquet.PageHeader, error) {
+	if _, err := s.r.Seek(offset, io.SeekStart); err != nil {
+		return nil, nil, err
+	}
+
+	reader := &offsetReader{
+		inner:  s.r,
+		offset: offset,
+	}
+	ph, err := s.dec.readPageHeader(reader, page)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return reader, ph, nil
+}
+
+// nextPage reads the next data page of the column chunk, and the index of its first row if it
+// is known. It returns io.EOF if there are no pages left.
+func (s *chunkStream) nextPage() (pageReader, int64, error) {
+	if s.indexed {
+		return s.nextIndexedPage()
+	}
+
+	for s.offset < s.end {
+		page := s.page
+		if page == 0 && s.dictPage == nil && hasDictionaryPage(s.meta) {
+			page = -1
+		}
+		reader, ph, err := s.readPageHeader(s.offset, page)
+		if err != nil {
+			return nil, 0, err
+		}
+
+		if ph.Type == parquet.PageType_DICTIONARY_PAGE {
+			if s.dictPage != nil {
+				return nil, 0, errors.New("there should be only one dictionary")
+			}
+			data, err := pageData(reader, s.col, ph, -1, s.offset, s.crc, s.dec)
+			if err != nil {
+				return nil, 0, err
+			}
+			if s.dictPage, err = readDictPage(data, s.col, ph, s.codec); err != nil {
+				return nil, 0, err
+			}
+
+			s.offset = reader.offset
+			// if we have a DictionaryPageOffset we should continue at the DataPageOffset
+			if s.meta.DictionaryPageOffset != nil {
+				s.offset = s.meta.DataPageOffset
+			}
+			continue
+		}
+
+		data, err := pageData(reader, s.col, ph, s.page, s.offset, s.crc, s.dec)
+		if err != nil {
+			return nil, 0, err
+		}
+		p, err := readDataPage(data, s.col, ph, s.codec, s.dictPage, s.dDecoder, s.rDecoder)
+		if err != nil {
+			return nil, 0, err
+		}
+		s.offset = reader.offset
+		s.page++
+		return p, -1, nil
+	}
+
+	return nil, 0, io.EOF
+}
+
+func (s *chunkStream) nextIndexedPage() (pageReader, int64, error) {
+	if !s.dictRead && s.offset >= 0 {
+		reader, ph, err := s.readPageHeader(s.offset, -1)
+		if err != nil {
+			return nil, 0, err
+		}
+		if ph.Type != parquet.PageType_DICTIONARY_PAGE {
+			return nil, 0, errors.Errorf("expected DICTIONARY_PAGE at offset %d, but was %s", s.offset, ph.Type)
+		}
+		data, err := pageData(reader, s.col, ph, -1, s.offset, s.crc, s.dec)
+		if err != nil {
+			return nil, 0, err
+		}
+		if s.dictPage, err = readDictPage(data, s.col, ph, s.codec); err != nil {
+			return nil, 0, err
+		}
+	}
+	s.dictRead = true
+
+	if len(s.selected) == 0 {
+		return nil, 0, io.EOF
+	}
+
+	page := s.selected[0]
+	s.selected = s.selected[1:]
+	loc := s.offsetIndex.PageLocations[page]
+
+	reader, ph, err := s.readPageHeader(loc.Offset, page)
+	if err != nil {
+		return nil, 0, err
+	}
+	data, err := pageData(reader, s.col, ph, page, loc.Offset, s.crc, s.dec)
+	if err != nil {
+		return nil, 0, err
+	}
+	p, err := readDataPage(data, s.col, ph, s.codec, s.dictPage, s.dDecoder, s.rDecoder)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return p, loc.FirstRowIndex, nil
+}
+
+// fill replaces the content of the column store with the next page of the column chunk that
+// contains selected rows. If there are no pages left, the column store remains empty.
+func (s *chunkStream) fill() error {
+	if s.err != nil {
+		return s.err
+	}
+
+	cs := s.col.getColumnStore()
+	cs.values.init()
+	cs.rLevels.reset(bits.Len16(s.col.MaxRepetitionLevel()))
+	cs.dLevels.reset(bits.Len16(s.col.MaxDefinitionLevel()))
+	cs.readPos = 0
+
+	for cs.rLevels.count == 0 {
+		p, firstRow, err := s.nextPage()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			s.err = err
+			return err
+		}
+
+		if firstRow >= 0 {
+			s.row = firstRow - 1
+		}
+		if s.row, err = appendPageData(s.col, p, s.row, s.ranges); err != nil {
+			s.err = err
+			return err
+		}
+	}
+
+	return nil
+}
+
+// streamRowGroup prepares the schema's column stores to read the row group page by page. If sel is not
+// nil, only the selected rows are read. If crc is not nil, the checksums of all pages are verified. decs
+// contains the decryptors of the column chunks, or is nil if the file isn't encrypted. files contains the
+// files that contain the selected column chunks.
+func streamRowGroup(files []io.ReaderAt, schema SchemaReader, rowGroup *parquet.RowGroup, sel *rowGroupSelection, crc *pageVerifier, decs []*columnDecryptor) error {
+	schema.resetData()
+	if sel != nil {
+		schema.setNumRecords(sel.ranges.numRows())
+	} else {
+		schema.setNumRecords(rowGroup.NumRows)
+	}
+	for _, c := range schema.Columns() {
+		if !schema.isSelected(c.flatName) {
+			c.data.skipped = true
+			continue
+		}
+		stream, err := newChunkStream(files[c.Index()], c, rowGroup.Columns[c.Index()], sel, crc, columnDecryptorAt(decs, c.Index()))
+		if err != nil {
+			return err
+		}
+		c.data.stream = stream
+	}
+
+	return nil
+}
@@ -0,0 +1,176 @@
+package goparquet
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"testing"
+
+	"github.com/fraugster/parquet-go/parquetschema"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStreamingRead(t *testing.T) {
+	tests := []struct {
+		name    string
+		options []FileWriterOption
+	}{
+		{"single_page", nil},
+		{"multiple_pages", []FileWriterOption{WithMaxPageRowCount(7)}},
+		{"multiple_pages_v2", []FileWriterOption{WithMaxPageRowCount(7), WithDataPageV2()}},
+		{"page_index", []FileWriterOption{WithMaxPageRowCount(10), WithPageIndex()}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			file := writePredicateTestFile(t, tt.options...)
+
+			r, err := NewFileReader(bytes.NewReader(file))
+			require.NoError(t, err)
+			expected := readAllRows(t, r)
+			require.Len(t, expected, 100)
+
+			r, err = NewFileReaderWithOptions(bytes.NewReader(file), WithStreaming())
+			require.NoError(t, err)
+			require.Equal(t, expected, readAllRows(t, r))
+
+			r, err = NewFileReaderWithOptions(bytes.NewReader(file), WithStreaming(), WithColumns("baz"))
+			require.NoError(t, err)
+			rows := readAllRows(t, r)
+			require.Len(t, rows, 100)
+			for i := range rows {
+				if baz, ok := expected[i]["baz"]; ok {
+					require.Equal(t, map[string]interface{}{"baz": baz}, rows[i])
+				} else {
+					require.Empty(t, rows[i])
+				}
+			}
+
+			r, err = NewFileReaderWithOptions(bytes.NewReader(file), WithStreaming(), WithPredicate(ColumnPredicate("foo", GreaterThanOrEqual, 75)))
+			require.NoError(t, err)
+			rows = readAllRows(t, r)
+			if tt.name == "page_index" {
+				require.Equal(t, expected[70:], rows)
+			} else {
+				require.Equal(t, expected[50:], rows)
+			}
+		})
+	}
+}
+
+func TestStreamingReadBoundedMemory(t *testing.T) {
+	file := writePredicateTestFile(t, WithMaxPageRowCount(10))
+
+	r, err := NewFileReaderWithOptions(bytes.NewReader(file), WithStreaming())
+	require.NoError(t, err)
+
+	for i := 0; ; i++ {
+		row, err := r.NextRow()
+		if err == io.EOF {
+			require.Equal(t, 100, i)
+			break
+		}
+		require.NoError(t, err)
+		require.Equal(t, predicateTestRow(i), row)
+
+		for _, col := range r.Columns() {
+			require.LessOrEqual(t, col.data.rLevels.count, 10*3, "column %s", col.FlatName())
+		}
+	}
+}
+
+func TestStreamingReadNested(t *testing.T) {
+	sd, err := parquetschema.ParseSchemaDefinition(
+		`message test_msg {
+			required int64 id;
+			optional group items (LIST) {
+				repeated group list {
+					required binary element (STRING);
+				}
+			}
+			repeated group entries {
+				required int32 key;
+				optional group value {
+					repeated double numbers;
+				}
+			}
+		}`)
+	require.NoError(t, err)
+
+	buf := &bytes.Buffer{}
+	w := NewFileWriter(buf, WithSchemaDefinition(sd), WithMaxPageRowCount(3))
+
+	for i := 0; i < 50; i++ {
+		data := map[string]interface{}{"id": int64(i)}
+		if i%5 != 0 && i%4 != 0 {
+			var list []map[string]interface{}
+			for j := 0; j < i%4; j++ {
+				list = append(list, map[string]interface{}{"element": []byte(fmt.Sprintf("item %d", j))})
+			}
+			data["items"] = map[string]interface{}{"list": list}
+		}
+		var entries []map[string]interface{}
+		for j := 0; j < i%3; j++ {
+			entry := map[string]interface{}{"key": int32(j)}
+			if j%2 == 0 {
+				entry["value"] = map[string]interface{}{"numbers": []float64{float64(i), float64(j)}}
+			}
+			entries = append(entries, entry)
+		}
+		if len(entries) > 0 {
+			data["entries"] = entries
+		}
+		require.NoError(t, w.AddData(data))
+		if i == 29 {
+			require.NoError(t, w.FlushRowGroup())
+		}
+	}
+	require.NoError(t, w.Close())
+
+	r, err := NewFileReader(bytes.NewReader(buf.Bytes()))
+	require.NoError(t, err)
+	expected := readAllRows(t, r)
+	require.Len(t, expected, 50)
+
+	r, err = NewFileReaderWithOptions(bytes.NewReader(buf.Bytes()), WithStreaming())
+	require.NoError(t, err)
+	require.Equal(t, expected, readAllRows(t, r))
+}
+
+func TestStreamingReadCorruptPage(t *testing.T) {
+	sd, err := parquetschema.ParseSchemaDefinition(`message test {
+		repeated int64 values;
+	}`)
+	require.NoError(t, err)
+
+	buf := &bytes.Buffer{}
+	w := NewFileWriter(buf, WithSchemaDefinition(sd), WithMaxPageRowCount(10), WithCRC())
+	for i := 0; i < 20; i++ {
+		require.NoError(t, w.AddData(map[string]interface{}{"values": []int64{int64(i), int64(i + 1)}}))
+	}
+	require.NoError(t, w.Close())
+
+	// corrupt the last byte of the second and last page of the column chunk.
+	file := buf.Bytes()
+	chunk := w.FileMetaData().RowGroups[0].Columns[0].MetaData
+	start := chunk.DataPageOffset
+	if chunk.DictionaryPageOffset != nil && *chunk.DictionaryPageOffset < start {
+		start = *chunk.DictionaryPageOffset
+	}
+	file[start+chunk.TotalCompressedSize-1] ^= 0xff
+
+	r, err := NewFileReaderWithOptions(bytes.NewReader(file), WithStreaming(), WithCRC32Validation())
+	require.NoError(t, err)
+	for i := 0; ; i++ {
+		row, err := r.NextRow()
+		if err != nil {
+			// the last record of the first page is read when the second page is loaded.
+			require.Equal(t, 9, i)
+			var checksumErr *PageChecksumError
+			require.True(t, errors.As(err, &checksumErr), "%v", err)
+			break
+		}
+		require.Equal(t, map[string]interface{}{"values": []int64{int64(i), int64(i + 1)}}, row)
+	}
+}
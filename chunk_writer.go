@@ -1,6 +1,8 @@
 package goparquet
 
 import (
+	"bytes"
+	"io"
 	"sort"
 
 	"github.com/fraugster/parquet-go/parquet"
@@ -62,7 +64,7 @@ func getInt32ValuesEncoder(pageEncoding parquet.Encoding, typ *parquet.SchemaEle
 	case parquet.Encoding_PLAIN:
 		return &int32PlainEncoder{unSigned: unSigned}, nil
 	case parquet.Encoding_DELTA_BINARY_PACKED:
-		return &int32DeltaBPEncoder{unSigned: unSigned}, nil
+		return newInt32DeltaBPEncoder(unSigned), nil
 	case parquet.Encoding_RLE_DICTIONARY:
 		return &dictEncoder{
 			dictStore: *store,
@@ -86,7 +88,7 @@ func getInt64ValuesEncoder(pageEncoding parquet.Encoding, typ *parquet.SchemaEle
 	case parquet.Encoding_PLAIN:
 		return &int64PlainEncoder{unSigned: unSigned}, nil
 	case parquet.Encoding_DELTA_BINARY_PACKED:
-		return &int64DeltaBPEncoder{unSigned: unSigned}, nil
+		return newInt64DeltaBPEncoder(unSigned), nil
 	case parquet.Encoding_RLE_DICTIONARY:
 		return &dictEncoder{
 			dictStore: *store,
@@ -119,6 +121,8 @@ func getValuesEncoder(pageEncoding parquet.Encoding, typ *parquet.SchemaElement,
 		switch pageEncoding {
 		case parquet.Encoding_PLAIN:
 			return &floatPlainEncoder{}, nil
+		case parquet.Encoding_BYTE_STREAM_SPLIT:
+			return newFloatByteStreamSplitEncoder(), nil
 		case parquet.Encoding_RLE_DICTIONARY:
 			return &dictEncoder{
 				dictStore: *store,
@@ -129,6 +133,8 @@ func getValuesEncoder(pageEncoding parquet.Encoding, typ *parquet.SchemaElement,
 		switch pageEncoding {
 		case parquet.Encoding_PLAIN:
 			return &doublePlainEncoder{}, nil
+		case parquet.Encoding_BYTE_STREAM_SPLIT:
+			return newDoubleByteStreamSplitEncoder(), nil
 		case parquet.Encoding_RLE_DICTIONARY:
 			return &dictEncoder{
 				dictStore: *store,
@@ -200,12 +206,191 @@ func getDictValuesEncoder(typ *parquet.SchemaElement) (valuesEncoder, error) {
 	return nil, errors.Errorf("type %s is not supported for dict value encoder", typ)
 }
 
-func writeChunk(w writePos, schema SchemaWriter, col *Column, codec parquet.CompressionCodec, pageFn newDataPageFunc, kvMetaData map[string]string) (*parquet.ColumnChunk, error) {
+// pageWriteOptions contains the settings for writing a single page.
+type pageWriteOptions struct {
+	withCRC bool
+	// enc encrypts the page header and the page data, it is nil if the column chunk isn't encrypted.
+	enc *columnEncryptor
+	// ordinal is the index of the data page within the column chunk, or -1 for the dictionary page.
+	ordinal int
+}
+
+// writePage writes the page header followed by the page data, which consists of the concatenation
+// of data. The compressed page size and the CRC32 checksum in the header are set for the page
+// data as it is written to the file, i.e. after encryption. It returns the size of the page data.
+func (o pageWriteOptions) writePage(w io.Writer, header *parquet.PageHeader, data ...[]byte) (int, error) {
+	headerModule, dataModule := moduleDataPageHeader, moduleDataPage
+	if o.ordinal < 0 {
+		headerModule, dataModule = moduleDictionaryPageHeader, moduleDictionaryPage
+	}
+
+	if o.enc != nil {
+		page, err := o.enc.encrypt(dataModule, o.ordinal, bytes.Join(data, nil))
+		if err != nil {
+			return 0, err
+		}
+		data = [][]byte{page}
+	}
+
+	var size int
+	for _, d := range data {
+		size += len(d)
+	}
+	header.CompressedPageSize = int32(size)
+	if o.withCRC {
+		header.Crc = pageCRC(data...)
+	}
+
+	if err := o.enc.writeThrift(header, w, headerModule, o.ordinal); err != nil {
+		return 0, err
+	}
+	for _, d := range data {
+		if err := writeFull(w, d); err != nil {
+			return 0, err
+		}
+	}
+
+	return size, nil
+}
+
+// dataPage contains the part of a column chunk's data that is written to a single data page.
+type dataPage struct {
+	rLevels, dLevels *packedArray
+
+	// indices contains the position of each non-null value of the page in the column's dictionary store.
+	indices []int32
+
+	numValues int32 // the number of values in the page, including null values
+	numNulls  int32
+	numRows   int32
+
+	stats minMaxTracker
+}
+
+func (p *dataPage) values(store *dictStore) []interface{} {
+	ret := make([]interface{}, len(p.indices))
+	for i, idx := range p.indices {
+		ret[i] = store.values[idx]
+	}
+	return ret
+}
+
+func (p *dataPage) statistics(elem *parquet.SchemaElement) *parquet.Statistics {
+	return p.stats.statistics(elem, int64(p.numNulls))
+}
+
+// splitDataPages divides the data of a column into data pages. A new page is started as soon as
+// the current page contains at least maxPageRowCount rows or at least maxPageSize bytes of values.
+// Pages always start at a row boundary. If neither limit is set, all data is put into a single page.
+func splitDataPages(col *Column, maxPageSize, maxPageRowCount int64, maxStatsLength int) []*dataPage {
+	cs := col.data
+	maxD := int32(col.MaxDefinitionLevel())
+
+	var (
+		pages                  []*dataPage
+		levelStart, valueStart int
+		valuePos               int
+		numRows, size          int64
+	)
+	stats := newMinMaxTracker(col.Element(), maxStatsLength)
+
+	addPage := func(levelEnd int) {
+		numValues := levelEnd - levelStart
+		indices := cs.values.data[valueStart:valuePos]
+		pages = append(pages, &dataPage{
+			rLevels:   cs.rLevels.slice(levelStart, levelEnd),
+			dLevels:   cs.dLevels.slice(levelStart, levelEnd),
+			indices:   indices,
+			numValues: int32(numValues),
+			numNulls:  int32(numValues - len(indices)),
+			numRows:   int32(numRows),
+			stats:     stats,
+		})
+	}
+
+	count := cs.dLevels.count
+	for i := 0; i < count; i++ {
+		rl, dl, _, _ := cs.getRDLevelAt(i)
+		if rl == 0 {
+			pageFull := (maxPageRowCount > 0 && numRows >= maxPageRowCount) || (maxPageSize > 0 && size >= maxPageSize)
+			if i > levelStart && pageFull {
+				addPage(i)
+				levelStart, valueStart = i, valuePos
+				numRows, size = 0, 0
+				stats = newMinMaxTracker(col.Element(), maxStatsLength)
+			}
+			numRows++
+		}
+		if dl == maxD {
+			v := cs.values.values[cs.values.data[valuePos]]
+			size += int64(cs.sizeOf(v))
+			stats.add(v)
+			valuePos++
+		}
+	}
+	addPage(count)
+
+	return pages
+}
+
+// dictionaryPageCount returns the number of leading pages of a column chunk that can be dictionary
+// encoded without the dictionary exceeding maxDictSize bytes, and the number of dictionary values
+// used by these pages. The dictionary store keeps its values in the order of their first
+// occurrence, so the dictionary of the leading pages is always a prefix of the store's values.
+// If maxDictSize is 0 or less, the size of the dictionary is not limited.
+func dictionaryPageCount(col *Column, pages []*dataPage, maxDictSize int64) (numPages int, numValues int) {
+	cs := col.data
+	var dictSize int64
+	for _, p := range pages {
+		n, size := numValues, dictSize
+		for _, idx := range p.indices {
+			for ; int(idx) >= n; n++ {
+				size += int64(cs.sizeOf(cs.values.values[n]))
+			}
+		}
+		if maxDictSize > 0 && size > maxDictSize {
+			break
+		}
+		numPages, numValues, dictSize = numPages+1, n, size
+	}
+
+	return numPages, numValues
+}
+
+// chunkEncodings returns the list of encodings and the encoding stats of a column chunk. If the
+// chunk has a dictionary, its first numDictPages data pages are dictionary encoded and the remaining
+// pages fall back to the column's encoding.
+func chunkEncodings(col *Column, dataPageType parquet.PageType, numDictPages, numPages int) ([]parquet.Encoding, []*parquet.PageEncodingStats) {
+	enc := col.data.encoding()
+	if numDictPages == 0 {
+		return []parquet.Encoding{parquet.Encoding_RLE, enc}, []*parquet.PageEncodingStats{
+			{PageType: dataPageType, Encoding: enc, Count: int32(numPages)},
+		}
+	}
+
+	// In dictionary we use PLAIN for the data, not the column encoding
+	encodings := []parquet.Encoding{parquet.Encoding_RLE, parquet.Encoding_PLAIN, parquet.Encoding_RLE_DICTIONARY}
+	stats := []*parquet.PageEncodingStats{
+		{PageType: parquet.PageType_DICTIONARY_PAGE, Encoding: parquet.Encoding_PLAIN, Count: 1},
+		{PageType: dataPageType, Encoding: parquet.Encoding_RLE_DICTIONARY, Count: int32(numDictPages)},
+	}
+	if numDictPages < numPages {
+		if enc != parquet.Encoding_PLAIN {
+			encodings = append(encodings, enc)
+		}
+		stats = append(stats, &parquet.PageEncodingStats{PageType: dataPageType, Encoding: enc, Count: int32(numPages - numDictPages)})
+	}
+
+	return encodings, stats
+}
+
+// writeChunk writes a column chunk. If enc is not nil, all pages of the column chunk are
+// encrypted.
+func (fw *FileWriter) writeChunk(w writePos, col *Column, enc *columnEncryptor, kvMetaData map[string]string) (*parquet.ColumnChunk, *pageIndex, error) {
 	pos := w.Pos() // Save the position before writing data
 	chunkOffset := pos
 	var (
 		dictPageOffset *int64
-		useDict        bool
 		// NOTE :
 		// This is documentation on these two field :
 		//  - TotalUncompressedSize: total byte size of all uncompressed pages in this column chunk (including the headers) *
@@ -215,17 +400,27 @@ func writeChunk(w writePos, schema SchemaWriter, col *Column, codec parquet.Comp
 		totalComp   int64
 		totalUnComp int64
 	)
+
+	codec := fw.compressionCodec(col.FlatName())
+	pages := splitDataPages(col, fw.maxPageSize, fw.maxPageRowCount, fw.maxStatsLength)
+
+	// The pages are dictionary encoded until the dictionary reaches its maximum size, the
+	// remaining pages fall back to the column's encoding.
+	var numDictPages, numDictValues int
 	if col.data.useDictionary() {
-		useDict = true
+		numDictPages, numDictValues = dictionaryPageCount(col, pages, fw.maxDictionarySize(col.FlatName()))
+	}
+
+	if numDictPages > 0 {
 		tmp := pos // make a copy, do not use the pos here
 		dictPageOffset = &tmp
-		dict := &dictPageWriter{}
-		if err := dict.init(schema, col, codec); err != nil {
-			return nil, err
+		dict := &dictPageWriter{opts: pageWriteOptions{withCRC: fw.withCRC, enc: enc, ordinal: -1}}
+		if err := dict.init(col, codec, col.data.values.values[:numDictValues]); err != nil {
+			return nil, nil, err
 		}
 		compSize, unCompSize, err := dict.write(w)
 		if err != nil {
-			return nil, err
+			return nil, nil, err
 		}
 		totalComp = w.Pos() - pos
 		// Header size plus the rLevel and dLevel size
@@ -234,32 +429,37 @@ func writeChunk(w writePos, schema SchemaWriter, col *Column, codec parquet.Comp
 		pos = w.Pos() // Move position for data pos
 	}
 
-	page := pageFn(useDict)
-
-	if err := page.init(schema, col, codec); err != nil {
-		return nil, err
-	}
+	dataPageOffset := pos
+	dataPageType := parquet.PageType_DATA_PAGE
+	index := newPageIndex()
+	index.enc = enc
+	var firstRow int64
+	for i, p := range pages {
+		page := fw.newPage(i < numDictPages, pageWriteOptions{withCRC: fw.withCRC, enc: enc, ordinal: i})
+		dataPageType = page.pageType()
+
+		if err := page.init(col, codec, p); err != nil {
+			return nil, nil, err
+		}
 
-	compSize, unCompSize, err := page.write(w)
-	if err != nil {
-		return nil, err
-	}
+		compSize, unCompSize, err := page.write(w)
+		if err != nil {
+			return nil, nil, err
+		}
 
-	totalComp += w.Pos() - pos
-	// Header size plus the rLevel and dLevel size
-	headerSize := totalComp - int64(compSize)
-	totalUnComp += int64(unCompSize) + headerSize
+		pageSize := w.Pos() - pos
+		totalComp += pageSize
+		// Header size plus the rLevel and dLevel size
+		headerSize := pageSize - int64(compSize)
+		totalUnComp += int64(unCompSize) + headerSize
 
-	encodings := make([]parquet.Encoding, 0, 3)
-	encodings = append(encodings,
-		parquet.Encoding_RLE,
-		col.data.encoding(),
-	)
-	if useDict {
-		encodings[1] = parquet.Encoding_PLAIN // In dictionary we use PLAIN for the data, not the column encoding
-		encodings = append(encodings, parquet.Encoding_RLE_DICTIONARY)
+		index.addPage(pos, int32(pageSize), firstRow, p)
+		firstRow += int64(p.numRows)
+		pos = w.Pos()
 	}
 
+	encodings, encodingStats := chunkEncodings(col, dataPageType, numDictPages, len(pages))
+
 	keyValueMetaData := make([]*parquet.KeyValue, 0, len(kvMetaData))
 	for k, v := range kvMetaData {
 		value := v
@@ -272,12 +472,12 @@ func writeChunk(w writePos, schema SchemaWriter, col *Column, codec parquet.Comp
 	nullCount := int64(col.data.values.nullValueCount())
 	distinctCount := int64(col.data.values.numDistinctValues())
 
-	stats := &parquet.Statistics{
-		MinValue:      col.data.minValue(),
-		MaxValue:      col.data.maxValue(),
-		NullCount:     &nullCount,
-		DistinctCount: &distinctCount,
+	chunkStats := newMinMaxTracker(col.Element(), fw.maxStatsLength)
+	for _, p := range pages {
+		chunkStats.merge(&p.stats)
 	}
+	stats := chunkStats.statistics(col.Element(), nullCount)
+	stats.DistinctCount = &distinctCount
 
 	ch := &parquet.ColumnChunk{
 		FilePath:   nil, // No support for external
@@ -291,11 +491,11 @@ func writeChunk(w writePos, schema SchemaWriter, col *Column, codec parquet.Comp
 			TotalUncompressedSize: totalUnComp,
 			TotalCompressedSize:   totalComp,
 			KeyValueMetadata:      keyValueMetaData,
-			DataPageOffset:        pos,
+			DataPageOffset:        dataPageOffset,
 			IndexPageOffset:       nil,
 			DictionaryPageOffset:  dictPageOffset,
 			Statistics:            stats,
-			EncodingStats:         nil,
+			EncodingStats:         encodingStats,
 		},
 		OffsetIndexOffset: nil,
 		OffsetIndexLength: nil,
@@ -303,20 +503,105 @@ func writeChunk(w writePos, schema SchemaWriter, col *Column, codec parquet.Comp
 		ColumnIndexLength: nil,
 	}
 
-	return ch, nil
+	return ch, index, nil
 }
 
-func writeRowGroup(w writePos, schema SchemaWriter, codec parquet.CompressionCodec, pageFn newDataPageFunc, h *flushRowGroupOptionHandle) ([]*parquet.ColumnChunk, error) {
-	dataCols := schema.Columns()
-	var res = make([]*parquet.ColumnChunk, 0, len(dataCols))
-	for _, ci := range dataCols {
-		ch, err := writeChunk(w, schema, ci, codec, pageFn, h.getMetaData(ci.FlatName()))
+// writeRowGroup writes the column chunks of the row group. encs contains the encryptors of the
+// column chunks, or is nil if the file isn't encrypted.
+func (fw *FileWriter) writeRowGroup(h *flushRowGroupOptionHandle, encs []*columnEncryptor) ([]*parquet.ColumnChunk, []*pageIndex, error) {
+	if fw.concurrency > 1 {
+		return fw.writeRowGroupConcurrently(h, encs)
+	}
+
+	dataCols := fw.SchemaWriter.Columns()
+	var (
+		res     = make([]*parquet.ColumnChunk, 0, len(dataCols))
+		indexes = make([]*pageIndex, 0, len(dataCols))
+	)
+	for i, ci := range dataCols {
+		ch, index, err := fw.writeChunk(fw.w, ci, columnEncryptorAt(encs, i), h.getMetaData(ci.FlatName()))
 		if err != nil {
-			return nil, err
+			return nil, nil, err
 		}
 
 		res = append(res, ch)
+		indexes = append(indexes, index)
+	}
+
+	return res, indexes, nil
+}
+
+// encodedChunk is a column chunk that was encoded into a buffer.
+type encodedChunk struct {
+	buf   *bytes.Buffer
+	chunk *parquet.ColumnChunk
+	index *pageIndex
+	err   error
+}
+
+// writeRowGroupConcurrently encodes and compresses up to fw.concurrency column chunks in parallel
+// into buffers, and writes the buffers to the file in the order of the columns.
+func (fw *FileWriter) writeRowGroupConcurrently(h *flushRowGroupOptionHandle, encs []*columnEncryptor) ([]*parquet.ColumnChunk, []*pageIndex, error) {
+	dataCols := fw.SchemaWriter.Columns()
+
+	results := make([]chan encodedChunk, len(dataCols))
+	for i := range results {
+		results[i] = make(chan encodedChunk, 1)
 	}
 
-	return res, nil
+	// a slot is released as soon as the column chunk has been written to the file, so that
+	// at most fw.concurrency column chunks are kept in memory.
+	sem := make(chan struct{}, fw.concurrency)
+	go func() {
+		for i, col := range dataCols {
+			sem <- struct{}{}
+			go func(col *Column, enc *columnEncryptor, kvMetaData map[string]string, res chan<- encodedChunk) {
+				buf := &bytes.Buffer{}
+				ch, index, err := fw.writeChunk(&writePosStruct{w: buf}, col, enc, kvMetaData)
+				res <- encodedChunk{buf: buf, chunk: ch, index: index, err: err}
+			}(col, columnEncryptorAt(encs, i), h.getMetaData(col.FlatName()), results[i])
+		}
+	}()
+
+	var (
+		res      = make([]*parquet.ColumnChunk, 0, len(dataCols))
+		indexes  = make([]*pageIndex, 0, len(dataCols))
+		firstErr error
+	)
+	for i := range results {
+		// all results need to be received, even after an error, to not leak any goroutines.
+		enc := <-results[i]
+		if firstErr == nil && enc.err != nil {
+			firstErr = enc.err
+		}
+		if firstErr == nil {
+			enc.shiftOffsets(fw.w.Pos())
+			if err := writeFull(fw.w, enc.buf.Bytes()); err != nil {
+				firstErr = err
+			}
+			res = append(res, enc.chunk)
+			indexes = append(indexes, enc.index)
+		}
+		<-sem
+	}
+
+	if firstErr != nil {
+		return nil, nil, firstErr
+	}
+	return res, indexes, nil
+}
+
+// shiftOffsets moves all offsets of the encoded column chunk by offset bytes. The column chunk
+// is encoded as if it started at the beginning of the file.
+func (enc *encodedChunk) shiftOffsets(offset int64) {
+	meta := enc.chunk.MetaData
+	enc.chunk.FileOffset += offset
+	meta.DataPageOffset += offset
+	if meta.DictionaryPageOffset != nil {
+		dictOffset := *meta.DictionaryPageOffset + offset
+		meta.DictionaryPageOffset = &dictOffset
+	}
+	for _, loc := range enc.index.offsetIndex.PageLocations {
+		loc.Offset += offset
+	}
 }
@@ -24,7 +24,7 @@ func init() {
 	partSize = splitFile.PersistentFlags().StringP("file-size", "s", "100MB", "The target size of parquet files, it is not the *exact* size on the output")
 	targetFolder = splitFile.PersistentFlags().StringP("target-folder", "t", "", "Target folder to write the files, use the source file folder if it's empty")
 	rowGroupSize = splitFile.PersistentFlags().StringP("row-group-size", "r", "128MB", "Uncompressed row group size")
-	compressionMethod = splitFile.PersistentFlags().StringP("compression", "c", "Snappy", "Compression method, valid values are Snappy, Gzip, None")
+	compressionMethod = splitFile.PersistentFlags().StringP("compression", "c", "Snappy", "Compression method, valid values are Snappy, Gzip, Zstd, Lz4, Lz4_Raw, Brotli, None")
 	rootCmd.AddCommand(splitFile)
 }
 
@@ -53,10 +53,18 @@ var splitFile = &cobra.Command{
 			comp = parquet.CompressionCodec_SNAPPY
 		case "GZIP":
 			comp = parquet.CompressionCodec_GZIP
+		case "ZSTD":
+			comp = parquet.CompressionCodec_ZSTD
+		case "LZ4":
+			comp = parquet.CompressionCodec_LZ4
+		case "LZ4_RAW":
+			comp = parquet.CompressionCodec_LZ4_RAW
+		case "BROTLI":
+			comp = parquet.CompressionCodec_BROTLI
 		case "NONE":
 			comp = parquet.CompressionCodec_UNCOMPRESSED
 		default:
-			log.Fatalf("Invalid compression codec: %q", *rowGroupSize)
+			log.Fatalf("Invalid compression codec: %q", *compressionMethod)
 		}
 
 		fl, err := os.Open(args[0])
@@ -9,8 +9,10 @@ import (
 
 	"github.com/pkg/errors"
 
+	"github.com/andybalholm/brotli"
 	"github.com/fraugster/parquet-go/parquet"
 	"github.com/golang/snappy"
+	"github.com/klauspost/compress/zstd"
 )
 
 var (
@@ -26,9 +28,24 @@ type (
 		DecompressBlock([]byte) ([]byte, error)
 	}
 
-	plainCompressor  struct{}
-	snappyCompressor struct{}
-	gzipCompressor   struct{}
+	// sizedBlockDecompressor is implemented by block compressors that use the known uncompressed
+	// size of a block to preallocate the decompressed data and to limit its size.
+	sizedBlockDecompressor interface {
+		decompressBlockWithSize(block []byte, size int) ([]byte, error)
+	}
+
+	plainCompressor     struct{}
+	snappyCompressor    struct{}
+	gzipCompressor      struct{}
+	brotliCompressor    struct{}
+	lz4RawCompressor    struct{}
+	lz4HadoopCompressor struct{}
+	zstdCompressor      struct {
+		once    sync.Once
+		encoder *zstd.Encoder
+		decoder *zstd.Decoder
+		err     error
+	}
 )
 
 func (plainCompressor) CompressBlock(block []byte) ([]byte, error) {
@@ -75,6 +92,88 @@ func (gzipCompressor) DecompressBlock(block []byte) ([]byte, error) {
 	return ret, r.Close()
 }
 
+func (brotliCompressor) CompressBlock(block []byte) ([]byte, error) {
+	buf := &bytes.Buffer{}
+	w := brotli.NewWriter(buf)
+	if _, err := w.Write(block); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+func (brotliCompressor) DecompressBlock(block []byte) ([]byte, error) {
+	return ioutil.ReadAll(brotli.NewReader(bytes.NewReader(block)))
+}
+
+func (lz4RawCompressor) CompressBlock(block []byte) ([]byte, error) {
+	return lz4CompressBlock(block), nil
+}
+
+func (lz4RawCompressor) DecompressBlock(block []byte) ([]byte, error) {
+	return lz4DecompressBlock(nil, block, lz4MaxSize(block))
+}
+
+func (lz4RawCompressor) decompressBlockWithSize(block []byte, size int) ([]byte, error) {
+	return lz4DecompressBlock(make([]byte, 0, size), block, size)
+}
+
+func (lz4HadoopCompressor) CompressBlock(block []byte) ([]byte, error) {
+	return lz4HadoopCompressBlock(block), nil
+}
+
+// DecompressBlock decompresses data in the Hadoop LZ4 framing. Some writers used the LZ4 codec for
+// raw LZ4 blocks, so if the data can't be decompressed as Hadoop LZ4 frames, it is decompressed as
+// a raw LZ4 block instead.
+func (c lz4HadoopCompressor) DecompressBlock(block []byte) ([]byte, error) {
+	return c.decompressBlockWithSize(block, -1)
+}
+
+func (lz4HadoopCompressor) decompressBlockWithSize(block []byte, size int) ([]byte, error) {
+	res, err := lz4HadoopDecompressBlock(block, size)
+	if err != nil {
+		var raw []byte
+		var rawErr error
+		if size < 0 {
+			raw, rawErr = lz4RawCompressor{}.DecompressBlock(block)
+		} else {
+			raw, rawErr = lz4RawCompressor{}.decompressBlockWithSize(block, size)
+		}
+		if rawErr == nil {
+			return raw, nil
+		}
+		return nil, err
+	}
+	return res, nil
+}
+
+func (z *zstdCompressor) init() error {
+	z.once.Do(func() {
+		if z.encoder, z.err = zstd.NewWriter(nil); z.err != nil {
+			return
+		}
+		z.decoder, z.err = zstd.NewReader(nil)
+	})
+	return z.err
+}
+
+func (z *zstdCompressor) CompressBlock(block []byte) ([]byte, error) {
+	if err := z.init(); err != nil {
+		return nil, err
+	}
+	return z.encoder.EncodeAll(block, nil), nil
+}
+
+func (z *zstdCompressor) DecompressBlock(block []byte) ([]byte, error) {
+	if err := z.init(); err != nil {
+		return nil, err
+	}
+	return z.decoder.DecodeAll(block, nil)
+}
+
 func compressBlock(block []byte, method parquet.CompressionCodec) ([]byte, error) {
 	compressorLock.RLock()
 	defer compressorLock.RUnlock()
@@ -87,7 +186,9 @@ func compressBlock(block []byte, method parquet.CompressionCodec) ([]byte, error
 	return c.CompressBlock(block)
 }
 
-func decompressBlock(block []byte, method parquet.CompressionCodec) ([]byte, error) {
+// decompressBlock decompresses the block with the compressor of method. If size isn't negative, it's
+// the uncompressed size of the block.
+func decompressBlock(block []byte, method parquet.CompressionCodec, size int) ([]byte, error) {
 	compressorLock.RLock()
 	defer compressorLock.RUnlock()
 
@@ -96,6 +197,9 @@ func decompressBlock(block []byte, method parquet.CompressionCodec) ([]byte, err
 		return nil, errors.Errorf("method %q is not supported", method.String())
 	}
 
+	if sc, ok := c.(sizedBlockDecompressor); ok && size >= 0 {
+		return sc.decompressBlockWithSize(block, size)
+	}
 	return c.DecompressBlock(block)
 }
 
@@ -109,7 +213,7 @@ func newBlockReader(in io.Reader, codec parquet.CompressionCodec, compressedSize
 		return nil, errors.Errorf("compressed data must be %d byte but its %d byte", compressedSize, len(buf))
 	}
 
-	res, err := decompressBlock(buf, codec)
+	res, err := decompressBlock(buf, codec, int(uncompressedSize))
 	if err != nil {
 		return nil, errors.Wrap(err, "decompression failed")
 	}
@@ -122,11 +226,11 @@ func newBlockReader(in io.Reader, codec parquet.CompressionCodec, compressedSize
 }
 
 // RegisterBlockCompressor is a function to to register additional block compressors to the package. By default,
-// only UNCOMPRESSED, GZIP and SNAPPY are supported as parquet compression algorithms. The parquet file format
-// supports more compression algorithms, such as LZO, BROTLI, LZ4 and ZSTD. To limit the amount of external dependencies,
-// the number of supported algorithms was reduced to a core set. If you want to use any of the other compression
-// algorithms, please provide your own implementation of it in a way that satisfies the BlockCompressor interface,
-// and register it using this function from your code.
+// UNCOMPRESSED, GZIP, SNAPPY, BROTLI, LZ4, LZ4_RAW and ZSTD are supported as parquet compression algorithms. The
+// LZ4 compressor uses the framing of Hadoop's Lz4Codec, which is what most other implementations use for this
+// codec. The parquet file format also supports LZO, which is not implemented in this package. If you want to use
+// it, or want to replace any of the built-in compressors, please provide your own implementation in a way that
+// satisfies the BlockCompressor interface, and register it using this function from your code.
 func RegisterBlockCompressor(method parquet.CompressionCodec, compressor BlockCompressor) {
 	compressorLock.Lock()
 	defer compressorLock.Unlock()
@@ -138,4 +242,8 @@ func init() {
 	RegisterBlockCompressor(parquet.CompressionCodec_UNCOMPRESSED, plainCompressor{})
 	RegisterBlockCompressor(parquet.CompressionCodec_GZIP, gzipCompressor{})
 	RegisterBlockCompressor(parquet.CompressionCodec_SNAPPY, snappyCompressor{})
+	RegisterBlockCompressor(parquet.CompressionCodec_BROTLI, brotliCompressor{})
+	RegisterBlockCompressor(parquet.CompressionCodec_LZ4, lz4HadoopCompressor{})
+	RegisterBlockCompressor(parquet.CompressionCodec_LZ4_RAW, lz4RawCompressor{})
+	RegisterBlockCompressor(parquet.CompressionCodec_ZSTD, &zstdCompressor{})
 }
@@ -20,12 +20,16 @@ occaecat cupidatat non proident, sunt in culpa qui officia deserunt mollit anim
 		parquet.CompressionCodec_GZIP,
 		parquet.CompressionCodec_SNAPPY,
 		parquet.CompressionCodec_UNCOMPRESSED,
+		parquet.CompressionCodec_BROTLI,
+		parquet.CompressionCodec_LZ4,
+		parquet.CompressionCodec_LZ4_RAW,
+		parquet.CompressionCodec_ZSTD,
 	}
 
 	for _, m := range methods {
 		b, err := compressBlock(block, m)
 		require.NoError(t, err)
-		b2, err := decompressBlock(b, m)
+		b2, err := decompressBlock(b, m, len(block))
 		require.NoError(t, err)
 		assert.Equal(t, block, b2)
 	}
@@ -0,0 +1,81 @@
+package goparquet
+
+import (
+	"bytes"
+	"fmt"
+	"hash/crc32"
+	"io"
+
+	"github.com/fraugster/parquet-go/parquet"
+	"github.com/pkg/errors"
+)
+
+// PageChecksumError is returned by the FileReader if the validation of CRC32 checksums is enabled
+// and the checksum of a page doesn't match the checksum in its page header.
+type PageChecksumError struct {
+	// Column is the full dotted-notation name of the column.
+	Column string
+	// RowGroup is the index of the row group within the file.
+	RowGroup int
+	// Page is the index of the data page within the column chunk, or -1 for the dictionary page.
+	Page int
+	// Offset is the offset of the page header within the file.
+	Offset int64
+
+	Expected, Actual uint32
+}
+
+func (e *PageChecksumError) Error() string {
+	page := fmt.Sprintf("data page %d", e.Page)
+	if e.Page < 0 {
+		page = "dictionary page"
+	}
+	return fmt.Sprintf("column %s, row group %d, %s at offset %d: CRC32 checksum mismatch, expected %08x but was %08x",
+		e.Column, e.RowGroup, page, e.Offset, e.Expected, e.Actual)
+}
+
+// pageCRC returns the CRC32 checksum of the page data as it is stored in the page header.
+func pageCRC(data ...[]byte) *int32 {
+	var crc uint32
+	for _, d := range data {
+		crc = crc32.Update(crc, crc32.IEEETable, d)
+	}
+	ret := int32(crc)
+	return &ret
+}
+
+// pageVerifier verifies the CRC32 checksums of the pages of a row group. A nil pageVerifier
+// doesn't verify anything.
+type pageVerifier struct {
+	rowGroup int
+}
+
+// verify reads the data of the page from r, which needs to be positioned right after the page
+// header at offset, and verifies its checksum. Pages without a checksum are not verified. It returns
+// a reader for the page data.
+func (v *pageVerifier) verify(r io.Reader, col *Column, ph *parquet.PageHeader, page int, offset int64) (io.Reader, error) {
+	if v == nil || ph.Crc == nil {
+		return r, nil
+	}
+	if ph.CompressedPageSize < 0 {
+		return nil, errors.Errorf("invalid page data size %d", ph.CompressedPageSize)
+	}
+
+	data := make([]byte, ph.CompressedPageSize)
+	if _, err := io.ReadFull(r, data); err != nil {
+		return nil, err
+	}
+
+	if actual := uint32(*pageCRC(data)); actual != uint32(*ph.Crc) {
+		return nil, &PageChecksumError{
+			Column:   col.FlatName(),
+			RowGroup: v.rowGroup,
+			Page:     page,
+			Offset:   offset,
+			Expected: uint32(*ph.Crc),
+			Actual:   actual,
+		}
+	}
+
+	return bytes.NewReader(data), nil
+}
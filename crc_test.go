@@ -0,0 +1,97 @@
+package goparquet
+
+import (
+	"bytes"
+	"errors"
+	"hash/crc32"
+	"testing"
+
+	"github.com/fraugster/parquet-go/parquet"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteWithCRC(t *testing.T) {
+	for _, options := range [][]FileWriterOption{
+		{WithCRC(), WithMaxPageRowCount(10)},
+		{WithCRC(), WithMaxPageRowCount(10), WithDataPageV2(), WithCompressionCodec(parquet.CompressionCodec_GZIP)},
+	} {
+		file := writePredicateTestFile(t, options...)
+
+		r, err := NewFileReaderWithOptions(bytes.NewReader(file), WithCRC32Validation())
+		require.NoError(t, err)
+		rows := readAllRows(t, r)
+		require.Len(t, rows, 100)
+		for i := range rows {
+			require.Equal(t, predicateTestRow(i), rows[i])
+		}
+
+		for _, rg := range r.meta.RowGroups {
+			for _, chunk := range rg.Columns {
+				offset := chunkOffset(chunk.MetaData)
+				end := offset + chunk.MetaData.TotalCompressedSize
+				for offset < end {
+					pr := bytes.NewReader(file[offset:end])
+					ph := &parquet.PageHeader{}
+					require.NoError(t, readThrift(ph, pr))
+					dataOffset := end - int64(pr.Len())
+
+					require.NotNil(t, ph.Crc)
+					data := file[dataOffset : dataOffset+int64(ph.CompressedPageSize)]
+					require.Equal(t, crc32.ChecksumIEEE(data), uint32(*ph.Crc))
+
+					offset = dataOffset + int64(ph.CompressedPageSize)
+				}
+			}
+		}
+	}
+}
+
+func TestReadWithCRC32Validation(t *testing.T) {
+	file := writePredicateTestFile(t, WithCRC(), WithMaxPageRowCount(10), WithPageIndex())
+
+	r, err := NewFileReader(bytes.NewReader(file))
+	require.NoError(t, err)
+
+	// corrupt the last byte of the third data page of column bar in the second row group.
+	chunk := r.meta.RowGroups[1].Columns[1]
+	headers := readDataPageHeaders(t, file, chunk)
+	require.Len(t, headers, 5)
+
+	offsetIndex := &parquet.OffsetIndex{}
+	require.NoError(t, readIndex(r.reader, offsetIndex, *chunk.OffsetIndexOffset, *chunk.OffsetIndexLength, nil, moduleOffsetIndex))
+	loc := offsetIndex.PageLocations[2]
+	pageEnd := loc.Offset + int64(loc.CompressedPageSize)
+
+	corrupted := append([]byte(nil), file...)
+	corrupted[pageEnd-1] ^= 0xff
+
+	for _, options := range [][]FileReaderOption{
+		nil,
+		{WithStreaming()},
+		{WithConcurrency(3)},
+		{WithPredicate(ColumnPredicate("foo", GreaterThanOrEqual, 75))},
+		{WithStreaming(), WithPredicate(ColumnPredicate("foo", GreaterThanOrEqual, 75))},
+	} {
+		r, err := NewFileReaderWithOptions(bytes.NewReader(corrupted), append(options, WithCRC32Validation())...)
+		require.NoError(t, err)
+
+		var checksumErr *PageChecksumError
+		for err == nil {
+			_, err = r.NextRow()
+		}
+		require.True(t, errors.As(err, &checksumErr), "unexpected error %v", err)
+		require.Equal(t, &PageChecksumError{
+			Column:   "bar",
+			RowGroup: 1,
+			Page:     2,
+			Offset:   loc.Offset,
+			Expected: uint32(*headers[2].Crc),
+			Actual:   crc32.ChecksumIEEE(corrupted[pageEnd-int64(headers[2].CompressedPageSize) : pageEnd]),
+		}, checksumErr)
+	}
+
+	// reading pages that aren't corrupted doesn't fail.
+	r, err = NewFileReaderWithOptions(bytes.NewReader(corrupted), WithCRC32Validation(), WithPredicate(ColumnPredicate("foo", GreaterThanOrEqual, 80)))
+	require.NoError(t, err)
+	require.Len(t, readAllRows(t, r), 20)
+}
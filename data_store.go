@@ -1,7 +1,6 @@
 package goparquet
 
 import (
-	"math"
 	"math/bits"
 
 	"github.com/fraugster/parquet-go/parquet"
@@ -28,6 +27,12 @@ type ColumnStore struct {
 	allowDict bool
 
 	skipped bool
+
+	// stream is used to load the pages of a column chunk one at a time while reading. It is
+	// nil if the whole column chunk was loaded at once.
+	stream *chunkStream
+	// err is the error that occurred while loading the next page of the stream.
+	err error
 }
 
 // useDictionary is simply a function to decide to use dictionary or not,
@@ -35,9 +40,6 @@ func (cs *ColumnStore) useDictionary() bool {
 	if !cs.allowDict {
 		return false
 	}
-	if len(cs.values.data) > math.MaxInt16 {
-		return false
-	}
 
 	// There is no point for using dictionary if all values are nil
 	if len(cs.values.data) == 0 || len(cs.values.values) == 0 {
@@ -71,6 +73,8 @@ func (cs *ColumnStore) reset(rep parquet.FieldRepetitionType, maxR, maxD uint16)
 	cs.dLevels.reset(bits.Len16(maxD))
 	cs.readPos = 0
 	cs.skipped = false
+	cs.stream = nil
+	cs.err = nil
 
 	cs.typedColumnStore.reset(rep)
 }
@@ -127,24 +131,44 @@ func (cs *ColumnStore) add(v interface{}, dL uint16, maxRL, rL uint16) error {
 
 // getRDLevelAt return the next rLevel in the read position, if there is no value left, it returns true
 // if the position is less than zero, then it returns the current position
+// if the next page can't be loaded while streaming, the error is returned
 // NOTE: make sure always r is before d, in any function
-func (cs *ColumnStore) getRDLevelAt(pos int) (int32, int32, bool) {
+func (cs *ColumnStore) getRDLevelAt(pos int) (int32, int32, bool, error) {
 	if pos < 0 {
 		pos = cs.readPos
 	}
+	if pos == cs.readPos {
+		if err := cs.fill(); err != nil {
+			return 0, 0, true, err
+		}
+		pos = cs.readPos
+	}
 	if pos >= cs.rLevels.count || pos >= cs.dLevels.count {
-		return 0, 0, true
+		return 0, 0, true, nil
 	}
 	dl, err := cs.dLevels.at(pos)
 	if err != nil {
-		return 0, 0, true
+		return 0, 0, true, nil
 	}
 	rl, err := cs.rLevels.at(pos)
 	if err != nil {
-		return 0, 0, true
+		return 0, 0, true, nil
 	}
 
-	return rl, dl, false
+	return rl, dl, false, nil
+}
+
+// fill loads the next page when streaming and all levels of the current page have been read. Once
+// loading a page failed, the error is returned by all further calls.
+func (cs *ColumnStore) fill() error {
+	if cs.err != nil {
+		return cs.err
+	}
+	if cs.stream == nil || cs.readPos < cs.rLevels.count {
+		return nil
+	}
+	cs.err = cs.stream.fill()
+	return cs.err
 }
 
 func (cs *ColumnStore) getNext() (v interface{}, err error) {
@@ -160,10 +184,17 @@ func (cs *ColumnStore) get(maxD, maxR int32) (interface{}, int32, error) {
 		return nil, 0, nil
 	}
 
+	if err := cs.fill(); err != nil {
+		return nil, 0, err
+	}
+
 	if cs.readPos >= cs.rLevels.count || cs.readPos >= cs.dLevels.count {
 		return nil, 0, errors.New("out of range")
 	}
-	_, dl, _ := cs.getRDLevelAt(cs.readPos)
+	_, dl, _, err := cs.getRDLevelAt(cs.readPos)
+	if err != nil {
+		return nil, 0, err
+	}
 	// this is a null value, increase the read pos, for advancing the rLvl and dLvl but
 	// do not touch the dict-store
 	if dl < maxD {
@@ -188,7 +219,10 @@ func (cs *ColumnStore) get(maxD, maxR int32) (interface{}, int32, error) {
 	var ret = cs.typedColumnStore.append(nil, v)
 	for {
 		cs.readPos++
-		rl, _, last := cs.getRDLevelAt(cs.readPos)
+		rl, _, last, err := cs.getRDLevelAt(cs.readPos)
+		if err != nil {
+			return nil, maxD, err
+		}
 		if last || rl < maxR {
 			// end of this object
 			return ret, maxD, nil
@@ -304,10 +338,11 @@ func NewInt96Store(enc parquet.Encoding, allowDict bool, params *ColumnParameter
 
 // NewFloatStore creates a new column store to store float (float32) values. If allowDict is true,
 // then using a dictionary is considered by the column store depending on its heuristics.
-// If allowDict is false, a dictionary will never be used to encode the data.
+// If allowDict is false, a dictionary will never be used to encode the data. Besides PLAIN, the
+// BYTE_STREAM_SPLIT encoding is supported, which often improves the compression of float values.
 func NewFloatStore(enc parquet.Encoding, allowDict bool, params *ColumnParameters) (*ColumnStore, error) {
 	switch enc {
-	case parquet.Encoding_PLAIN:
+	case parquet.Encoding_PLAIN, parquet.Encoding_BYTE_STREAM_SPLIT:
 	default:
 		return nil, errors.Errorf("encoding %q is not supported on this type", enc)
 	}
@@ -316,10 +351,11 @@ func NewFloatStore(enc parquet.Encoding, allowDict bool, params *ColumnParameter
 
 // NewDoubleStore creates a new column store to store double (float64) values. If allowDict is true,
 // then using a dictionary is considered by the column store depending on its heuristics.
-// If allowDict is false, a dictionary will never be used to encode the data.
+// If allowDict is false, a dictionary will never be used to encode the data. Besides PLAIN, the
+// BYTE_STREAM_SPLIT encoding is supported, which often improves the compression of double values.
 func NewDoubleStore(enc parquet.Encoding, allowDict bool, params *ColumnParameters) (*ColumnStore, error) {
 	switch enc {
-	case parquet.Encoding_PLAIN:
+	case parquet.Encoding_PLAIN, parquet.Encoding_BYTE_STREAM_SPLIT:
 	default:
 		return nil, errors.Errorf("encoding %q is not supported on this type", enc)
 	}
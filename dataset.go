@@ -0,0 +1,455 @@
+//go:build go1.16
+// +build go1.16
+
+package goparquet
+
+import (
+	"io"
+	"io/fs"
+	"path"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/fraugster/parquet-go/parquet"
+	"github.com/fraugster/parquet-go/parquetschema"
+	"github.com/pkg/errors"
+)
+
+// Dataset reads the rows of all parquet files in a directory tree, like the Hive-style partitioned
+// datasets written by the DatasetWriter. The directories of the form key=value in the path of a file
+// are the partitions of the file, and their values are returned as additional columns of its rows.
+// Files and directories whose names start with "_" or ".", like _metadata or _SUCCESS, are ignored.
+type Dataset struct {
+	fsys      fs.FS
+	options   []FileReaderOption
+	predicate Predicate
+	// the predicate that is used by the readers of the files, nil if it references partition columns.
+	filePredicate Predicate
+
+	partitionKeys []string
+	// the schema definition that the types of the partition columns are taken from, and the schema
+	// elements of the partition columns in the order of the partition keys.
+	partitionSchema  *parquetschema.SchemaDefinition
+	partitionColumns []*parquet.SchemaElement
+	files            []*datasetEntry
+
+	// the index of the file that is currently read, and its reader.
+	current int
+	file    io.Closer
+	reader  *FileReader
+}
+
+// datasetEntry is a file of a dataset.
+type datasetEntry struct {
+	path string
+	size int64
+	// the partition values of the file in the order of the partition keys, nil for null values.
+	partitions []interface{}
+	numRows    int64
+}
+
+// DatasetOption describes an option function that is applied to a Dataset when it is opened.
+type DatasetOption func(ds *Dataset)
+
+// WithDatasetReaderOptions sets the options of the FileReaders that read the files of the dataset,
+// like WithColumns or WithKeyRetriever. The column names only refer to the columns stored in the
+// files. Predicates need to be set with WithDatasetPredicate instead of WithPredicate.
+func WithDatasetReaderOptions(options ...FileReaderOption) DatasetOption {
+	return func(ds *Dataset) {
+		ds.options = append(ds.options, options...)
+	}
+}
+
+// WithPartitionSchemaDefinition sets the schema definition that the types of the partition columns are
+// taken from, like the schema definition passed to NewDatasetWriter. The partition values are parsed into
+// values of the type of their column, which need to be top-level columns of a primitive type other than
+// INT96. Partition columns that are not part of the schema definition are of the type BYTE_ARRAY (STRING),
+// which is also the default if no schema definition is set.
+func WithPartitionSchemaDefinition(sd *parquetschema.SchemaDefinition) DatasetOption {
+	return func(ds *Dataset) {
+		ds.partitionSchema = sd
+	}
+}
+
+// WithDatasetPredicate sets a predicate that is used to skip whole files of the dataset. The partition
+// columns can be used in the predicate like columns of their type, see WithPartitionSchemaDefinition. A file is skipped if the
+// predicate can't match any of its rows according to its partition values and the statistics of its
+// row groups. If a target schema is set with WithTargetSchemaDefinition, the other columns of the
+// predicate refer to the columns of the target schema. If the predicate only references columns stored
+// in the files, it is also used to skip data within the files, see WithPredicate. Just like with
+// WithPredicate, rows that don't match the predicate may still be returned.
+func WithDatasetPredicate(p Predicate) DatasetOption {
+	return func(ds *Dataset) {
+		ds.predicate = p
+	}
+}
+
+// OpenDataset opens the dataset in the directory dir of fsys. The footers of all files are read to
+// check that their schemas are the same and to skip the files that can't match the predicate. If a
+// target schema is set with WithTargetSchemaDefinition, the schemas of the files only need to be
+// compatible with it.
+func OpenDataset(fsys fs.FS, dir string, options ...DatasetOption) (*Dataset, error) {
+	ds := &Dataset{fsys: fsys}
+	for _, opt := range options {
+		opt(ds)
+	}
+
+	var firstPath string
+	var firstSchema []*parquet.SchemaElement
+	err := fs.WalkDir(fsys, dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if p != dir && (strings.HasPrefix(d.Name(), "_") || strings.HasPrefix(d.Name(), ".")) {
+			if d.IsDir() {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		keys, values := parsePartitions(strings.TrimPrefix(path.Dir(p), dir))
+		entry := &datasetEntry{path: p, size: info.Size()}
+
+		reader, file, err := ds.openFile(entry, nil)
+		if err != nil {
+			return err
+		}
+		defer func() {
+			_ = reader.Close()
+			_ = file.Close()
+		}()
+		meta := reader.FileMetaData()
+		entry.numRows = meta.NumRows
+
+		if firstSchema == nil {
+			firstPath, firstSchema = p, meta.Schema
+			ds.partitionKeys = keys
+			for _, key := range keys {
+				if reader.GetColumnByName(key) != nil {
+					return errors.Errorf("partition key %q is also a column of file %q", key, p)
+				}
+				elem, err := ds.partitionColumn(key)
+				if err != nil {
+					return err
+				}
+				ds.partitionColumns = append(ds.partitionColumns, elem)
+			}
+		}
+		if !reflect.DeepEqual(keys, ds.partitionKeys) {
+			return errors.Errorf("file %q: partition keys %v differ from the partition keys %v of file %q", p, keys, ds.partitionKeys, firstPath)
+		}
+		for i, value := range values {
+			v, err := parsePartitionValue(ds.partitionColumns[i], value)
+			if err != nil {
+				return errors.Wrapf(err, "file %q: partition column %q", p, keys[i])
+			}
+			entry.partitions = append(entry.partitions, v)
+		}
+		// with a target schema, the FileReader checks that the schema of the file is compatible.
+		if reader.projection == nil && !reflect.DeepEqual(meta.Schema[1:], firstSchema[1:]) {
+			return errors.Errorf("file %q: schema differs from the schema of file %q", p, firstPath)
+		}
+
+		match, err := ds.mightMatch(reader, entry)
+		if err != nil {
+			return errors.Wrapf(err, "file %q", p)
+		}
+		if match {
+			ds.files = append(ds.files, entry)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if ds.predicate != nil && !ds.hasPartitionColumns(ds.predicate) {
+		ds.filePredicate = ds.predicate
+	}
+
+	return ds, nil
+}
+
+// partitionColumn returns the schema element of the partition column with the name.
+func (ds *Dataset) partitionColumn(name string) (*parquet.SchemaElement, error) {
+	elem := &parquet.SchemaElement{
+		Name:          name,
+		Type:          parquet.TypePtr(parquet.Type_BYTE_ARRAY),
+		ConvertedType: parquet.ConvertedTypePtr(parquet.ConvertedType_UTF8),
+		LogicalType:   &parquet.LogicalType{STRING: &parquet.StringType{}},
+	}
+	if ds.partitionSchema != nil {
+		if col := ds.partitionSchema.SubSchema(name); col != nil {
+			if col.SchemaElement().Type == nil || len(col.RootColumn.Children) > 0 {
+				return nil, errors.Errorf("partition column %q is not of a primitive type", name)
+			}
+			if col.SchemaElement().GetType() == parquet.Type_INT96 {
+				return nil, errors.Errorf("partition column %q is of type INT96, which has no string representation", name)
+			}
+			e := *col.SchemaElement()
+			elem = &e
+		}
+	}
+
+	// partition values can always be null.
+	elem.RepetitionType = parquet.FieldRepetitionTypePtr(parquet.FieldRepetitionType_OPTIONAL)
+	return elem, nil
+}
+
+// openFile opens a file of the dataset and creates a reader for it that uses the predicate p, if it
+// isn't nil.
+func (ds *Dataset) openFile(entry *datasetEntry, p Predicate) (*FileReader, io.Closer, error) {
+	file, err := openFSFile(ds.fsys, entry.path)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	options := append([]FileReaderOption{WithFileOpener(FSFileOpener(ds.fsys, path.Dir(entry.path)))}, ds.options...)
+	if p != nil {
+		options = append(options, WithPredicate(p))
+	}
+
+	reader, err := NewFileReaderAt(file, entry.size, options...)
+	if err != nil {
+		_ = file.Close()
+		return nil, nil, errors.Wrapf(err, "file %q", entry.path)
+	}
+
+	return reader, file, nil
+}
+
+// hasPartitionColumns returns true if the predicate references partition columns.
+func (ds *Dataset) hasPartitionColumns(p Predicate) bool {
+	for _, col := range p.columns() {
+		if ds.isPartitionKey(col) {
+			return true
+		}
+	}
+	return false
+}
+
+// isPartitionKey returns true if name is the name of a partition column.
+func (ds *Dataset) isPartitionKey(name string) bool {
+	for _, key := range ds.partitionKeys {
+		if name == key {
+			return true
+		}
+	}
+	return false
+}
+
+// mightMatch returns true if the predicate might match rows of the file. The partition values of the
+// file are evaluated like the statistics of additional columns of its row groups.
+func (ds *Dataset) mightMatch(reader *FileReader, entry *datasetEntry) (bool, error) {
+	if ds.predicate == nil {
+		return true, nil
+	}
+
+	// with a target schema, the predicate refers to its columns and the partition columns.
+	if reader.projection != nil {
+		for _, col := range ds.predicate.columns() {
+			if reader.projection.column(col) == nil && !ds.isPartitionKey(col) {
+				return false, errors.Errorf("predicate column %q not found", col)
+			}
+		}
+	}
+
+	sd := reader.SchemaReader.GetSchemaDefinition()
+	root := *sd.RootColumn
+	root.Children = append([]*parquetschema.ColumnDefinition{}, root.Children...)
+	for _, elem := range ds.partitionColumns {
+		root.Children = append(root.Children, &parquetschema.ColumnDefinition{SchemaElement: elem})
+	}
+	s := &schema{}
+	if err := s.SetSchemaDefinition(parquetschema.SchemaDefinitionFromColumnDefinition(&root)); err != nil {
+		return false, err
+	}
+
+	for _, rowGroup := range reader.FileMetaData().RowGroups {
+		rg := *rowGroup
+		rg.Columns = append([]*parquet.ColumnChunk{}, rowGroup.Columns...)
+		for _, value := range entry.partitions {
+			stats := &parquet.Statistics{NullCount: new(int64)}
+			if value == nil {
+				*stats.NullCount = rg.NumRows
+			} else {
+				stats.MinValue = encodeStatValue(value)
+				stats.MaxValue = stats.MinValue
+			}
+			rg.Columns = append(rg.Columns, &parquet.ColumnChunk{
+				MetaData: &parquet.ColumnMetaData{NumValues: rg.NumRows, Statistics: stats},
+			})
+		}
+
+		maybe, _, err := ds.predicate.evaluate(&rowGroupStats{schema: s, rowGroup: &rg, projection: reader.projection})
+		if err != nil {
+			return false, err
+		}
+		if maybe.numRows() > 0 {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// PartitionKeys returns the names of the partition columns of the dataset.
+func (ds *Dataset) PartitionKeys() []string {
+	return ds.partitionKeys
+}
+
+// Files returns the paths of the files of the dataset that are read, in the order they are read.
+func (ds *Dataset) Files() []string {
+	paths := make([]string, len(ds.files))
+	for i, file := range ds.files {
+		paths[i] = file.path
+	}
+	return paths
+}
+
+// NumRows returns the number of rows of the files of the dataset that are read.
+func (ds *Dataset) NumRows() int64 {
+	var numRows int64
+	for _, file := range ds.files {
+		numRows += file.numRows
+	}
+	return numRows
+}
+
+// NextRow reads the next row of the dataset. The values of the partition columns are added to the row
+// in the type of their column, see WithPartitionSchemaDefinition, unless they are null. It returns io.EOF when there are no more rows.
+func (ds *Dataset) NextRow() (map[string]interface{}, error) {
+	for ds.current < len(ds.files) {
+		entry := ds.files[ds.current]
+		if ds.reader == nil {
+			reader, file, err := ds.openFile(entry, ds.filePredicate)
+			if err != nil {
+				return nil, err
+			}
+			ds.reader, ds.file = reader, file
+		}
+
+		row, err := ds.reader.NextRow()
+		if err == io.EOF {
+			if err := ds.closeFile(); err != nil {
+				return nil, err
+			}
+			ds.current++
+			continue
+		}
+		if err != nil {
+			return nil, errors.Wrapf(err, "file %q", entry.path)
+		}
+
+		for i, key := range ds.partitionKeys {
+			if entry.partitions[i] != nil {
+				row[key] = entry.partitions[i]
+			}
+		}
+		return row, nil
+	}
+
+	return nil, io.EOF
+}
+
+// Close closes the file of the dataset that is currently read.
+func (ds *Dataset) Close() error {
+	return ds.closeFile()
+}
+
+func (ds *Dataset) closeFile() error {
+	if ds.reader == nil {
+		return nil
+	}
+
+	err := ds.reader.Close()
+	if closeErr := ds.file.Close(); err == nil {
+		err = closeErr
+	}
+	ds.reader, ds.file = nil, nil
+	return err
+}
+
+// parsePartitions returns the keys and values of the directories of the form key=value in the
+// slash-separated path. Values of the default partition are returned as nil.
+func parsePartitions(dir string) (keys []string, values [][]byte) {
+	for _, segment := range strings.Split(dir, "/") {
+		i := strings.IndexByte(segment, '=')
+		if i <= 0 {
+			continue
+		}
+		keys = append(keys, unescapePartitionPath(segment[:i]))
+
+		var value []byte
+		if v := segment[i+1:]; v != DefaultPartitionName {
+			value = []byte(unescapePartitionPath(v))
+		}
+		values = append(values, value)
+	}
+	return keys, values
+}
+
+// parsePartitionValue parses the partition value of the partition column described by elem, the
+// reverse of the DatasetWriter's partitionValue. Null values stay nil.
+func parsePartitionValue(elem *parquet.SchemaElement, value []byte) (interface{}, error) {
+	if value == nil {
+		return nil, nil
+	}
+
+	s := string(value)
+	unsigned := isUnsigned(elem)
+	switch typ := elem.GetType(); typ {
+	case parquet.Type_BYTE_ARRAY:
+		return value, nil
+	case parquet.Type_FIXED_LEN_BYTE_ARRAY:
+		if len(value) != int(elem.GetTypeLength()) {
+			return nil, errors.Errorf("the size of the value should be %d but is %d", elem.GetTypeLength(), len(value))
+		}
+		return value, nil
+	case parquet.Type_BOOLEAN:
+		return strconv.ParseBool(s)
+	case parquet.Type_INT32:
+		if unsigned {
+			v, err := strconv.ParseUint(s, 10, 32)
+			return uint32(v), err
+		}
+		v, err := strconv.ParseInt(s, 10, 32)
+		return int32(v), err
+	case parquet.Type_INT64:
+		if unsigned {
+			return strconv.ParseUint(s, 10, 64)
+		}
+		return strconv.ParseInt(s, 10, 64)
+	case parquet.Type_FLOAT:
+		v, err := strconv.ParseFloat(s, 32)
+		return float32(v), err
+	case parquet.Type_DOUBLE:
+		return strconv.ParseFloat(s, 64)
+	default:
+		return nil, errors.Errorf("unsupported type %s", typ)
+	}
+}
+
+// unescapePartitionPath reverts escapePartitionPath. Invalid escape sequences are kept as they are.
+func unescapePartitionPath(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == '%' && i+2 < len(s) {
+			if c, err := strconv.ParseUint(s[i+1:i+3], 16, 8); err == nil {
+				b.WriteByte(byte(c))
+				i += 2
+				continue
+			}
+		}
+		b.WriteByte(s[i])
+	}
+	return b.String()
+}
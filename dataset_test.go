@@ -0,0 +1,282 @@
+//go:build go1.16
+// +build go1.16
+
+package goparquet
+
+import (
+	"io"
+	"testing"
+	"testing/fstest"
+
+	"github.com/fraugster/parquet-go/parquetschema"
+	"github.com/stretchr/testify/require"
+)
+
+func writeDatasetTestFS(t *testing.T, rows []map[string]interface{}, options ...DatasetWriterOption) fstest.MapFS {
+	files := make(map[string]*datasetTestFile)
+	dw, err := NewDatasetWriter(datasetTestCreator(files), datasetTestSchema(t), []string{"year", "month"}, options...)
+	require.NoError(t, err)
+	for _, row := range rows {
+		require.NoError(t, dw.AddData(row))
+	}
+	require.NoError(t, dw.Close())
+
+	fsys := fstest.MapFS{}
+	for path, f := range files {
+		fsys["table/"+path] = &fstest.MapFile{Data: f.Bytes()}
+	}
+	return fsys
+}
+
+func readDatasetRows(t *testing.T, ds *Dataset) []map[string]interface{} {
+	var rows []map[string]interface{}
+	for {
+		row, err := ds.NextRow()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		rows = append(rows, row)
+	}
+	require.NoError(t, ds.Close())
+	return rows
+}
+
+func TestDataset(t *testing.T) {
+	var rows []map[string]interface{}
+	for i := 0; i < 40; i++ {
+		row := map[string]interface{}{"year": []byte("2026"), "id": int64(i)}
+		if i >= 10 {
+			row["year"] = []byte("20/25")
+			row["month"] = int32(i / 10)
+		}
+		rows = append(rows, row)
+	}
+	fsys := writeDatasetTestFS(t, rows)
+	fsys["table/_SUCCESS"] = &fstest.MapFile{}
+	fsys["table/.hidden/part-0.parquet"] = &fstest.MapFile{Data: []byte("invalid")}
+
+	ds, err := OpenDataset(fsys, "table")
+	require.NoError(t, err)
+	require.Equal(t, []string{"year", "month"}, ds.PartitionKeys())
+	require.Equal(t, []string{
+		"table/year=20%2F25/month=1/part-1.parquet",
+		"table/year=20%2F25/month=2/part-2.parquet",
+		"table/year=20%2F25/month=3/part-3.parquet",
+		"table/year=2026/month=__HIVE_DEFAULT_PARTITION__/part-0.parquet",
+	}, ds.Files())
+	require.Equal(t, int64(40), ds.NumRows())
+
+	dsRows := readDatasetRows(t, ds)
+	require.Len(t, dsRows, 40)
+	require.Equal(t, map[string]interface{}{"year": []byte("20/25"), "month": []byte("1"), "id": int64(10)}, dsRows[0])
+	require.Equal(t, map[string]interface{}{"year": []byte("2026"), "id": int64(0)}, dsRows[30])
+
+	tests := []struct {
+		name      string
+		predicate Predicate
+		files     []string
+	}{
+		{"partition", ColumnPredicate("year", Equal, "2026"), []string{"table/year=2026/month=__HIVE_DEFAULT_PARTITION__/part-0.parquet"}},
+		{"null_partition", Not(ColumnPredicate("month", NotEqual, "1")), []string{
+			"table/year=20%2F25/month=1/part-1.parquet",
+			"table/year=2026/month=__HIVE_DEFAULT_PARTITION__/part-0.parquet",
+		}},
+		{"statistics", ColumnPredicate("id", GreaterThanOrEqual, 25), []string{
+			"table/year=20%2F25/month=2/part-2.parquet",
+			"table/year=20%2F25/month=3/part-3.parquet",
+		}},
+		{"combined", And(ColumnPredicate("month", Equal, "2"), ColumnPredicate("id", LessThan, 25)), []string{
+			"table/year=20%2F25/month=2/part-2.parquet",
+		}},
+		{"none", ColumnPredicate("id", GreaterThan, 100), nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ds, err := OpenDataset(fsys, "table", WithDatasetPredicate(tt.predicate))
+			require.NoError(t, err)
+			require.Equal(t, len(tt.files), len(ds.Files()))
+			if len(tt.files) > 0 {
+				require.Equal(t, tt.files, ds.Files())
+			}
+		})
+	}
+
+	ds, err = OpenDataset(fsys, "table", WithDatasetPredicate(ColumnPredicate("month", Equal, "3")), WithDatasetReaderOptions(WithColumns("id")))
+	require.NoError(t, err)
+	dsRows = readDatasetRows(t, ds)
+	require.Len(t, dsRows, 10)
+	require.Equal(t, map[string]interface{}{"year": []byte("20/25"), "month": []byte("3"), "id": int64(30)}, dsRows[0])
+
+	_, err = OpenDataset(fsys, "table", WithDatasetPredicate(ColumnPredicate("day", Equal, "1")))
+	require.EqualError(t, err, `file "table/year=20%2F25/month=1/part-1.parquet": predicate column "day" not found`)
+}
+
+func TestDatasetPartitionSchema(t *testing.T) {
+	var rows []map[string]interface{}
+	for _, month := range []int32{1, 9, 10, 12} {
+		rows = append(rows, map[string]interface{}{"year": []byte("2026"), "month": month, "id": int64(month)})
+	}
+	rows = append(rows, map[string]interface{}{"year": []byte("2026"), "id": int64(0)})
+	fsys := writeDatasetTestFS(t, rows)
+
+	// without a schema definition, partition values are strings and compared lexicographically.
+	ds, err := OpenDataset(fsys, "table", WithDatasetPredicate(ColumnPredicate("month", GreaterThan, 9)))
+	require.Nil(t, ds)
+	require.EqualError(t, err, `file "table/year=2026/month=1/part-0.parquet": invalid value for predicate on column "month": can't use int for BYTE_ARRAY column`)
+
+	ds, err = OpenDataset(fsys, "table", WithPartitionSchemaDefinition(datasetTestSchema(t)),
+		WithDatasetPredicate(ColumnPredicate("month", GreaterThan, 9)))
+	require.NoError(t, err)
+	require.Equal(t, []string{
+		"table/year=2026/month=10/part-2.parquet",
+		"table/year=2026/month=12/part-3.parquet",
+	}, ds.Files())
+
+	ds, err = OpenDataset(fsys, "table", WithPartitionSchemaDefinition(datasetTestSchema(t)),
+		WithDatasetPredicate(Or(ColumnPredicate("month", LessThan, 2), Not(ColumnPredicate("month", NotEqual, 0)))))
+	require.NoError(t, err)
+	require.Equal(t, []map[string]interface{}{
+		{"year": []byte("2026"), "month": int32(1), "id": int64(1)},
+		{"year": []byte("2026"), "id": int64(0)},
+	}, readDatasetRows(t, ds))
+
+	// partition columns that are not part of the schema definition are strings.
+	sd, err := parquetschema.ParseSchemaDefinition(`message test {
+		optional int64 month;
+	}`)
+	require.NoError(t, err)
+	ds, err = OpenDataset(fsys, "table", WithPartitionSchemaDefinition(sd), WithDatasetPredicate(ColumnPredicate("month", Equal, 12)))
+	require.NoError(t, err)
+	require.Equal(t, []map[string]interface{}{
+		{"year": []byte("2026"), "month": int64(12), "id": int64(12)},
+	}, readDatasetRows(t, ds))
+
+	fsys["table/year=2026/month=abc/part-4.parquet"] = fsys["table/year=2026/month=1/part-0.parquet"]
+	_, err = OpenDataset(fsys, "table", WithPartitionSchemaDefinition(datasetTestSchema(t)))
+	require.EqualError(t, err, `file "table/year=2026/month=abc/part-4.parquet": partition column "month": strconv.ParseInt: parsing "abc": invalid syntax`)
+	delete(fsys, "table/year=2026/month=abc/part-4.parquet")
+
+	sd, err = parquetschema.ParseSchemaDefinition(`message test {
+		optional group month {
+			optional int32 value;
+		}
+	}`)
+	require.NoError(t, err)
+	_, err = OpenDataset(fsys, "table", WithPartitionSchemaDefinition(sd))
+	require.EqualError(t, err, `partition column "month" is not of a primitive type`)
+}
+
+func TestParsePartitionValue(t *testing.T) {
+	sd, err := parquetschema.ParseSchemaDefinition(`message test {
+		required int64 a;
+		required int32 b (INT(32, false));
+		required double c;
+		required float d;
+		required boolean e;
+		required fixed_len_byte_array(2) f;
+		required binary g (STRING);
+	}`)
+	require.NoError(t, err)
+
+	for _, tt := range []struct {
+		column string
+		value  interface{}
+	}{
+		{"a", int64(-2026)},
+		{"b", uint32(4000000000)},
+		{"c", 1.5},
+		{"d", float32(0.1)},
+		{"e", true},
+		{"f", []byte("ab")},
+		{"g", []byte("a/b")},
+	} {
+		elem := sd.SubSchema(tt.column).SchemaElement()
+		s, err := partitionValue(elem, tt.value)
+		require.NoError(t, err)
+		v, err := parsePartitionValue(elem, []byte(unescapePartitionPath(s)))
+		require.NoError(t, err)
+		require.Equal(t, tt.value, v)
+	}
+
+	_, err = parsePartitionValue(sd.SubSchema("b").SchemaElement(), []byte("-1"))
+	require.Error(t, err)
+	_, err = parsePartitionValue(sd.SubSchema("f").SchemaElement(), []byte("abc"))
+	require.EqualError(t, err, "the size of the value should be 2 but is 3")
+	v, err := parsePartitionValue(sd.SubSchema("a").SchemaElement(), nil)
+	require.NoError(t, err)
+	require.Nil(t, v)
+}
+
+func TestDatasetErrors(t *testing.T) {
+	fsys := writeDatasetTestFS(t, []map[string]interface{}{{"year": []byte("2026"), "month": int32(1), "id": int64(1)}})
+	file := fsys["table/year=2026/month=1/part-0.parquet"]
+
+	fsys["table/year=2026/part-1.parquet"] = file
+	_, err := OpenDataset(fsys, "table")
+	require.EqualError(t, err, `file "table/year=2026/part-1.parquet": partition keys [year] differ from the partition keys [year month] of file "table/year=2026/month=1/part-0.parquet"`)
+	delete(fsys, "table/year=2026/part-1.parquet")
+
+	fsys["table/year=2026/month=2/part-1.parquet"] = &fstest.MapFile{Data: writePredicateTestFile(t)}
+	_, err = OpenDataset(fsys, "table")
+	require.EqualError(t, err, `file "table/year=2026/month=2/part-1.parquet": schema differs from the schema of file "table/year=2026/month=1/part-0.parquet"`)
+
+	fsys = fstest.MapFS{"table/id=1/part-0.parquet": file}
+	_, err = OpenDataset(fsys, "table")
+	require.EqualError(t, err, `partition key "id" is also a column of file "table/id=1/part-0.parquet"`)
+
+	fsys = fstest.MapFS{"table/part-0.parquet": &fstest.MapFile{Data: []byte("invalid")}}
+	_, err = OpenDataset(fsys, "table")
+	require.Error(t, err)
+
+	ds, err := OpenDataset(fstest.MapFS{"table/_metadata": file}, "table")
+	require.NoError(t, err)
+	require.Empty(t, ds.Files())
+	_, err = ds.NextRow()
+	require.Equal(t, io.EOF, err)
+}
+
+func TestUnescapePartitionPath(t *testing.T) {
+	for _, s := range []string{"2026", "a/b=c", "100%", "%zz", "\x01\"#%'*/:=?\\{[]^\x7f"} {
+		require.Equal(t, s, unescapePartitionPath(escapePartitionPath(s)))
+	}
+	require.Equal(t, "%zz%", unescapePartitionPath("%zz%"))
+}
+
+func TestDatasetWithTargetSchema(t *testing.T) {
+	fsys := fstest.MapFS{
+		"table/part-0.parquet": &fstest.MapFile{Data: writeProjectionTestFile(t, `message v1 {
+			required int32 id;
+		}`, map[string]interface{}{"id": int32(1)})},
+		"table/part-1.parquet": &fstest.MapFile{Data: writeProjectionTestFile(t, `message v2 {
+			required int64 id;
+			optional binary name (STRING);
+		}`, map[string]interface{}{"id": int64(2), "name": []byte("b")})},
+	}
+
+	_, err := OpenDataset(fsys, "table")
+	require.EqualError(t, err, `file "table/part-1.parquet": schema differs from the schema of file "table/part-0.parquet"`)
+
+	target, err := parquetschema.ParseSchemaDefinition(`message v2 {
+		required int64 id;
+		optional binary name (STRING);
+	}`)
+	require.NoError(t, err)
+	ds, err := OpenDataset(fsys, "table", WithDatasetReaderOptions(WithTargetSchemaDefinition(target)),
+		WithDatasetPredicate(ColumnPredicate("id", LessThan, 5)))
+	require.NoError(t, err)
+	require.Equal(t, []map[string]interface{}{
+		{"id": int64(1)},
+		{"id": int64(2), "name": []byte("b")},
+	}, readDatasetRows(t, ds))
+
+	// the predicate refers to the columns of the target schema, which are null if they are missing.
+	ds, err = OpenDataset(fsys, "table", WithDatasetReaderOptions(WithTargetSchemaDefinition(target)),
+		WithDatasetPredicate(ColumnPredicate("name", Equal, "b")))
+	require.NoError(t, err)
+	require.Equal(t, []string{"table/part-1.parquet"}, ds.Files())
+
+	_, err = OpenDataset(fsys, "table", WithDatasetReaderOptions(WithTargetSchemaDefinition(target)),
+		WithDatasetPredicate(ColumnPredicate("comment", Equal, "b")))
+	require.EqualError(t, err, `file "table/part-0.parquet": predicate column "comment" not found`)
+}
@@ -0,0 +1,364 @@
+package goparquet
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/fraugster/parquet-go/parquet"
+	"github.com/fraugster/parquet-go/parquetschema"
+	"github.com/pkg/errors"
+)
+
+// DefaultPartitionName is the name of the partition directory of rows whose partition column is
+// null or empty, as used by Hive.
+const DefaultPartitionName = "__HIVE_DEFAULT_PARTITION__"
+
+// FileCreator creates the files of a dataset.
+type FileCreator interface {
+	// Create creates the file at the slash-separated path, relative to the directory of the
+	// dataset. The FileCreator is responsible for creating the directories of the path.
+	Create(path string) (io.WriteCloser, error)
+}
+
+// FileCreatorFunc is a function that implements the FileCreator interface.
+type FileCreatorFunc func(path string) (io.WriteCloser, error)
+
+// Create calls f(path).
+func (f FileCreatorFunc) Create(path string) (io.WriteCloser, error) {
+	return f(path)
+}
+
+// DirFileCreator returns a FileCreator that creates the files in the directory dir of the local
+// file system. Existing files are truncated.
+func DirFileCreator(dir string) FileCreator {
+	return FileCreatorFunc(func(name string) (io.WriteCloser, error) {
+		file := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+			return nil, err
+		}
+		return os.OpenFile(file, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+	})
+}
+
+// DatasetWriter writes the rows of a Hive-style partitioned dataset. The values of the partition
+// columns of a row determine the directory of the file the row is written to, for example
+// year=2026/month=10/part-0.parquet. The partition columns are not stored in the files themselves.
+type DatasetWriter struct {
+	creator          FileCreator
+	schemaDef        *parquetschema.SchemaDefinition
+	partitionColumns []*parquetschema.ColumnDefinition
+
+	options      []FileWriterOption
+	maxOpenFiles int
+	targetSize   int64
+
+	partitions map[string]*datasetFile
+	files      []SummaryFile
+	// the number of files that have been created, and a counter to find the least recently used file.
+	numFiles int
+	clock    int64
+}
+
+// datasetFile is the file of a partition that is currently written.
+type datasetFile struct {
+	path     string
+	w        io.WriteCloser
+	fw       *FileWriter
+	lastUsed int64
+}
+
+// DatasetWriterOption describes an option function that is applied to a DatasetWriter when it is created.
+type DatasetWriterOption func(dw *DatasetWriter)
+
+// WithFileWriterOptions sets the options of the FileWriters that write the files of the dataset. The
+// schema definition of the files is always set by the DatasetWriter. Since the partition columns are not
+// part of the files, NewDatasetWriter returns an error if a column option refers to one of them.
+func WithFileWriterOptions(options ...FileWriterOption) DatasetWriterOption {
+	return func(dw *DatasetWriter) {
+		dw.options = append(dw.options, options...)
+	}
+}
+
+// WithMaxOpenFiles limits the number of files that are written at the same time. If a row belongs to a
+// partition that has no open file and the limit has been reached, the least recently used file is closed.
+// Subsequent rows of its partition are written to a new file. The default of 0 means no limit.
+func WithMaxOpenFiles(n int) DatasetWriterOption {
+	return func(dw *DatasetWriter) {
+		dw.maxOpenFiles = n
+	}
+}
+
+// WithTargetFileSize sets the size at which a file is closed, and subsequent rows of its partition are
+// written to a new file. The size of a file is estimated from the data written so far and the uncompressed
+// size of its current row group, so files may be smaller or larger than the target size. The default of 0
+// means that files are only closed when the DatasetWriter is closed.
+func WithTargetFileSize(size int64) DatasetWriterOption {
+	return func(dw *DatasetWriter) {
+		dw.targetSize = size
+	}
+}
+
+// NewDatasetWriter creates a new DatasetWriter that creates the files of the dataset using creator. The
+// rows of the dataset need to match the schema definition. The partition columns need to be top-level
+// columns of a primitive type other than INT96 that are not repeated, and are removed from the schema
+// of the files.
+func NewDatasetWriter(creator FileCreator, sd *parquetschema.SchemaDefinition, partitionColumns []string, options ...DatasetWriterOption) (*DatasetWriter, error) {
+	if err := sd.Validate(); err != nil {
+		return nil, errors.Wrap(err, "invalid schema definition")
+	}
+
+	dw := &DatasetWriter{
+		creator:    creator,
+		partitions: make(map[string]*datasetFile),
+	}
+	for _, opt := range options {
+		opt(dw)
+	}
+
+	partitioned := make(map[string]bool, len(partitionColumns))
+	for _, name := range partitionColumns {
+		col := sd.SubSchema(name)
+		if col == nil {
+			return nil, errors.Errorf("partition column %q not found in schema definition", name)
+		}
+		if partitioned[name] {
+			return nil, errors.Errorf("duplicate partition column %q", name)
+		}
+		elem := col.SchemaElement()
+		if elem.Type == nil || len(col.RootColumn.Children) > 0 {
+			return nil, errors.Errorf("partition column %q is not of a primitive type", name)
+		}
+		if elem.GetRepetitionType() == parquet.FieldRepetitionType_REPEATED {
+			return nil, errors.Errorf("partition column %q is repeated", name)
+		}
+		if elem.GetType() == parquet.Type_INT96 {
+			return nil, errors.Errorf("partition column %q is of type INT96, which has no string representation", name)
+		}
+		partitioned[name] = true
+		dw.partitionColumns = append(dw.partitionColumns, col.RootColumn)
+	}
+
+	root := *sd.RootColumn
+	elem := *root.SchemaElement
+	root.SchemaElement = &elem
+	root.Children = nil
+	for _, c := range sd.RootColumn.Children {
+		if !partitioned[c.SchemaElement.Name] {
+			root.Children = append(root.Children, c)
+		}
+	}
+	if len(root.Children) == 0 {
+		return nil, errors.New("the schema definition has no columns apart from the partition columns")
+	}
+	numChildren := int32(len(root.Children))
+	elem.NumChildren = &numChildren
+	dw.schemaDef = parquetschema.SchemaDefinitionFromColumnDefinition(&root)
+
+	// column options that refer to a partition column would only fail when the first file is created.
+	if _, err := NewFileWriterWithError(ioutil.Discard, append(dw.options, WithSchemaDefinition(dw.schemaDef))...); err != nil {
+		return nil, errors.Wrap(err, "invalid file writer options")
+	}
+
+	return dw, nil
+}
+
+// AddData adds a new row to the file of its partition, which is created if necessary.
+func (dw *DatasetWriter) AddData(m map[string]interface{}) error {
+	dir, err := dw.partitionPath(m)
+	if err != nil {
+		return err
+	}
+
+	data := make(map[string]interface{}, len(m))
+	for k, v := range m {
+		data[k] = v
+	}
+	for _, col := range dw.partitionColumns {
+		delete(data, col.SchemaElement.Name)
+	}
+
+	file, err := dw.partitionFile(dir)
+	if err != nil {
+		return err
+	}
+	if err := file.fw.AddData(data); err != nil {
+		return err
+	}
+
+	if dw.targetSize > 0 && file.fw.CurrentFileSize()+file.fw.CurrentRowGroupSize() >= dw.targetSize {
+		return dw.closeFile(dir)
+	}
+
+	return nil
+}
+
+// Close closes all open files of the dataset.
+func (dw *DatasetWriter) Close() error {
+	dirs := make([]string, 0, len(dw.partitions))
+	for dir := range dw.partitions {
+		dirs = append(dirs, dir)
+	}
+	sort.Strings(dirs)
+
+	var firstErr error
+	for _, dir := range dirs {
+		if err := dw.closeFile(dir); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// Files returns the files of the dataset that have been closed, in the order they were closed. Their
+// file meta data can be merged into the summary files of the dataset using MergeFileMetaData.
+func (dw *DatasetWriter) Files() []SummaryFile {
+	return dw.files
+}
+
+// partitionFile returns the open file of the partition, and creates it if necessary.
+func (dw *DatasetWriter) partitionFile(dir string) (*datasetFile, error) {
+	dw.clock++
+
+	if file, ok := dw.partitions[dir]; ok {
+		file.lastUsed = dw.clock
+		return file, nil
+	}
+
+	if dw.maxOpenFiles > 0 && len(dw.partitions) >= dw.maxOpenFiles {
+		var lru *datasetFile
+		var lruDir string
+		for d, f := range dw.partitions {
+			if lru == nil || f.lastUsed < lru.lastUsed {
+				lru, lruDir = f, d
+			}
+		}
+		if err := dw.closeFile(lruDir); err != nil {
+			return nil, err
+		}
+	}
+
+	p := path.Join(dir, fmt.Sprintf("part-%d.parquet", dw.numFiles))
+	w, err := dw.creator.Create(p)
+	if err != nil {
+		return nil, errors.Wrapf(err, "create file %q failed", p)
+	}
+	dw.numFiles++
+
+	fw, err := NewFileWriterWithError(w, append(dw.options, WithSchemaDefinition(dw.schemaDef))...)
+	if err != nil {
+		_ = w.Close()
+		return nil, errors.Wrapf(err, "create file writer for %q failed", p)
+	}
+
+	file := &datasetFile{
+		path:     p,
+		w:        w,
+		fw:       fw,
+		lastUsed: dw.clock,
+	}
+	dw.partitions[dir] = file
+
+	return file, nil
+}
+
+// closeFile closes the open file of the partition.
+func (dw *DatasetWriter) closeFile(dir string) error {
+	file := dw.partitions[dir]
+	delete(dw.partitions, dir)
+
+	if err := file.fw.Close(); err != nil {
+		_ = file.w.Close()
+		return errors.Wrapf(err, "close file %q failed", file.path)
+	}
+	if err := file.w.Close(); err != nil {
+		return errors.Wrapf(err, "close file %q failed", file.path)
+	}
+
+	dw.files = append(dw.files, SummaryFile{Path: file.path, MetaData: file.fw.FileMetaData()})
+	return nil
+}
+
+// partitionPath returns the directory of the partition of the row, like year=2026/month=10.
+func (dw *DatasetWriter) partitionPath(m map[string]interface{}) (string, error) {
+	dirs := make([]string, 0, len(dw.partitionColumns))
+	for _, col := range dw.partitionColumns {
+		name := col.SchemaElement.Name
+		v, ok := m[name]
+		if (!ok || v == nil) && col.SchemaElement.GetRepetitionType() == parquet.FieldRepetitionType_REQUIRED {
+			return "", errors.Errorf("the value of the required partition column %q is missing", name)
+		}
+
+		value, err := partitionValue(col.SchemaElement, v)
+		if err != nil {
+			return "", errors.Wrapf(err, "partition column %q", name)
+		}
+		dirs = append(dirs, escapePartitionPath(name)+"="+value)
+	}
+
+	return path.Join(dirs...), nil
+}
+
+// partitionValue returns the escaped string representation of a value of the partition column
+// described by elem. Just like with FileWriter.AddData, the type of the value needs to match the
+// type of the column. Values of BYTE_ARRAY and FIXED_LEN_BYTE_ARRAY columns can also be strings.
+func partitionValue(elem *parquet.SchemaElement, v interface{}) (string, error) {
+	typ, unsigned := elem.GetType(), isUnsigned(elem)
+
+	var s string
+	valid := true
+	switch v := v.(type) {
+	case nil:
+	case []byte:
+		s, valid = string(v), typ == parquet.Type_BYTE_ARRAY || typ == parquet.Type_FIXED_LEN_BYTE_ARRAY
+	case string:
+		s, valid = v, typ == parquet.Type_BYTE_ARRAY || typ == parquet.Type_FIXED_LEN_BYTE_ARRAY
+	case bool:
+		s, valid = strconv.FormatBool(v), typ == parquet.Type_BOOLEAN
+	case int32:
+		s, valid = strconv.FormatInt(int64(v), 10), typ == parquet.Type_INT32 && !unsigned
+	case uint32:
+		s, valid = strconv.FormatUint(uint64(v), 10), typ == parquet.Type_INT32 && unsigned
+	case int64:
+		s, valid = strconv.FormatInt(v, 10), typ == parquet.Type_INT64 && !unsigned
+	case uint64:
+		s, valid = strconv.FormatUint(v, 10), typ == parquet.Type_INT64 && unsigned
+	case float32:
+		s, valid = strconv.FormatFloat(float64(v), 'g', -1, 32), typ == parquet.Type_FLOAT
+	case float64:
+		s, valid = strconv.FormatFloat(v, 'g', -1, 64), typ == parquet.Type_DOUBLE
+	default:
+		valid = false
+	}
+	if !valid {
+		return "", errors.Errorf("unsupported partition value of type %T for %s column", v, typ)
+	}
+	if typ == parquet.Type_FIXED_LEN_BYTE_ARRAY && v != nil && len(s) != int(elem.GetTypeLength()) {
+		return "", errors.Errorf("the size of the partition value should be %d but is %d", elem.GetTypeLength(), len(s))
+	}
+
+	if s == "" {
+		return DefaultPartitionName, nil
+	}
+	return escapePartitionPath(s), nil
+}
+
+// escapePartitionPath escapes the characters of a partition column name or value that are escaped by Hive.
+func escapePartitionPath(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c < 0x20 || c == 0x7f || strings.IndexByte("\"#%'*/:=?\\{[]^", c) >= 0 {
+			fmt.Fprintf(&b, "%%%02X", c)
+			continue
+		}
+		b.WriteByte(c)
+	}
+	return b.String()
+}
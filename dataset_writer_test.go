@@ -0,0 +1,237 @@
+package goparquet
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/fraugster/parquet-go/parquet"
+	"github.com/fraugster/parquet-go/parquetschema"
+	"github.com/stretchr/testify/require"
+)
+
+type datasetTestFile struct {
+	bytes.Buffer
+	closed bool
+}
+
+func (f *datasetTestFile) Close() error {
+	f.closed = true
+	return nil
+}
+
+func datasetTestCreator(files map[string]*datasetTestFile) FileCreator {
+	return FileCreatorFunc(func(path string) (io.WriteCloser, error) {
+		f := &datasetTestFile{}
+		files[path] = f
+		return f, nil
+	})
+}
+
+func datasetTestSchema(t *testing.T) *parquetschema.SchemaDefinition {
+	sd, err := parquetschema.ParseSchemaDefinition(`message test {
+		required binary year (STRING);
+		optional int32 month;
+		required int64 id;
+		optional binary name (STRING);
+	}`)
+	require.NoError(t, err)
+	return sd
+}
+
+func readDatasetTestFile(t *testing.T, file []byte) []map[string]interface{} {
+	r, err := NewFileReader(bytes.NewReader(file))
+	require.NoError(t, err)
+	require.Equal(t, "message test {\n  required int64 id;\n  optional binary name (STRING);\n}\n", r.GetSchemaDefinition().String())
+	return readAllRows(t, r)
+}
+
+func TestDatasetWriter(t *testing.T) {
+	files := make(map[string]*datasetTestFile)
+	dw, err := NewDatasetWriter(datasetTestCreator(files), datasetTestSchema(t), []string{"year", "month"})
+	require.NoError(t, err)
+
+	rows := []map[string]interface{}{
+		{"year": []byte("2026"), "month": int32(10), "id": int64(1), "name": []byte("a")},
+		{"year": []byte("2026"), "month": int32(9), "id": int64(2)},
+		{"year": []byte("2026"), "month": int32(10), "id": int64(3), "name": []byte("c")},
+		{"year": []byte("2025"), "id": int64(4)},
+		{"year": []byte("20/26"), "month": int32(1), "id": int64(5)},
+	}
+	for _, row := range rows {
+		require.NoError(t, dw.AddData(row))
+	}
+	require.Contains(t, rows[0], "year")
+	require.NoError(t, dw.Close())
+
+	require.Len(t, files, 4)
+	for _, f := range files {
+		require.True(t, f.closed)
+	}
+	require.Equal(t, []map[string]interface{}{
+		{"id": int64(1), "name": []byte("a")},
+		{"id": int64(3), "name": []byte("c")},
+	}, readDatasetTestFile(t, files["year=2026/month=10/part-0.parquet"].Bytes()))
+	require.Equal(t, []map[string]interface{}{{"id": int64(2)}}, readDatasetTestFile(t, files["year=2026/month=9/part-1.parquet"].Bytes()))
+	require.Equal(t, []map[string]interface{}{{"id": int64(4)}}, readDatasetTestFile(t, files["year=2025/month=__HIVE_DEFAULT_PARTITION__/part-2.parquet"].Bytes()))
+	require.Equal(t, []map[string]interface{}{{"id": int64(5)}}, readDatasetTestFile(t, files["year=20%2F26/month=1/part-3.parquet"].Bytes()))
+
+	var paths []string
+	for _, f := range dw.Files() {
+		paths = append(paths, f.Path)
+		require.NotNil(t, f.MetaData)
+	}
+	require.Equal(t, []string{
+		"year=20%2F26/month=1/part-3.parquet",
+		"year=2025/month=__HIVE_DEFAULT_PARTITION__/part-2.parquet",
+		"year=2026/month=10/part-0.parquet",
+		"year=2026/month=9/part-1.parquet",
+	}, paths)
+
+	meta, err := MergeFileMetaData(dw.Files()...)
+	require.NoError(t, err)
+	require.Equal(t, int64(5), meta.NumRows)
+
+	dw, err = NewDatasetWriter(datasetTestCreator(files), datasetTestSchema(t), []string{"year"})
+	require.NoError(t, err)
+	require.EqualError(t, dw.AddData(map[string]interface{}{"id": int64(1)}), `the value of the required partition column "year" is missing`)
+	require.EqualError(t, dw.AddData(map[string]interface{}{"year": 2026, "id": int64(1)}), `partition column "year": unsupported partition value of type int for BYTE_ARRAY column`)
+	require.Len(t, files, 4)
+}
+
+func TestPartitionValue(t *testing.T) {
+	sd, err := parquetschema.ParseSchemaDefinition(`message test {
+		required int64 a;
+		required int32 b (INT(32, false));
+		required double c;
+		required float d;
+		required boolean e;
+		required fixed_len_byte_array(2) f;
+		required binary g (STRING);
+	}`)
+	require.NoError(t, err)
+	elem := func(name string) *parquet.SchemaElement {
+		return sd.SubSchema(name).SchemaElement()
+	}
+
+	tests := []struct {
+		column   string
+		value    interface{}
+		expected string
+		err      string
+	}{
+		{"a", int64(-2026), "-2026", ""},
+		{"a", "abc", "", "unsupported partition value of type string for INT64 column"},
+		{"a", 1.5, "", "unsupported partition value of type float64 for INT64 column"},
+		{"a", int32(1), "", "unsupported partition value of type int32 for INT64 column"},
+		{"a", uint64(1), "", "unsupported partition value of type uint64 for INT64 column"},
+		{"b", uint32(4000000000), "4000000000", ""},
+		{"b", int32(1), "", "unsupported partition value of type int32 for INT32 column"},
+		{"c", 1.5, "1.5", ""},
+		{"c", float32(1.5), "", "unsupported partition value of type float32 for DOUBLE column"},
+		{"d", float32(0.1), "0.1", ""},
+		{"e", true, "true", ""},
+		{"e", []byte("true"), "", "unsupported partition value of type []uint8 for BOOLEAN column"},
+		{"f", []byte("ab"), "ab", ""},
+		{"f", "abc", "", "the size of the partition value should be 2 but is 3"},
+		{"g", "a/b", "a%2Fb", ""},
+		{"g", []byte{}, DefaultPartitionName, ""},
+		{"g", nil, DefaultPartitionName, ""},
+		{"g", int64(1), "", "unsupported partition value of type int64 for BYTE_ARRAY column"},
+	}
+	for _, tt := range tests {
+		value, err := partitionValue(elem(tt.column), tt.value)
+		if tt.err != "" {
+			require.EqualError(t, err, tt.err, "%s %v", tt.column, tt.value)
+			continue
+		}
+		require.NoError(t, err)
+		require.Equal(t, tt.expected, value)
+	}
+}
+
+func TestDatasetWriterRollFiles(t *testing.T) {
+	files := make(map[string]*datasetTestFile)
+	dw, err := NewDatasetWriter(datasetTestCreator(files), datasetTestSchema(t), []string{"year", "month"},
+		WithMaxOpenFiles(2), WithTargetFileSize(400), WithFileWriterOptions(WithMaxRowGroupSize(100)))
+	require.NoError(t, err)
+
+	// the file of month 1 is closed when the file of month 3 is created.
+	for i := 0; i < 3; i++ {
+		require.NoError(t, dw.AddData(map[string]interface{}{"year": []byte("2026"), "month": int32(i + 1), "id": int64(i)}))
+	}
+	require.Len(t, dw.Files(), 1)
+	require.Equal(t, "year=2026/month=1/part-0.parquet", dw.Files()[0].Path)
+
+	for i := 0; i < 100; i++ {
+		require.NoError(t, dw.AddData(map[string]interface{}{"year": []byte("2026"), "month": int32(2), "id": int64(i)}))
+	}
+	require.NoError(t, dw.Close())
+	require.Greater(t, len(dw.Files()), 4)
+
+	var numRows int64
+	for _, f := range dw.Files() {
+		numRows += f.MetaData.NumRows
+	}
+	require.Equal(t, int64(103), numRows)
+}
+
+func TestNewDatasetWriterErrors(t *testing.T) {
+	creator := DirFileCreator("")
+	tests := []struct {
+		columns []string
+		err     string
+	}{
+		{[]string{"day"}, `partition column "day" not found in schema definition`},
+		{[]string{"year", "year"}, `duplicate partition column "year"`},
+		{[]string{"year", "month", "id", "name"}, "the schema definition has no columns apart from the partition columns"},
+	}
+	for _, tt := range tests {
+		_, err := NewDatasetWriter(creator, datasetTestSchema(t), tt.columns)
+		require.EqualError(t, err, tt.err)
+	}
+
+	sd, err := parquetschema.ParseSchemaDefinition(`message test {
+		repeated int64 ids;
+		required group data {
+			required int64 id;
+		}
+	}`)
+	require.NoError(t, err)
+	_, err = NewDatasetWriter(creator, sd, []string{"ids"})
+	require.EqualError(t, err, `partition column "ids" is repeated`)
+	_, err = NewDatasetWriter(creator, sd, []string{"data"})
+	require.EqualError(t, err, `partition column "data" is not of a primitive type`)
+
+	sd, err = parquetschema.ParseSchemaDefinition(`message test {
+		required int96 ts;
+		required int64 id;
+	}`)
+	require.NoError(t, err)
+	_, err = NewDatasetWriter(creator, sd, []string{"ts"})
+	require.EqualError(t, err, `partition column "ts" is of type INT96, which has no string representation`)
+
+	// the partition columns are not part of the schema of the files.
+	_, err = NewDatasetWriter(creator, datasetTestSchema(t), []string{"year"}, WithFileWriterOptions(WithColumnCompression("year", parquet.CompressionCodec_GZIP)))
+	require.EqualError(t, err, `invalid file writer options: column "year" from the column options not found in schema definition`)
+	_, err = NewDatasetWriter(creator, datasetTestSchema(t), []string{"year"}, WithFileWriterOptions(WithBloomFilter("year", 0, 0)))
+	require.EqualError(t, err, `invalid file writer options: bloom filter column "year" not found in schema definition`)
+}
+
+func TestDirFileCreator(t *testing.T) {
+	dir, err := ioutil.TempDir("", "dataset")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	dw, err := NewDatasetWriter(DirFileCreator(dir), datasetTestSchema(t), []string{"year", "month"})
+	require.NoError(t, err)
+	require.NoError(t, dw.AddData(map[string]interface{}{"year": []byte("2026"), "month": int32(10), "id": int64(1)}))
+	require.NoError(t, dw.Close())
+
+	file, err := ioutil.ReadFile(filepath.Join(dir, "year=2026", "month=10", "part-0.parquet"))
+	require.NoError(t, err)
+	require.Equal(t, []map[string]interface{}{{"id": int64(1)}}, readDatasetTestFile(t, file))
+}
@@ -69,12 +69,26 @@
 // To read from files, create a FileReader object using the NewFileReader function. You can
 // optionally provide a list of columns to read. If these are set, only these columns are read
 // from the file, while all other columns are ignored. If no columns are proided, then all
-// columns are read.
+// columns are read. For random-access sources like blobs in an object storage, you can use the
+// NewFileReaderAt function instead, which only requires an io.ReaderAt and the size of the file.
 //
 // With the FileReader, you can then go through the row groups (using PreLoad and SkipRowGroup).
 // and iterate through the row data in each row group (using NextRow). To find out how many rows
 // to expect in total and per row group, use the NumRows and RowGroupNumRows methods. The number
 // of row groups can be determined using the RowGroupCount method.
+//
+// You can use NewFileReaderWithOptions together with the WithPredicate option to skip row groups
+// whose column statistics show that they can't contain any rows matching a predicate. Predicates
+// are created using ColumnPredicate and can be combined using And, Or and Not. If the file was
+// written with page indexes, the reader also only reads the pages that may contain matching rows.
+// Please note that this only prunes the data that is read, so rows that don't match the predicate
+// can still be returned.
+//
+// By default, the FileReader loads all selected column chunks of a row group into memory when the
+// row group is loaded. For large row groups, the WithStreaming option can be used instead, which
+// makes the reader decode the pages of every column one at a time while the rows are read. For
+// wide tables, the WithConcurrency option makes the reader read and decode the column chunks of a
+// row group in parallel.
 package goparquet
 
 //go:generate go run bitpack_gen.go
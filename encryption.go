@@ -0,0 +1,761 @@
+package goparquet
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math"
+
+	"github.com/fraugster/parquet-go/parquet"
+	"github.com/pkg/errors"
+)
+
+// EncryptionAlgorithm is an encryption algorithm of the parquet modular encryption.
+type EncryptionAlgorithm int
+
+const (
+	// AESGCMV1 encrypts all modules of a file with AES-GCM, which protects the confidentiality
+	// and the integrity of all data. This is the default.
+	AESGCMV1 EncryptionAlgorithm = iota
+	// AESGCMCTRV1 encrypts the page data with AES-CTR and all other modules, like page headers
+	// and the footer, with AES-GCM. It is faster than AESGCMV1, but doesn't protect the
+	// integrity of the page data.
+	AESGCMCTRV1
+)
+
+// magicEncrypted is the magic of files with an encrypted footer.
+var magicEncrypted = []byte{'P', 'A', 'R', 'E'}
+
+// The module types of the parquet modular encryption. The module type is part of the additional
+// authenticated data of every encrypted module.
+const (
+	moduleFooter byte = iota
+	moduleColumnMetaData
+	moduleDataPage
+	moduleDictionaryPage
+	moduleDataPageHeader
+	moduleDictionaryPageHeader
+	moduleColumnIndex
+	moduleOffsetIndex
+	moduleBloomFilterHeader
+	moduleBloomFilterBitset
+)
+
+const (
+	encryptionNonceLength = 12
+	encryptionTagLength   = 16
+	aadFileUniqueLength   = 8
+)
+
+// moduleAAD returns the additional authenticated data of a module. The row group and column
+// ordinals are only part of it for modules other than the footer, and the page ordinal only if
+// it isn't negative.
+func moduleAAD(fileAAD []byte, module byte, rowGroup, column, page int) []byte {
+	aad := make([]byte, len(fileAAD), len(fileAAD)+7)
+	copy(aad, fileAAD)
+	aad = append(aad, module)
+	if module == moduleFooter {
+		return aad
+	}
+	aad = appendOrdinal(aad, rowGroup)
+	aad = appendOrdinal(aad, column)
+	if page >= 0 {
+		aad = appendOrdinal(aad, page)
+	}
+	return aad
+}
+
+func appendOrdinal(aad []byte, ordinal int) []byte {
+	return append(aad, byte(ordinal), byte(ordinal>>8))
+}
+
+// moduleCipher encrypts modules with a single key.
+type moduleCipher struct {
+	block cipher.Block
+	gcm   cipher.AEAD
+	// ctr is set if page data is encrypted with AES-CTR instead of AES-GCM.
+	ctr bool
+}
+
+func newModuleCipher(key []byte, algorithm EncryptionAlgorithm) (*moduleCipher, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid encryption key")
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	return &moduleCipher{block: block, gcm: gcm, ctr: algorithm == AESGCMCTRV1}, nil
+}
+
+// encrypt encrypts the module data. The result consists of its length as 4 byte little-endian
+// integer, followed by the nonce and the ciphertext, which ends with the authentication tag
+// when using AES-GCM.
+func (c *moduleCipher) encrypt(module byte, aad, data []byte) ([]byte, error) {
+	ctr := c.ctr && (module == moduleDataPage || module == moduleDictionaryPage)
+
+	size := encryptionNonceLength + len(data)
+	if !ctr {
+		size += encryptionTagLength
+	}
+	ret := make([]byte, 4+encryptionNonceLength, 4+size)
+	binary.LittleEndian.PutUint32(ret, uint32(size))
+	nonce := ret[4:]
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, errors.Wrap(err, "creating nonce failed")
+	}
+
+	if !ctr {
+		return c.gcm.Seal(ret, nonce, data, aad), nil
+	}
+
+	// the initialization vector of AES-CTR consists of the nonce and a 4 byte big-endian
+	// counter that starts at 1.
+	iv := make([]byte, aes.BlockSize)
+	copy(iv, nonce)
+	iv[aes.BlockSize-1] = 1
+	ret = ret[:4+size]
+	cipher.NewCTR(c.block, iv).XORKeyStream(ret[4+encryptionNonceLength:], data)
+	return ret, nil
+}
+
+// decrypt decrypts the module data, which consists of the nonce followed by the ciphertext,
+// without the length that precedes it in the file.
+func (c *moduleCipher) decrypt(module byte, aad, data []byte) ([]byte, error) {
+	ctr := c.ctr && (module == moduleDataPage || module == moduleDictionaryPage)
+
+	minSize := encryptionNonceLength
+	if !ctr {
+		minSize += encryptionTagLength
+	}
+	if len(data) < minSize {
+		return nil, errors.Errorf("invalid encrypted module size %d", len(data))
+	}
+	nonce, ciphertext := data[:encryptionNonceLength], data[encryptionNonceLength:]
+
+	if !ctr {
+		plaintext, err := c.gcm.Open(nil, nonce, ciphertext, aad)
+		if err != nil {
+			return nil, errors.Wrap(err, "decrypting module failed")
+		}
+		return plaintext, nil
+	}
+
+	iv := make([]byte, aes.BlockSize)
+	copy(iv, nonce)
+	iv[aes.BlockSize-1] = 1
+	plaintext := make([]byte, len(ciphertext))
+	cipher.NewCTR(c.block, iv).XORKeyStream(plaintext, ciphertext)
+	return plaintext, nil
+}
+
+// readModule reads an encrypted module from r, and returns it without its length.
+func readModule(r io.Reader) ([]byte, error) {
+	buf := make([]byte, 4)
+	if _, err := io.ReadFull(r, buf); err != nil {
+		return nil, errors.Wrap(err, "reading encrypted module length failed")
+	}
+	size := int64(binary.LittleEndian.Uint32(buf))
+
+	// the module is not read into a buffer of its stated size at once, so that a corrupted size
+	// doesn't cause a huge allocation.
+	data, err := ioutil.ReadAll(io.LimitReader(r, size))
+	if err != nil {
+		return nil, errors.Wrap(err, "reading encrypted module failed")
+	}
+	if int64(len(data)) != size {
+		return nil, errors.Wrapf(io.ErrUnexpectedEOF, "need to read %d byte of encrypted module but there was only %d byte", size, len(data))
+	}
+	return data, nil
+}
+
+// columnEncryptor encrypts the modules of a single column chunk. A nil columnEncryptor doesn't
+// encrypt anything.
+type columnEncryptor struct {
+	cipher   *moduleCipher
+	fileAAD  []byte
+	rowGroup int
+	column   int
+
+	// footerKey is set if the column is encrypted with the footer key.
+	footerKey bool
+	crypto    *parquet.ColumnCryptoMetaData
+}
+
+// encrypt encrypts a module of the column chunk. page is the ordinal of the data page within the
+// column chunk for data pages and their headers, and -1 for all other modules.
+func (ce *columnEncryptor) encrypt(module byte, page int, data []byte) ([]byte, error) {
+	if ce == nil {
+		return data, nil
+	}
+	if page > math.MaxInt16 {
+		return nil, errors.Errorf("encrypted column chunks can't contain more than %d pages", math.MaxInt16+1)
+	}
+	return ce.cipher.encrypt(module, moduleAAD(ce.fileAAD, module, ce.rowGroup, ce.column, page), data)
+}
+
+// writeThrift writes the thrift structure, encrypted as the module if ce isn't nil.
+func (ce *columnEncryptor) writeThrift(tr thriftWriter, w io.Writer, module byte, page int) error {
+	if ce == nil {
+		return writeThrift(tr, w)
+	}
+
+	buf := &bytes.Buffer{}
+	if err := writeThrift(tr, buf); err != nil {
+		return err
+	}
+	data, err := ce.encrypt(module, page, buf.Bytes())
+	if err != nil {
+		return err
+	}
+	return writeFull(w, data)
+}
+
+// columnEncryptorAt returns the encryptor of the i-th column chunk, or nil if encs is nil.
+func columnEncryptorAt(encs []*columnEncryptor, i int) *columnEncryptor {
+	if encs == nil {
+		return nil
+	}
+	return encs[i]
+}
+
+// columnDecryptorAt returns the decryptor of the i-th column chunk, or nil if decs is nil.
+func columnDecryptorAt(decs []*columnDecryptor, i int) *columnDecryptor {
+	if decs == nil {
+		return nil
+	}
+	return decs[i]
+}
+
+// columnKey is the key of an encrypted column.
+type columnKey struct {
+	key         []byte
+	keyMetadata []byte
+}
+
+// encryptionOptions contains the encryption settings of a FileWriter.
+type encryptionOptions struct {
+	algorithm         EncryptionAlgorithm
+	footerKey         []byte
+	footerKeyMetadata []byte
+	plaintextFooter   bool
+	aadPrefix         []byte
+	storeAADPrefix    bool
+	columnKeys        map[string]columnKey
+}
+
+// fileEncryptor encrypts the modules of a file.
+type fileEncryptor struct {
+	opts          *encryptionOptions
+	aadFileUnique []byte
+	fileAAD       []byte
+	footer        *moduleCipher
+	columns       map[string]*moduleCipher
+}
+
+func newFileEncryptor(opts *encryptionOptions, schema SchemaWriter) (*fileEncryptor, error) {
+	if opts.footerKey == nil {
+		return nil, errors.New("encryption requires a footer key")
+	}
+
+	fe := &fileEncryptor{
+		opts:          opts,
+		aadFileUnique: make([]byte, aadFileUniqueLength),
+		columns:       make(map[string]*moduleCipher),
+	}
+	if _, err := io.ReadFull(rand.Reader, fe.aadFileUnique); err != nil {
+		return nil, errors.Wrap(err, "creating unique file AAD failed")
+	}
+	fe.fileAAD = append(append([]byte(nil), opts.aadPrefix...), fe.aadFileUnique...)
+
+	var err error
+	if fe.footer, err = newModuleCipher(opts.footerKey, opts.algorithm); err != nil {
+		return nil, errors.Wrap(err, "footer key")
+	}
+	for name, key := range opts.columnKeys {
+		if schema.GetColumnByName(name) == nil {
+			return nil, errors.Errorf("encrypted column %q not found", name)
+		}
+		if fe.columns[name], err = newModuleCipher(key.key, opts.algorithm); err != nil {
+			return nil, errors.Wrapf(err, "column %q", name)
+		}
+	}
+
+	return fe, nil
+}
+
+// magic returns the magic at the beginning and the end of the file.
+func (fe *fileEncryptor) magic() []byte {
+	if fe.opts.plaintextFooter {
+		return magic
+	}
+	return magicEncrypted
+}
+
+// columnEncryptors returns the encryptors of the column chunks of a row group. The encryptor of
+// a column that isn't encrypted is nil. If no column keys are set, all columns are encrypted
+// with the footer key.
+func (fe *fileEncryptor) columnEncryptors(rowGroup int, cols []*Column) ([]*columnEncryptor, error) {
+	if rowGroup > math.MaxInt16 {
+		return nil, errors.Errorf("encrypted files can't contain more than %d row groups", math.MaxInt16+1)
+	}
+	if len(cols) > math.MaxInt16+1 {
+		return nil, errors.Errorf("encrypted files can't contain more than %d columns", math.MaxInt16+1)
+	}
+
+	encs := make([]*columnEncryptor, len(cols))
+	for i, col := range cols {
+		enc := &columnEncryptor{
+			fileAAD:  fe.fileAAD,
+			rowGroup: rowGroup,
+			column:   i,
+		}
+		switch c, ok := fe.columns[col.FlatName()]; {
+		case ok:
+			enc.cipher = c
+			enc.crypto = &parquet.ColumnCryptoMetaData{
+				ENCRYPTION_WITH_COLUMN_KEY: &parquet.EncryptionWithColumnKey{
+					PathInSchema: col.pathArray(),
+					KeyMetadata:  fe.opts.columnKeys[col.FlatName()].keyMetadata,
+				},
+			}
+		case len(fe.columns) == 0:
+			enc.cipher = fe.footer
+			enc.footerKey = true
+			enc.crypto = &parquet.ColumnCryptoMetaData{
+				ENCRYPTION_WITH_FOOTER_KEY: parquet.NewEncryptionWithFooterKey(),
+			}
+		default:
+			continue
+		}
+		encs[i] = enc
+	}
+
+	return encs, nil
+}
+
+// encryptColumnMetaData sets the crypto meta data of the encrypted column chunks, and encrypts
+// their column meta data unless it is protected by the encrypted footer. With a plaintext
+// footer, the column meta data is also kept in plaintext without the statistics, so that the
+// file remains readable for readers that don't have the key of the column.
+func (fe *fileEncryptor) encryptColumnMetaData(chunks []*parquet.ColumnChunk, encs []*columnEncryptor) error {
+	for i, chunk := range chunks {
+		enc := encs[i]
+		if enc == nil {
+			continue
+		}
+		chunk.CryptoMetadata = enc.crypto
+		if enc.footerKey && !fe.opts.plaintextFooter {
+			continue
+		}
+
+		buf := &bytes.Buffer{}
+		if err := enc.writeThrift(chunk.MetaData, buf, moduleColumnMetaData, -1); err != nil {
+			return err
+		}
+		chunk.EncryptedColumnMetadata = buf.Bytes()
+
+		if fe.opts.plaintextFooter {
+			meta := *chunk.MetaData
+			meta.Statistics = nil
+			meta.EncodingStats = nil
+			chunk.MetaData = &meta
+		} else {
+			chunk.MetaData = nil
+		}
+	}
+
+	return nil
+}
+
+// algorithm returns the encryption algorithm as it is stored in the file.
+func (fe *fileEncryptor) algorithm() *parquet.EncryptionAlgorithm {
+	var aadPrefix []byte
+	var supplyAADPrefix *bool
+	if fe.opts.aadPrefix != nil {
+		supply := !fe.opts.storeAADPrefix
+		supplyAADPrefix = &supply
+		if fe.opts.storeAADPrefix {
+			aadPrefix = fe.opts.aadPrefix
+		}
+	}
+
+	if fe.opts.algorithm == AESGCMCTRV1 {
+		return &parquet.EncryptionAlgorithm{
+			AES_GCM_CTR_V1: &parquet.AesGcmCtrV1{
+				AadPrefix:       aadPrefix,
+				AadFileUnique:   fe.aadFileUnique,
+				SupplyAadPrefix: supplyAADPrefix,
+			},
+		}
+	}
+	return &parquet.EncryptionAlgorithm{
+		AES_GCM_V1: &parquet.AesGcmV1{
+			AadPrefix:       aadPrefix,
+			AadFileUnique:   fe.aadFileUnique,
+			SupplyAadPrefix: supplyAADPrefix,
+		},
+	}
+}
+
+// writeFooter writes the file meta data. With an encrypted footer, the file crypto meta data is
+// written, followed by the encrypted file meta data. With a plaintext footer, the file meta data
+// is written in plaintext, followed by its signature, which consists of the nonce and the
+// authentication tag of the encrypted file meta data.
+func (fe *fileEncryptor) writeFooter(w io.Writer, meta *parquet.FileMetaData) error {
+	aad := moduleAAD(fe.fileAAD, moduleFooter, 0, 0, -1)
+
+	if !fe.opts.plaintextFooter {
+		crypto := &parquet.FileCryptoMetaData{
+			EncryptionAlgorithm: fe.algorithm(),
+			KeyMetadata:         fe.opts.footerKeyMetadata,
+		}
+		if err := writeThrift(crypto, w); err != nil {
+			return err
+		}
+
+		buf := &bytes.Buffer{}
+		if err := writeThrift(meta, buf); err != nil {
+			return err
+		}
+		data, err := fe.footer.encrypt(moduleFooter, aad, buf.Bytes())
+		if err != nil {
+			return err
+		}
+		return writeFull(w, data)
+	}
+
+	meta.EncryptionAlgorithm = fe.algorithm()
+	meta.FooterSigningKeyMetadata = fe.opts.footerKeyMetadata
+	buf := &bytes.Buffer{}
+	if err := writeThrift(meta, buf); err != nil {
+		return err
+	}
+	data, err := fe.footer.encrypt(moduleFooter, aad, buf.Bytes())
+	if err != nil {
+		return err
+	}
+
+	nonce := data[4 : 4+encryptionNonceLength]
+	tag := data[len(data)-encryptionTagLength:]
+	if err := writeFull(w, buf.Bytes()); err != nil {
+		return err
+	}
+	if err := writeFull(w, nonce); err != nil {
+		return err
+	}
+	return writeFull(w, tag)
+}
+
+// KeyRetriever retrieves the keys of encrypted parquet files.
+type KeyRetriever interface {
+	// GetKey returns the key for the key meta data that was stored in the file by the writer. It
+	// returns an error if the key is not available.
+	GetKey(keyMetadata []byte) ([]byte, error)
+}
+
+// KeyRetrieverFunc is a function that implements the KeyRetriever interface.
+type KeyRetrieverFunc func(keyMetadata []byte) ([]byte, error)
+
+// GetKey calls f(keyMetadata).
+func (f KeyRetrieverFunc) GetKey(keyMetadata []byte) ([]byte, error) {
+	return f(keyMetadata)
+}
+
+// ColumnKeyError is returned by the FileReader if an encrypted column is read, but its key can't
+// be retrieved. All other columns of the file can still be read.
+type ColumnKeyError struct {
+	// Column is the full dotted-notation name of the column.
+	Column string
+	// Err is the reason why the key is not available.
+	Err error
+}
+
+func (e *ColumnKeyError) Error() string {
+	return fmt.Sprintf("column %s: key of encrypted column not available: %v", e.Column, e.Err)
+}
+
+// Unwrap returns the reason why the key is not available.
+func (e *ColumnKeyError) Unwrap() error {
+	return e.Err
+}
+
+// columnDecryptor decrypts the modules of a single column chunk. A nil columnDecryptor doesn't
+// decrypt anything.
+type columnDecryptor struct {
+	cipher   *moduleCipher
+	fileAAD  []byte
+	rowGroup int
+	column   int
+}
+
+// decrypt decrypts a module of the column chunk. page is the ordinal of the data page within the
+// column chunk for data pages and their headers, and -1 for all other modules.
+func (cd *columnDecryptor) decrypt(module byte, page int, data []byte) ([]byte, error) {
+	if cd == nil {
+		return data, nil
+	}
+	return cd.cipher.decrypt(module, moduleAAD(cd.fileAAD, module, cd.rowGroup, cd.column, page), data)
+}
+
+// readThrift reads the thrift structure, which is encrypted as the module if cd isn't nil.
+func (cd *columnDecryptor) readThrift(tr thriftReader, r io.Reader, module byte, page int) error {
+	if cd == nil {
+		return readThrift(tr, r)
+	}
+
+	data, err := readModule(r)
+	if err != nil {
+		return err
+	}
+	if data, err = cd.decrypt(module, page, data); err != nil {
+		return err
+	}
+	return readThrift(tr, bytes.NewReader(data))
+}
+
+// readPageHeader reads a page header. page is the ordinal of the data page within the column
+// chunk, or -1 for the dictionary page.
+func (cd *columnDecryptor) readPageHeader(r io.Reader, page int) (*parquet.PageHeader, error) {
+	module := moduleDataPageHeader
+	if page < 0 {
+		module = moduleDictionaryPageHeader
+	}
+
+	ph := &parquet.PageHeader{}
+	if err := cd.readThrift(ph, r, module, page); err != nil {
+		return nil, err
+	}
+	return ph, nil
+}
+
+// decryptPage reads the page data from r and decrypts it. page is the ordinal of the data page
+// within the column chunk, or -1 for the dictionary page. Since the compressed page size in the
+// header is the size of the encrypted page data, it is replaced by the size of the decrypted page
+// data. It returns a reader for the decrypted page data.
+func (cd *columnDecryptor) decryptPage(r io.Reader, ph *parquet.PageHeader, page int) (io.Reader, error) {
+	if cd == nil {
+		return r, nil
+	}
+
+	module := moduleDataPage
+	if page < 0 {
+		module = moduleDictionaryPage
+	}
+
+	data, err := readModule(io.LimitReader(r, int64(ph.CompressedPageSize)))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data))+4 != int64(ph.CompressedPageSize) {
+		return nil, errors.Errorf("encrypted page data size %d doesn't match the page size %d", len(data)+4, ph.CompressedPageSize)
+	}
+	if data, err = cd.decrypt(module, page, data); err != nil {
+		return nil, err
+	}
+
+	ph.CompressedPageSize = int32(len(data))
+	return bytes.NewReader(data), nil
+}
+
+// fileDecryptor decrypts the modules of a file.
+type fileDecryptor struct {
+	retriever         KeyRetriever
+	algorithm         EncryptionAlgorithm
+	fileAAD           []byte
+	footerKeyMetadata []byte
+
+	// the ciphers of all keys that were retrieved, by their key meta data.
+	ciphers map[string]*moduleCipher
+}
+
+// newFileDecryptor creates the decryptor of a file that was encrypted with the algorithm. If the
+// file requires an AAD prefix that isn't stored in the file, aadPrefix needs to be provided.
+func newFileDecryptor(algorithm *parquet.EncryptionAlgorithm, footerKeyMetadata []byte, retriever KeyRetriever, aadPrefix []byte) (*fileDecryptor, error) {
+	fd := &fileDecryptor{
+		retriever:         retriever,
+		footerKeyMetadata: footerKeyMetadata,
+		ciphers:           make(map[string]*moduleCipher),
+	}
+
+	var (
+		storedAADPrefix, aadFileUnique []byte
+		supplyAADPrefix                bool
+	)
+	switch {
+	case algorithm == nil:
+		return nil, errors.New("missing encryption algorithm")
+	case algorithm.AES_GCM_V1 != nil:
+		fd.algorithm = AESGCMV1
+		storedAADPrefix = algorithm.AES_GCM_V1.AadPrefix
+		aadFileUnique = algorithm.AES_GCM_V1.AadFileUnique
+		supplyAADPrefix = algorithm.AES_GCM_V1.GetSupplyAadPrefix()
+	case algorithm.AES_GCM_CTR_V1 != nil:
+		fd.algorithm = AESGCMCTRV1
+		storedAADPrefix = algorithm.AES_GCM_CTR_V1.AadPrefix
+		aadFileUnique = algorithm.AES_GCM_CTR_V1.AadFileUnique
+		supplyAADPrefix = algorithm.AES_GCM_CTR_V1.GetSupplyAadPrefix()
+	default:
+		return nil, errors.New("unsupported encryption algorithm")
+	}
+
+	switch {
+	case aadPrefix == nil && supplyAADPrefix:
+		return nil, errors.New("the file requires an AAD prefix, but none was provided")
+	case aadPrefix != nil && storedAADPrefix != nil && !bytes.Equal(aadPrefix, storedAADPrefix):
+		return nil, errors.New("the provided AAD prefix doesn't match the AAD prefix stored in the file")
+	case aadPrefix == nil:
+		aadPrefix = storedAADPrefix
+	}
+	fd.fileAAD = append(append([]byte(nil), aadPrefix...), aadFileUnique...)
+
+	return fd, nil
+}
+
+// cipher returns the cipher of the key that belongs to the key meta data.
+func (fd *fileDecryptor) cipher(keyMetadata []byte) (*moduleCipher, error) {
+	if c, ok := fd.ciphers[string(keyMetadata)]; ok {
+		return c, nil
+	}
+	if fd.retriever == nil {
+		return nil, errors.New("no key retriever provided")
+	}
+
+	key, err := fd.retriever.GetKey(keyMetadata)
+	if err != nil {
+		return nil, err
+	}
+	c, err := newModuleCipher(key, fd.algorithm)
+	if err != nil {
+		return nil, err
+	}
+	fd.ciphers[string(keyMetadata)] = c
+	return c, nil
+}
+
+// decryptFooter decrypts the file meta data of a file with an encrypted footer.
+func (fd *fileDecryptor) decryptFooter(r io.Reader) (*parquet.FileMetaData, error) {
+	c, err := fd.cipher(fd.footerKeyMetadata)
+	if err != nil {
+		return nil, errors.Wrap(err, "footer key not available")
+	}
+
+	data, err := readModule(r)
+	if err != nil {
+		return nil, err
+	}
+	if data, err = c.decrypt(moduleFooter, moduleAAD(fd.fileAAD, moduleFooter, 0, 0, -1), data); err != nil {
+		return nil, errors.Wrap(err, "decrypting footer failed")
+	}
+
+	meta := &parquet.FileMetaData{}
+	if err := readThrift(meta, bytes.NewReader(data)); err != nil {
+		return nil, err
+	}
+	return meta, nil
+}
+
+// verifyFooter verifies the signature of a plaintext footer, which consists of the serialized file
+// meta data followed by the nonce and the authentication tag. The signature can only be verified
+// if the footer key is available; otherwise the footer is accepted as it is, since only the
+// columns that aren't encrypted with the footer key can be read anyway.
+func (fd *fileDecryptor) verifyFooter(footer []byte) error {
+	c, err := fd.cipher(fd.footerKeyMetadata)
+	if err != nil {
+		return nil
+	}
+
+	if len(footer) < encryptionNonceLength+encryptionTagLength {
+		return errors.New("invalid footer signature")
+	}
+	meta := footer[:len(footer)-encryptionNonceLength-encryptionTagLength]
+	nonce := footer[len(meta) : len(meta)+encryptionNonceLength]
+	tag := footer[len(meta)+encryptionNonceLength:]
+
+	sealed := c.gcm.Seal(nil, nonce, meta, moduleAAD(fd.fileAAD, moduleFooter, 0, 0, -1))
+	if !hmac.Equal(sealed[len(sealed)-encryptionTagLength:], tag) {
+		return errors.New("footer signature verification failed")
+	}
+	return nil
+}
+
+// columnDecryptor returns the decryptor of a column chunk of the row group with the provided
+// ordinal, or nil if the column chunk isn't encrypted. A nil fileDecryptor returns nil for all
+// column chunks. If the key of the column is not available, a *ColumnKeyError is returned.
+func (fd *fileDecryptor) columnDecryptor(rowGroup int, col *Column, chunk *parquet.ColumnChunk) (*columnDecryptor, error) {
+	if fd == nil || chunk.CryptoMetadata == nil {
+		return nil, nil
+	}
+
+	var (
+		c   *moduleCipher
+		err error
+	)
+	switch crypto := chunk.CryptoMetadata; {
+	case crypto.ENCRYPTION_WITH_FOOTER_KEY != nil:
+		c, err = fd.cipher(fd.footerKeyMetadata)
+	case crypto.ENCRYPTION_WITH_COLUMN_KEY != nil:
+		c, err = fd.cipher(crypto.ENCRYPTION_WITH_COLUMN_KEY.KeyMetadata)
+	default:
+		return nil, errors.Errorf("unsupported encryption of column %s", col.FlatName())
+	}
+	if err != nil {
+		return nil, &ColumnKeyError{Column: col.FlatName(), Err: err}
+	}
+
+	return &columnDecryptor{
+		cipher:   c,
+		fileAAD:  fd.fileAAD,
+		rowGroup: rowGroup,
+		column:   col.Index(),
+	}, nil
+}
+
+// decryptColumnMetaData decrypts the encrypted column meta data of all column chunks whose key is
+// available. The column meta data of all other column chunks is left as it is, which means it is
+// either missing or, with a plaintext footer, lacks the statistics.
+func (fd *fileDecryptor) decryptColumnMetaData(meta *parquet.FileMetaData, cols []*Column) error {
+	for i, rowGroup := range meta.RowGroups {
+		if len(rowGroup.Columns) != len(cols) {
+			return errors.Errorf("row group %d has %d column chunks, but the schema has %d columns", i, len(rowGroup.Columns), len(cols))
+		}
+		for _, col := range cols {
+			chunk := rowGroup.Columns[col.Index()]
+			if chunk.EncryptedColumnMetadata == nil {
+				continue
+			}
+
+			dec, err := fd.columnDecryptor(rowGroupOrdinal(rowGroup, i), col, chunk)
+			if _, ok := err.(*ColumnKeyError); ok {
+				continue
+			}
+			if err != nil {
+				return err
+			}
+
+			chunkMeta := &parquet.ColumnMetaData{}
+			if err := dec.readThrift(chunkMeta, bytes.NewReader(chunk.EncryptedColumnMetadata), moduleColumnMetaData, -1); err != nil {
+				return errors.Wrapf(err, "decrypting meta data of column %s failed", col.FlatName())
+			}
+			chunk.MetaData = chunkMeta
+		}
+	}
+
+	return nil
+}
+
+// rowGroupOrdinal returns the ordinal of the i-th row group of a file, which is part of the
+// additional authenticated data of its modules.
+func rowGroupOrdinal(rowGroup *parquet.RowGroup, i int) int {
+	if rowGroup.Ordinal != nil {
+		return int(*rowGroup.Ordinal)
+	}
+	return i
+}
@@ -0,0 +1,419 @@
+package goparquet
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"encoding/binary"
+	"fmt"
+	"testing"
+
+	"github.com/fraugster/parquet-go/parquet"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	testFooterKey = []byte("0123456789abcdef")
+	testColumnKey = []byte("abcdef0123456789abcdef0123456789")
+)
+
+// testAAD returns the additional authenticated data of a module as defined by the parquet
+// modular encryption.
+func testAAD(fileAAD []byte, module byte, ordinals ...int) []byte {
+	aad := append(append([]byte(nil), fileAAD...), module)
+	for _, o := range ordinals {
+		aad = append(aad, byte(o), byte(o>>8))
+	}
+	return aad
+}
+
+// decryptTestModule decrypts the module at the beginning of data and returns the plaintext and
+// the length of the module.
+func decryptTestModule(t *testing.T, key, aad, data []byte, ctr bool) ([]byte, int64) {
+	require.True(t, len(data) >= 4)
+	size := int(binary.LittleEndian.Uint32(data))
+	require.True(t, len(data) >= 4+size)
+	nonce, ciphertext := data[4:4+encryptionNonceLength], data[4+encryptionNonceLength:4+size]
+
+	block, err := aes.NewCipher(key)
+	require.NoError(t, err)
+	if ctr {
+		iv := append(append([]byte(nil), nonce...), 0, 0, 0, 1)
+		plaintext := make([]byte, len(ciphertext))
+		cipher.NewCTR(block, iv).XORKeyStream(plaintext, ciphertext)
+		return plaintext, int64(4 + size)
+	}
+
+	gcm, err := cipher.NewGCM(block)
+	require.NoError(t, err)
+	plaintext, err := gcm.Open(nil, nonce, ciphertext, aad)
+	require.NoError(t, err)
+	return plaintext, int64(4 + size)
+}
+
+// decryptTestPages decrypts all pages of an encrypted column chunk and returns their headers.
+func decryptTestPages(t *testing.T, file, key, fileAAD []byte, ctr bool, rowGroup, column int, meta *parquet.ColumnMetaData) []*parquet.PageHeader {
+	offset := meta.DataPageOffset
+	if meta.DictionaryPageOffset != nil {
+		offset = *meta.DictionaryPageOffset
+	}
+	end := offset + meta.TotalCompressedSize
+
+	var headers []*parquet.PageHeader
+	page := 0
+	for offset < end {
+		headerAAD := testAAD(fileAAD, moduleDataPageHeader, rowGroup, column, page)
+		dataAAD := testAAD(fileAAD, moduleDataPage, rowGroup, column, page)
+		if len(headers) == 0 && meta.DictionaryPageOffset != nil {
+			headerAAD = testAAD(fileAAD, moduleDictionaryPageHeader, rowGroup, column)
+			dataAAD = testAAD(fileAAD, moduleDictionaryPage, rowGroup, column)
+		} else {
+			page++
+		}
+
+		data, n := decryptTestModule(t, key, headerAAD, file[offset:], false)
+		ph := &parquet.PageHeader{}
+		require.NoError(t, readThrift(ph, bytes.NewReader(data)))
+		offset += n
+
+		data, n = decryptTestModule(t, key, dataAAD, file[offset:], ctr)
+		require.Equal(t, int64(ph.CompressedPageSize), n)
+		require.Len(t, data, int(ph.UncompressedPageSize))
+		offset += n
+
+		headers = append(headers, ph)
+	}
+	require.Equal(t, end, offset)
+
+	return headers
+}
+
+func TestWriteEncryptedFooter(t *testing.T) {
+	file := writePredicateTestFile(t, WithFooterKey(testFooterKey, []byte("footer key")),
+		WithMaxPageRowCount(10), WithPageIndex(), WithBloomFilter("bar", 0, 0), WithCRC())
+
+	require.Equal(t, []byte("PARE"), file[:4])
+	require.Equal(t, []byte("PARE"), file[len(file)-4:])
+
+	footerLen := int64(binary.LittleEndian.Uint32(file[len(file)-8:]))
+	footer := bytes.NewReader(file[int64(len(file))-8-footerLen : len(file)-8])
+	crypto := &parquet.FileCryptoMetaData{}
+	require.NoError(t, readThrift(crypto, footer))
+	require.Equal(t, []byte("footer key"), crypto.KeyMetadata)
+	require.NotNil(t, crypto.EncryptionAlgorithm.AES_GCM_V1)
+	require.Nil(t, crypto.EncryptionAlgorithm.AES_GCM_V1.AadPrefix)
+	require.Nil(t, crypto.EncryptionAlgorithm.AES_GCM_V1.SupplyAadPrefix)
+	fileAAD := crypto.EncryptionAlgorithm.AES_GCM_V1.AadFileUnique
+	require.Len(t, fileAAD, aadFileUniqueLength)
+
+	encryptedFooter := file[int64(len(file))-8-int64(footer.Len()) : len(file)-8]
+	data, n := decryptTestModule(t, testFooterKey, testAAD(fileAAD, moduleFooter), encryptedFooter, false)
+	require.Equal(t, int64(len(encryptedFooter)), n)
+	meta := &parquet.FileMetaData{}
+	require.NoError(t, readThrift(meta, bytes.NewReader(data)))
+	require.Equal(t, int64(100), meta.NumRows)
+	require.Nil(t, meta.EncryptionAlgorithm)
+
+	for i, rg := range meta.RowGroups {
+		for j, chunk := range rg.Columns {
+			require.NotNil(t, chunk.CryptoMetadata.ENCRYPTION_WITH_FOOTER_KEY)
+			require.Nil(t, chunk.EncryptedColumnMetadata)
+			require.NotNil(t, chunk.MetaData.Statistics)
+
+			headers := decryptTestPages(t, file, testFooterKey, fileAAD, false, i, j, chunk.MetaData)
+			require.NotEmpty(t, headers)
+			for _, ph := range headers {
+				require.NotNil(t, ph.Crc)
+			}
+
+			data, _ := decryptTestModule(t, testFooterKey, testAAD(fileAAD, moduleColumnIndex, i, j), file[*chunk.ColumnIndexOffset:], false)
+			require.Len(t, data, int(*chunk.ColumnIndexLength)-4-encryptionNonceLength-encryptionTagLength)
+			require.NoError(t, readThrift(&parquet.ColumnIndex{}, bytes.NewReader(data)))
+			data, _ = decryptTestModule(t, testFooterKey, testAAD(fileAAD, moduleOffsetIndex, i, j), file[*chunk.OffsetIndexOffset:], false)
+			require.NoError(t, readThrift(&parquet.OffsetIndex{}, bytes.NewReader(data)))
+		}
+
+		chunk := rg.Columns[1]
+		offset := *chunk.MetaData.BloomFilterOffset
+		data, n := decryptTestModule(t, testFooterKey, testAAD(fileAAD, moduleBloomFilterHeader, i, 1), file[offset:], false)
+		header := &parquet.BloomFilterHeader{}
+		require.NoError(t, readThrift(header, bytes.NewReader(data)))
+		data, _ = decryptTestModule(t, testFooterKey, testAAD(fileAAD, moduleBloomFilterBitset, i, 1), file[offset+n:], false)
+		require.Len(t, data, int(header.NumBytes))
+	}
+}
+
+func TestWritePlaintextFooter(t *testing.T) {
+	file := writePredicateTestFile(t, WithFooterKey(testFooterKey, []byte("footer key")), WithPlaintextFooter(),
+		WithColumnKey("bar", testColumnKey, []byte("column key")), WithMaxPageRowCount(10))
+
+	require.Equal(t, magic, file[:4])
+	require.Equal(t, magic, file[len(file)-4:])
+
+	r, err := NewFileReaderWithOptions(bytes.NewReader(file), WithColumns("foo"))
+	require.NoError(t, err)
+	require.Equal(t, []byte("footer key"), r.meta.FooterSigningKeyMetadata)
+	require.NotNil(t, r.meta.EncryptionAlgorithm.AES_GCM_V1)
+	fileAAD := r.meta.EncryptionAlgorithm.AES_GCM_V1.AadFileUnique
+	require.Len(t, fileAAD, aadFileUniqueLength)
+
+	// the signature consists of the nonce and the tag of the encrypted footer.
+	footerLen := int64(binary.LittleEndian.Uint32(file[len(file)-8:]))
+	footer := file[int64(len(file))-8-footerLen : len(file)-8-encryptionNonceLength-encryptionTagLength]
+	nonce := file[len(file)-8-encryptionNonceLength-encryptionTagLength : len(file)-8-encryptionTagLength]
+	block, err := aes.NewCipher(testFooterKey)
+	require.NoError(t, err)
+	gcm, err := cipher.NewGCM(block)
+	require.NoError(t, err)
+	sealed := gcm.Seal(nil, nonce, footer, testAAD(fileAAD, moduleFooter))
+	require.Equal(t, sealed[len(sealed)-encryptionTagLength:], file[len(file)-8-encryptionTagLength:len(file)-8])
+
+	for i, rg := range r.meta.RowGroups {
+		require.Nil(t, rg.Columns[0].CryptoMetadata)
+		require.Nil(t, rg.Columns[2].CryptoMetadata)
+		require.NotNil(t, rg.Columns[0].MetaData.Statistics)
+
+		chunk := rg.Columns[1]
+		require.Equal(t, &parquet.ColumnCryptoMetaData{
+			ENCRYPTION_WITH_COLUMN_KEY: &parquet.EncryptionWithColumnKey{
+				PathInSchema: []string{"bar"},
+				KeyMetadata:  []byte("column key"),
+			},
+		}, chunk.CryptoMetadata)
+		require.Nil(t, chunk.MetaData.Statistics)
+
+		data, _ := decryptTestModule(t, testColumnKey, testAAD(fileAAD, moduleColumnMetaData, i, 1), chunk.EncryptedColumnMetadata, false)
+		meta := &parquet.ColumnMetaData{}
+		require.NoError(t, readThrift(meta, bytes.NewReader(data)))
+		require.NotNil(t, meta.Statistics)
+		meta.Statistics, meta.EncodingStats = nil, nil
+		require.Equal(t, chunk.MetaData, meta)
+
+		headers := decryptTestPages(t, file, testColumnKey, fileAAD, false, i, 1, meta)
+		require.Len(t, headers, 6)
+		require.Equal(t, parquet.PageType_DICTIONARY_PAGE, headers[0].Type)
+	}
+
+	rows := readAllRows(t, r)
+	require.Len(t, rows, 100)
+	for i := range rows {
+		require.Equal(t, map[string]interface{}{"foo": int64(i)}, rows[i])
+	}
+}
+
+func TestWriteEncryptedWithAESGCMCTR(t *testing.T) {
+	file := writePredicateTestFile(t, WithFooterKey(testFooterKey, nil), WithEncryptionAlgorithm(AESGCMCTRV1),
+		WithAADPrefix([]byte("table"), false), WithDataPageV2(), WithMaxPageRowCount(10), WithEncodingConcurrency(2))
+
+	footerLen := int64(binary.LittleEndian.Uint32(file[len(file)-8:]))
+	footer := bytes.NewReader(file[int64(len(file))-8-footerLen : len(file)-8])
+	crypto := &parquet.FileCryptoMetaData{}
+	require.NoError(t, readThrift(crypto, footer))
+	algorithm := crypto.EncryptionAlgorithm.AES_GCM_CTR_V1
+	require.NotNil(t, algorithm)
+	require.Nil(t, algorithm.AadPrefix)
+	require.True(t, *algorithm.SupplyAadPrefix)
+	fileAAD := append([]byte("table"), algorithm.AadFileUnique...)
+
+	data, _ := decryptTestModule(t, testFooterKey, testAAD(fileAAD, moduleFooter), file[int64(len(file))-8-int64(footer.Len()):], false)
+	meta := &parquet.FileMetaData{}
+	require.NoError(t, readThrift(meta, bytes.NewReader(data)))
+
+	for i, rg := range meta.RowGroups {
+		for j, chunk := range rg.Columns {
+			headers := decryptTestPages(t, file, testFooterKey, fileAAD, true, i, j, chunk.MetaData)
+			require.Equal(t, parquet.PageType_DATA_PAGE_V2, headers[len(headers)-1].Type)
+		}
+	}
+}
+
+func TestWriteEncryptedInvalidOptions(t *testing.T) {
+	tests := map[string][]FileWriterOption{
+		"no footer key":      {WithColumnKey("bar", testColumnKey, nil)},
+		"invalid footer key": {WithFooterKey([]byte("short"), nil)},
+		"invalid column key": {WithFooterKey(testFooterKey, nil), WithColumnKey("bar", []byte("short"), nil)},
+		"unknown column":     {WithFooterKey(testFooterKey, nil), WithColumnKey("unknown", testColumnKey, nil)},
+	}
+
+	for name, options := range tests {
+		t.Run(name, func(t *testing.T) {
+			w := NewFileWriter(&bytes.Buffer{}, append(options, WithSchemaDefinition(predicateTestSchema(t)))...)
+			require.NoError(t, w.AddData(predicateTestRow(1)))
+			require.Error(t, w.Close())
+		})
+	}
+}
+
+// testKeyRetriever returns the test keys for their key meta data.
+func testKeyRetriever(keys map[string][]byte) KeyRetriever {
+	return KeyRetrieverFunc(func(keyMetadata []byte) ([]byte, error) {
+		key, ok := keys[string(keyMetadata)]
+		if !ok {
+			return nil, fmt.Errorf("unknown key %q", keyMetadata)
+		}
+		return key, nil
+	})
+}
+
+func TestReadEncrypted(t *testing.T) {
+	keys := testKeyRetriever(map[string][]byte{"footer key": testFooterKey, "column key": testColumnKey})
+
+	tests := []struct {
+		name    string
+		write   []FileWriterOption
+		read    []FileReaderOption
+		columns []string
+	}{
+		{
+			name:  "encrypted footer",
+			write: []FileWriterOption{WithFooterKey(testFooterKey, []byte("footer key")), WithCRC()},
+			read:  []FileReaderOption{WithCRC32Validation()},
+		},
+		{
+			name:  "column key",
+			write: []FileWriterOption{WithFooterKey(testFooterKey, []byte("footer key")), WithColumnKey("bar", testColumnKey, []byte("column key"))},
+		},
+		{
+			name:  "plaintext footer",
+			write: []FileWriterOption{WithFooterKey(testFooterKey, []byte("footer key")), WithPlaintextFooter(), WithColumnKey("bar", testColumnKey, []byte("column key"))},
+			read:  []FileReaderOption{WithStreaming()},
+		},
+		{
+			name:  "plaintext footer with footer key",
+			write: []FileWriterOption{WithFooterKey(testFooterKey, []byte("footer key")), WithPlaintextFooter()},
+		},
+		{
+			name: "aes gcm ctr",
+			write: []FileWriterOption{WithFooterKey(testFooterKey, []byte("footer key")), WithEncryptionAlgorithm(AESGCMCTRV1),
+				WithColumnKey("baz", testColumnKey, []byte("column key")), WithDataPageV2(), WithAADPrefix([]byte("table"), true)},
+			read: []FileReaderOption{WithConcurrency(2)},
+		},
+		{
+			name:  "supplied aad prefix",
+			write: []FileWriterOption{WithFooterKey(testFooterKey, []byte("footer key")), WithAADPrefix([]byte("table"), false)},
+			read:  []FileReaderOption{WithDecryptionAADPrefix([]byte("table")), WithStreaming()},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			file := writePredicateTestFile(t, append(tt.write, WithMaxPageRowCount(10), WithPageIndex(), WithBloomFilter("bar", 0, 0))...)
+
+			r, err := NewFileReaderWithOptions(bytes.NewReader(file), append(tt.read, WithKeyRetriever(keys))...)
+			require.NoError(t, err)
+			require.Equal(t, int64(100), r.NumRows())
+			rows := readAllRows(t, r)
+			require.Len(t, rows, 100)
+			for i, row := range rows {
+				require.Equal(t, predicateTestRow(i), row)
+			}
+
+			for _, rg := range r.meta.RowGroups {
+				for _, chunk := range rg.Columns {
+					require.NotNil(t, chunk.MetaData.Statistics)
+				}
+			}
+
+			// the predicate uses the decrypted statistics, page indexes and Bloom filters.
+			r, err = NewFileReaderWithOptions(bytes.NewReader(file), append(tt.read, WithKeyRetriever(keys),
+				WithPredicate(And(ColumnPredicate("foo", GreaterThanOrEqual, 72), ColumnPredicate("bar", Equal, "value 3"))))...)
+			require.NoError(t, err)
+			rows = readAllRows(t, r)
+			require.Len(t, rows, 30)
+			require.Equal(t, predicateTestRow(70), rows[0])
+
+			contains, err := r.MightContain("bar", "unknown value")
+			require.NoError(t, err)
+			require.False(t, contains)
+
+			r, err = NewFileReaderWithOptions(bytes.NewReader(file), append(tt.read, WithKeyRetriever(keys),
+				WithPredicate(ColumnPredicate("bar", Equal, "unknown value")))...)
+			require.NoError(t, err)
+			require.Empty(t, readAllRows(t, r))
+		})
+	}
+}
+
+func TestReadEncryptedWithoutColumnKey(t *testing.T) {
+	for name, plaintextFooter := range map[string]bool{"encrypted footer": false, "plaintext footer": true} {
+		t.Run(name, func(t *testing.T) {
+			options := []FileWriterOption{WithFooterKey(testFooterKey, []byte("footer key")), WithColumnKey("bar", testColumnKey, []byte("column key")),
+				WithMaxPageRowCount(10), WithPageIndex(), WithBloomFilter("bar", 0, 0)}
+			if plaintextFooter {
+				options = append(options, WithPlaintextFooter())
+			}
+			file := writePredicateTestFile(t, options...)
+			keys := testKeyRetriever(map[string][]byte{"footer key": testFooterKey})
+
+			r, err := NewFileReaderWithOptions(bytes.NewReader(file), WithKeyRetriever(keys))
+			require.NoError(t, err)
+			_, err = r.NextRow()
+			keyErr, ok := err.(*ColumnKeyError)
+			require.True(t, ok, "unexpected error %v", err)
+			require.Equal(t, "bar", keyErr.Column)
+			require.EqualError(t, keyErr, `column bar: key of encrypted column not available: unknown key "column key"`)
+
+			// all other columns can still be read, even with a predicate on the encrypted column.
+			r, err = NewFileReaderWithOptions(bytes.NewReader(file), WithKeyRetriever(keys), WithColumns("foo", "baz"),
+				WithPredicate(And(ColumnPredicate("foo", LessThan, 15), ColumnPredicate("bar", Equal, "value 3"))))
+			require.NoError(t, err)
+			rows := readAllRows(t, r)
+			require.Len(t, rows, 20)
+			for i, row := range rows {
+				expected := predicateTestRow(i)
+				delete(expected, "bar")
+				require.Equal(t, expected, row)
+			}
+
+			if !plaintextFooter {
+				_, err = r.ColumnMetaData("bar")
+				require.IsType(t, &ColumnKeyError{}, err)
+			}
+			_, err = r.MightContain("bar", "value 3")
+			require.IsType(t, &ColumnKeyError{}, err)
+		})
+	}
+}
+
+func TestReadEncryptedInvalidKeys(t *testing.T) {
+	wrongKey := []byte("fedcba9876543210")
+
+	tests := []struct {
+		name  string
+		write []FileWriterOption
+		read  []FileReaderOption
+	}{
+		{
+			name:  "no key retriever",
+			write: []FileWriterOption{WithFooterKey(testFooterKey, nil)},
+		},
+		{
+			name:  "wrong footer key",
+			write: []FileWriterOption{WithFooterKey(testFooterKey, nil)},
+			read:  []FileReaderOption{WithKeyRetriever(testKeyRetriever(map[string][]byte{"": wrongKey}))},
+		},
+		{
+			name:  "wrong footer signing key",
+			write: []FileWriterOption{WithFooterKey(testFooterKey, nil), WithPlaintextFooter()},
+			read:  []FileReaderOption{WithKeyRetriever(testKeyRetriever(map[string][]byte{"": wrongKey}))},
+		},
+		{
+			name:  "missing aad prefix",
+			write: []FileWriterOption{WithFooterKey(testFooterKey, nil), WithAADPrefix([]byte("table"), false)},
+			read:  []FileReaderOption{WithKeyRetriever(testKeyRetriever(map[string][]byte{"": testFooterKey}))},
+		},
+		{
+			name:  "wrong aad prefix",
+			write: []FileWriterOption{WithFooterKey(testFooterKey, nil), WithAADPrefix([]byte("table"), false)},
+			read:  []FileReaderOption{WithKeyRetriever(testKeyRetriever(map[string][]byte{"": testFooterKey})), WithDecryptionAADPrefix([]byte("other"))},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			file := writePredicateTestFile(t, tt.write...)
+			_, err := NewFileReaderWithOptions(bytes.NewReader(file), tt.read...)
+			require.Error(t, err)
+		})
+	}
+}
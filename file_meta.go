@@ -11,52 +11,78 @@ import (
 
 var magic = []byte{'P', 'A', 'R', '1'}
 
-func readFileMetaData(r io.ReadSeeker) (*parquet.FileMetaData, error) {
-	if _, err := r.Seek(0, io.SeekStart); err != nil {
-		return nil, errors.Wrap(err, "seek for the file magic header failed")
+// readFileMetaData reads the file meta data. If the file is encrypted, the decryptor of the file
+// is returned as well, which uses the key retriever to retrieve the keys of the file.
+func readFileMetaData(r io.ReaderAt, size int64, retriever KeyRetriever, aadPrefix []byte) (*parquet.FileMetaData, *fileDecryptor, error) {
+	if size < 12 {
+		return nil, nil, errors.Errorf("invalid parquet file size %d", size)
 	}
 
-	buf := make([]byte, 4)
+	header := make([]byte, 4)
 	// read and validate header
-	if _, err := io.ReadFull(r, buf); err != nil {
-		return nil, errors.Wrap(err, "read the file magic header failed")
+	if _, err := r.ReadAt(header, 0); err != nil {
+		return nil, nil, errors.Wrap(err, "read the file magic header failed")
 	}
-	if !bytes.Equal(buf, magic) {
-		return nil, errors.Errorf("invalid parquet file header")
+	if !bytes.Equal(header, magic) && !bytes.Equal(header, magicEncrypted) {
+		return nil, nil, errors.Errorf("invalid parquet file header")
 	}
 
+	buf := make([]byte, 4)
 	// read and validate footer
-	if _, err := r.Seek(-4, io.SeekEnd); err != nil {
-		return nil, errors.Wrap(err, "seek for the file magic footer failed")
-	}
-
-	if _, err := io.ReadFull(r, buf); err != nil {
-		return nil, errors.Wrap(err, "read the file magic header failed")
+	if _, err := r.ReadAt(buf, size-4); err != nil {
+		return nil, nil, errors.Wrap(err, "read the file magic footer failed")
 	}
-	if !bytes.Equal(buf, magic) {
-		return nil, errors.Errorf("invalid parquet file footer")
+	if !bytes.Equal(buf, header) {
+		return nil, nil, errors.Errorf("invalid parquet file footer")
 	}
 
 	// read footer length
-	if _, err := r.Seek(-8, io.SeekEnd); err != nil {
-		return nil, errors.Wrap(err, "seek for the footer len failed")
+	if _, err := r.ReadAt(buf, size-8); err != nil {
+		return nil, nil, errors.Wrap(err, "read the footer len failed")
 	}
-	var fl int32
-	if err := binary.Read(r, binary.LittleEndian, &fl); err != nil {
-		return nil, errors.Wrap(err, "read the footer len failed")
+	fl := int64(int32(binary.LittleEndian.Uint32(buf)))
+	if fl <= 0 || fl > size-12 {
+		return nil, nil, errors.Errorf("invalid footer len %d", fl)
 	}
-	if fl <= 0 {
-		return nil, errors.Errorf("invalid footer len %d", fl)
+	footer := io.NewSectionReader(r, size-8-fl, fl)
+
+	if bytes.Equal(header, magicEncrypted) {
+		crypto := &parquet.FileCryptoMetaData{}
+		if err := readThrift(crypto, footer); err != nil {
+			return nil, nil, errors.Wrap(err, "read file crypto meta failed")
+		}
+		fd, err := newFileDecryptor(crypto.EncryptionAlgorithm, crypto.KeyMetadata, retriever, aadPrefix)
+		if err != nil {
+			return nil, nil, err
+		}
+		meta, err := fd.decryptFooter(footer)
+		if err != nil {
+			return nil, nil, errors.Wrap(err, "read file meta failed")
+		}
+		return meta, fd, nil
 	}
 
 	// read file metadata
-	if _, err := r.Seek(-8-int64(fl), io.SeekEnd); err != nil {
-		return nil, errors.Wrap(err, "seek file meta data failed")
-	}
 	meta := &parquet.FileMetaData{}
-	if err := readThrift(meta, io.LimitReader(r, int64(fl))); err != nil {
-		return nil, errors.Wrap(err, "read file meta failed")
+	if err := readThrift(meta, footer); err != nil {
+		return nil, nil, errors.Wrap(err, "read file meta failed")
+	}
+	if meta.EncryptionAlgorithm == nil {
+		return meta, nil, nil
+	}
+
+	// the file has encrypted columns, but a plaintext footer that is signed with the footer key.
+	fd, err := newFileDecryptor(meta.EncryptionAlgorithm, meta.FooterSigningKeyMetadata, retriever, aadPrefix)
+	if err != nil {
+		return nil, nil, err
+	}
+	data := make([]byte, fl)
+	if _, err := footer.ReadAt(data, 0); err != nil {
+		return nil, nil, errors.Wrap(err, "read the footer failed")
+	}
+	if err := fd.verifyFooter(data); err != nil {
+		return nil, nil, err
 	}
 
-	return meta, nil
+	return meta, fd, nil
 }
@@ -3,29 +3,107 @@ package goparquet
 import (
 	"fmt"
 	"io"
+	"math"
 	"strings"
 
 	"github.com/fraugster/parquet-go/parquet"
+	"github.com/fraugster/parquet-go/parquetschema"
 	"github.com/pkg/errors"
 )
 
-// FileReader is used to read data from a parquet file. Always use NewFileReader to create
-// such an object.
+// FileReader is used to read data from a parquet file. Always use NewFileReader or a related
+// function to create such an object.
 type FileReader struct {
 	meta *parquet.FileMetaData
 	SchemaReader
-	reader io.ReadSeeker
+	reader io.ReaderAt
+	size   int64
 
 	rowGroupPosition int
 	currentRecord    int64
 	skipRowGroup     bool
+
+	columns     []string
+	predicate   Predicate
+	streaming   bool
+	concurrency int
+	validateCRC bool
+
+	keyRetriever KeyRetriever
+	aadPrefix    []byte
+	decryptor    *fileDecryptor
+
+	opener FileOpener
+	// the files that contain column chunks stored outside of the file, by their path.
+	files map[string]io.ReaderAt
+
+	targetSchema *parquetschema.SchemaDefinition
+	fieldIDs     bool
+	columnIDs    []int32
+	projection   *schemaProjection
 }
 
+// FileOpener opens the files that contain the column chunks of a parquet file that are stored
+// outside of the file itself, like the column chunks that are referenced by a _metadata summary
+// file.
+type FileOpener interface {
+	// Open opens the file with the provided path, which is stored in the column chunk and is
+	// relative to the parquet file that references it. If the returned reader implements
+	// io.Closer, it is closed when the FileReader is closed.
+	Open(path string) (io.ReaderAt, error)
+}
+
+// FileOpenerFunc is a function that implements the FileOpener interface.
+type FileOpenerFunc func(path string) (io.ReaderAt, error)
+
+// Open calls f(path).
+func (f FileOpenerFunc) Open(path string) (io.ReaderAt, error) {
+	return f(path)
+}
+
+// FileReaderOption is an option that can be passed on to NewFileReaderWithOptions when
+// creating a new parquet file reader.
+type FileReaderOption func(fr *FileReader)
+
 // NewFileReader creates a new FileReader. You can limit the columns that are read by providing
 // the names of the specific columns to read using dotted notation. If no columns are provided,
 // then all columns are read.
 func NewFileReader(r io.ReadSeeker, columns ...string) (*FileReader, error) {
-	meta, err := readFileMetaData(r)
+	return NewFileReaderWithOptions(r, WithColumns(columns...))
+}
+
+// NewFileReaderWithOptions creates a new FileReader. You can provide a list of options to
+// configure the reader. If r also implements io.ReaderAt, like *os.File does, the data is
+// read using offset reads. Otherwise, all reads are serialized over the seek cursor of r.
+func NewFileReaderWithOptions(r io.ReadSeeker, options ...FileReaderOption) (*FileReader, error) {
+	size, err := r.Seek(0, io.SeekEnd)
+	if err != nil {
+		return nil, errors.Wrap(err, "determining file size failed")
+	}
+
+	ra, ok := r.(io.ReaderAt)
+	if !ok {
+		ra = newReadSeekerAt(r)
+	}
+
+	return NewFileReaderAt(ra, size, options...)
+}
+
+// NewFileReaderAt creates a new FileReader that reads the parquet file of the provided size from r
+// using offset reads only. Since such reads don't modify any shared state, r can be shared between
+// multiple goroutines or readers, which makes it a good fit for random-access sources like files
+// or blobs in an object storage. You can provide a list of options to configure the reader.
+func NewFileReaderAt(r io.ReaderAt, size int64, options ...FileReaderOption) (*FileReader, error) {
+	fr := &FileReader{
+		reader: r,
+		size:   size,
+	}
+
+	for _, opt := range options {
+		opt(fr)
+	}
+
+	meta, decryptor, err := readFileMetaData(r, size, fr.keyRetriever, fr.aadPrefix)
 	if err != nil {
 		return nil, errors.Wrap(err, "reading file meta data failed")
 	}
@@ -35,30 +113,488 @@ func NewFileReader(r io.ReadSeeker, columns ...string) (*FileReader, error) {
 		return nil, errors.Wrap(err, "creating schema failed")
 	}
 
+	if decryptor != nil {
+		if err := decryptor.decryptColumnMetaData(meta, schema.Columns()); err != nil {
+			return nil, errors.Wrap(err, "reading column meta data failed")
+		}
+	}
+
+	columns := append([]string{}, fr.columns...)
+	if fr.targetSchema != nil {
+		projection, err := newSchemaProjection(fr.targetSchema, schema.GetSchemaDefinition(), fr.fieldIDs)
+		if err != nil {
+			return nil, errors.Wrap(err, "incompatible target schema")
+		}
+		fr.projection = projection
+	}
+	if len(fr.columnIDs) > 0 {
+		sd := schema.GetSchemaDefinition()
+		if fr.projection != nil {
+			sd = fr.projection.schemaDef
+		}
+		for _, id := range fr.columnIDs {
+			path := fieldIDPath(sd.RootColumn, id)
+			if path == "" {
+				return nil, errors.Errorf("column with field ID %d not found", id)
+			}
+			columns = append(columns, path)
+		}
+	}
+	if fr.projection != nil {
+		columns = fr.projection.selectedColumns(schema, columns)
+	}
 	schema.setSelectedColumns(columns...)
-	// Reset the reader to the beginning of the file
-	if _, err := r.Seek(4, io.SeekStart); err != nil {
-		return nil, err
+
+	if fr.predicate != nil {
+		for _, col := range fr.predicate.columns() {
+			found := schema.GetColumnByName(col) != nil
+			if fr.projection != nil {
+				found = fr.projection.column(col) != nil
+			}
+			if !found {
+				return nil, errors.Errorf("predicate column %q not found", col)
+			}
+		}
+	}
+
+	fr.meta = meta
+	fr.SchemaReader = schema
+	fr.decryptor = decryptor
+	return fr, nil
+}
+
+// WithColumns limits the columns which are read. The names of the columns need to be
+// provided in dotted notation. If no columns are provided, then all columns are read. If a
+// target schema is set with WithTargetSchemaDefinition, the names refer to its columns.
+func WithColumns(columns ...string) FileReaderOption {
+	return func(fr *FileReader) {
+		fr.columns = columns
+	}
+}
+
+// WithPredicate sets a predicate that is used to skip data while reading. The reader uses
+// the column chunk statistics to skip row groups that can't contain any rows matching the
+// predicate. If the file contains column indexes and offset indexes, it also only reads the
+// pages that might contain matching rows, and skips all other pages of all selected columns.
+// Rows that don't match the predicate may still be returned, so the predicate needs to be
+// checked on every returned row if exact results are required.
+func WithPredicate(p Predicate) FileReaderOption {
+	return func(fr *FileReader) {
+		fr.predicate = p
+	}
+}
+
+// WithStreaming enables the streaming mode of the reader. Instead of loading all selected column
+// chunks of a row group into memory at once, the pages of every column are read and decoded one at
+// a time while the rows are consumed, so that at most one page per column is kept in memory. This
+// bounds the memory usage for large row groups, at the cost of more seeks in the underlying reader.
+func WithStreaming() FileReaderOption {
+	return func(fr *FileReader) {
+		fr.streaming = true
+	}
+}
+
+// WithConcurrency sets the number of column chunks that are read and decoded in parallel
+// when a row group is loaded. If the underlying reader implements io.ReaderAt, like *os.File
+// does, the column chunks are also fetched in parallel. Otherwise, they are read one after
+// another, and only decoded in parallel. The option has no effect in streaming mode.
+func WithConcurrency(n int) FileReaderOption {
+	return func(fr *FileReader) {
+		fr.concurrency = n
+	}
+}
+
+// WithCRC32Validation enables the verification of the CRC32 checksums of all pages that are read.
+// If the checksum of a page doesn't match its content, a *PageChecksumError is returned that
+// identifies the corrupted page. Pages without a checksum are not verified.
+func WithCRC32Validation() FileReaderOption {
+	return func(fr *FileReader) {
+		fr.validateCRC = true
+	}
+}
+
+// WithKeyRetriever sets the KeyRetriever that is used to retrieve the keys of encrypted files.
+// The key meta data that the writer stored in the file is passed to it for the footer key and
+// every column key. If the key of an encrypted column is not available, all other columns can
+// still be read, but reading the column itself returns a *ColumnKeyError.
+func WithKeyRetriever(kr KeyRetriever) FileReaderOption {
+	return func(fr *FileReader) {
+		fr.keyRetriever = kr
+	}
+}
+
+// WithDecryptionAADPrefix sets the AAD prefix of an encrypted file. It is only required for files
+// that were written with an AAD prefix that isn't stored in the file.
+func WithDecryptionAADPrefix(prefix []byte) FileReaderOption {
+	return func(fr *FileReader) {
+		fr.aadPrefix = prefix
+	}
+}
+
+// WithTargetSchemaDefinition sets the schema definition that the rows are returned in, which allows
+// reading files that were written with an older or newer version of the schema. The columns are matched
+// by name, or by field ID if WithFieldIDResolution is used. Columns of the file that are missing in the
+// target schema are not read, and optional and repeated columns of the target schema that are missing in
+// the file are returned as null. INT32 columns are promoted to INT64 or DOUBLE columns, and FLOAT columns
+// to DOUBLE columns. All other differences, like a required column of the target schema that is optional
+// or missing in the file, result in an error when the FileReader is created. The names passed to
+// WithColumns and used in predicates refer to the columns of the target schema. A predicate on a column
+// that is missing in the file is evaluated like a column that only contains nulls, and a predicate on a
+// promoted column can't skip any data.
+func WithTargetSchemaDefinition(sd *parquetschema.SchemaDefinition) FileReaderOption {
+	return func(fr *FileReader) {
+		fr.targetSchema = sd
+	}
+}
+
+// WithFieldIDResolution matches the columns of the target schema set with WithTargetSchemaDefinition
+// with the columns of the file by their field IDs instead of their names, so that renamed columns are
+// still resolved correctly. Columns of the target schema without a field ID are matched by name.
+func WithFieldIDResolution() FileReaderOption {
+	return func(fr *FileReader) {
+		fr.fieldIDs = true
+	}
+}
+
+// WithColumnsByFieldID limits the columns which are read to the columns with the provided field IDs,
+// in addition to the columns selected with WithColumns. If a target schema is set with
+// WithTargetSchemaDefinition, the field IDs refer to its columns.
+func WithColumnsByFieldID(ids ...int32) FileReaderOption {
+	return func(fr *FileReader) {
+		fr.columnIDs = ids
+	}
+}
+
+// WithFileOpener sets the FileOpener that is used to open the files that contain column chunks
+// which are stored outside of the file, like the column chunks that are referenced by a _metadata
+// summary file. Without a FileOpener, reading such column chunks returns an error. The files are
+// opened when they are first needed, and closed by the Close method of the FileReader.
+func WithFileOpener(opener FileOpener) FileReaderOption {
+	return func(fr *FileReader) {
+		fr.opener = opener
 	}
-	return &FileReader{
-		meta:         meta,
-		SchemaReader: schema,
-		reader:       r,
-	}, nil
 }
 
 // readRowGroup read the next row group into memory
 func (f *FileReader) readRowGroup() error {
-	if len(f.meta.RowGroups) <= f.rowGroupPosition {
-		return io.EOF
+	for {
+		if len(f.meta.RowGroups) <= f.rowGroupPosition {
+			return io.EOF
+		}
+		f.rowGroupPosition++
+		rowGroup := f.meta.RowGroups[f.rowGroupPosition-1]
+
+		sel, err := f.selectRows(f.rowGroupPosition - 1)
+		if err != nil {
+			return err
+		}
+		if sel != nil && len(sel.ranges) == 0 {
+			// no row within this row group can match the predicate.
+			continue
+		}
+
+		var crc *pageVerifier
+		if f.validateCRC {
+			crc = &pageVerifier{rowGroup: f.rowGroupPosition - 1}
+		}
+
+		decs, err := f.columnDecryptors(f.rowGroupPosition - 1)
+		if err != nil {
+			return err
+		}
+
+		files, err := f.chunkFiles(rowGroup)
+		if err != nil {
+			return err
+		}
+
+		if f.streaming {
+			return streamRowGroup(files, f.SchemaReader, rowGroup, sel, crc, decs)
+		}
+
+		return readRowGroup(files, f.SchemaReader, rowGroup, sel, f.concurrency, crc, decs)
+	}
+}
+
+// chunkFile returns the file that contains the column chunk. Column chunks that are stored outside
+// of the file are opened using the FileOpener.
+func (f *FileReader) chunkFile(chunk *parquet.ColumnChunk) (io.ReaderAt, error) {
+	if chunk.FilePath == nil || *chunk.FilePath == "" {
+		return f.reader, nil
+	}
+
+	path := *chunk.FilePath
+	if r, ok := f.files[path]; ok {
+		return r, nil
 	}
-	f.rowGroupPosition++
-	return readRowGroup(f.reader, f.SchemaReader, f.meta.RowGroups[f.rowGroupPosition-1])
+	if f.opener == nil {
+		return nil, errors.Errorf("column chunk is stored in file %q, but no FileOpener was provided", path)
+	}
+
+	r, err := f.opener.Open(path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "opening file %q failed", path)
+	}
+	if f.files == nil {
+		f.files = make(map[string]io.ReaderAt)
+	}
+	f.files[path] = r
+	return r, nil
+}
+
+// chunkFiles returns the files that contain the selected column chunks of the row group.
+func (f *FileReader) chunkFiles(rowGroup *parquet.RowGroup) ([]io.ReaderAt, error) {
+	files := make([]io.ReaderAt, len(rowGroup.Columns))
+	for _, col := range f.SchemaReader.Columns() {
+		if !f.SchemaReader.isSelected(col.flatName) {
+			continue
+		}
+		r, err := f.chunkFile(rowGroup.Columns[col.Index()])
+		if err != nil {
+			return nil, err
+		}
+		files[col.Index()] = r
+	}
+
+	return files, nil
+}
+
+// Close closes all files that were opened by the FileOpener. It doesn't close the reader that the
+// FileReader was created with.
+func (f *FileReader) Close() error {
+	var firstErr error
+	for path, r := range f.files {
+		if c, ok := r.(io.Closer); ok {
+			if err := c.Close(); err != nil && firstErr == nil {
+				firstErr = errors.Wrapf(err, "closing file %q failed", path)
+			}
+		}
+	}
+	f.files = nil
+
+	return firstErr
+}
+
+// columnDecryptor returns the decryptor of the column chunk of the column in the i-th row group,
+// or nil if the column chunk isn't encrypted.
+func (f *FileReader) columnDecryptor(i int, col *Column) (*columnDecryptor, error) {
+	rowGroup := f.meta.RowGroups[i]
+	return f.decryptor.columnDecryptor(rowGroupOrdinal(rowGroup, i), col, rowGroup.Columns[col.Index()])
+}
+
+// columnDecryptors returns the decryptors of the selected column chunks of the i-th row group, or
+// nil if the file isn't encrypted.
+func (f *FileReader) columnDecryptors(i int) ([]*columnDecryptor, error) {
+	if f.decryptor == nil {
+		return nil, nil
+	}
+
+	cols := f.SchemaReader.Columns()
+	decs := make([]*columnDecryptor, len(cols))
+	for _, col := range cols {
+		if !f.SchemaReader.isSelected(col.flatName) {
+			continue
+		}
+		dec, err := f.columnDecryptor(i, col)
+		if err != nil {
+			return nil, err
+		}
+		decs[col.Index()] = dec
+	}
+
+	return decs, nil
+}
+
+// selectRows evaluates the predicate against the statistics and page indexes of the i-th row group
+// and returns the rows that need to be read. It returns nil if all rows of the row group need to be
+// read. The statistics, page indexes and Bloom filters of encrypted columns whose key is not
+// available are ignored.
+func (f *FileReader) selectRows(i int) (*rowGroupSelection, error) {
+	rowGroup := f.meta.RowGroups[i]
+	if f.predicate == nil || rowGroup.NumRows == 0 {
+		return nil, nil
+	}
+
+	// The column chunk statistics are already part of the file meta data, so we check them
+	// first to avoid loading the page indexes of row groups that can be skipped entirely.
+	bloomFilters := make(map[int]*bloomFilter)
+	stats := &rowGroupStats{
+		schema:     f.SchemaReader,
+		rowGroup:   rowGroup,
+		projection: f.projection,
+		bloomFilter: func(col *Column) (*bloomFilter, error) {
+			bf, ok := bloomFilters[col.Index()]
+			if !ok {
+				var err error
+				if bf, err = f.readBloomFilter(i, col); err != nil {
+					if _, ok := err.(*ColumnKeyError); ok {
+						return nil, nil
+					}
+					return nil, err
+				}
+				bloomFilters[col.Index()] = bf
+			}
+			return bf, nil
+		},
+	}
+
+	ranges, _, err := f.predicate.evaluate(stats)
+	if err != nil {
+		return nil, err
+	}
+	if len(ranges) == 0 {
+		return &rowGroupSelection{}, nil
+	}
+
+	if !hasPageIndexes(rowGroup) {
+		return nil, nil
+	}
+
+	stats.columnIndexes = make([]*parquet.ColumnIndex, len(rowGroup.Columns))
+	stats.offsetIndexes = make([]*parquet.OffsetIndex, len(rowGroup.Columns))
+
+	for _, col := range f.SchemaReader.Columns() {
+		c, chunk := col.Index(), rowGroup.Columns[col.Index()]
+		dec, err := f.columnDecryptor(i, col)
+		if _, ok := err.(*ColumnKeyError); ok {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if chunk.ColumnIndexOffset == nil && chunk.OffsetIndexOffset == nil {
+			continue
+		}
+		r, err := f.chunkFile(chunk)
+		if err != nil {
+			return nil, err
+		}
+
+		if chunk.ColumnIndexOffset != nil && chunk.ColumnIndexLength != nil {
+			ci := &parquet.ColumnIndex{}
+			if err := readIndex(r, ci, *chunk.ColumnIndexOffset, *chunk.ColumnIndexLength, dec, moduleColumnIndex); err != nil {
+				return nil, errors.Wrap(err, "reading column index failed")
+			}
+			stats.columnIndexes[c] = ci
+		}
+		if chunk.OffsetIndexOffset != nil && chunk.OffsetIndexLength != nil {
+			oi := &parquet.OffsetIndex{}
+			if err := readIndex(r, oi, *chunk.OffsetIndexOffset, *chunk.OffsetIndexLength, dec, moduleOffsetIndex); err != nil {
+				return nil, errors.Wrap(err, "reading offset index failed")
+			}
+			stats.offsetIndexes[c] = oi
+		}
+	}
+
+	ranges, _, err = f.predicate.evaluate(stats)
+	if err != nil {
+		return nil, err
+	}
+
+	return &rowGroupSelection{
+		ranges:        ranges,
+		offsetIndexes: stats.offsetIndexes,
+	}, nil
+}
+
+func hasPageIndexes(rowGroup *parquet.RowGroup) bool {
+	for _, chunk := range rowGroup.Columns {
+		if chunk.ColumnIndexOffset != nil || chunk.OffsetIndexOffset != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// readIndex reads a column index or an offset index from r, which is decrypted as the module if dec is not nil.
+func readIndex(r io.ReaderAt, idx thriftReader, offset int64, length int32, dec *columnDecryptor, module byte) error {
+	return dec.readThrift(idx, io.NewSectionReader(r, offset, int64(length)), module, -1)
+}
+
+// readBloomFilter reads the Bloom filter of the column chunk of the column in the i-th row group. It returns nil
+// if the column chunk has no Bloom filter.
+func (f *FileReader) readBloomFilter(i int, col *Column) (*bloomFilter, error) {
+	dec, err := f.columnDecryptor(i, col)
+	if err != nil {
+		return nil, err
+	}
+
+	chunk := f.meta.RowGroups[i].Columns[col.Index()]
+	if chunk.MetaData == nil || chunk.MetaData.BloomFilterOffset == nil {
+		return nil, nil
+	}
+
+	r, err := f.chunkFile(chunk)
+	if err != nil {
+		return nil, err
+	}
+	// the size of files that contain column chunks stored outside of the file is not known. The
+	// limit must not make offset+size overflow, which io.SectionReader doesn't handle before Go 1.20.
+	offset := *chunk.MetaData.BloomFilterOffset
+	if offset < 0 {
+		return nil, errors.Errorf("invalid bloom filter offset %d", offset)
+	}
+	size := math.MaxInt64 - offset
+	if r == f.reader {
+		size = f.size - offset
+	}
+
+	bf, err := readBloomFilter(io.NewSectionReader(r, offset, size), dec)
+	if err != nil {
+		return nil, errors.Wrap(err, "reading bloom filter failed")
+	}
+	return bf, nil
+}
+
+// MightContain uses the Bloom filter of the provided column in the current row group to check
+// whether the row group might contain the provided value, see RowGroupMightContain. The current
+// row group is the one that was loaded by PreLoad or NextRow, so an error is returned if no row
+// group was loaded yet. Together with SkipRowGroup, it can be used to skip row groups that don't
+// contain a value.
+func (f *FileReader) MightContain(colName string, value interface{}) (bool, error) {
+	if f.CurrentRowGroup() == nil {
+		return false, errors.New("no row group loaded, call PreLoad first")
+	}
+
+	return f.RowGroupMightContain(f.rowGroupPosition-1, colName, value)
+}
+
+// RowGroupMightContain uses the Bloom filter of the provided column in the i-th row group to check
+// whether the row group might contain the provided value. Only the Bloom filter is read, not the
+// row group itself. The column name has to be provided in its dotted notation, and the value has
+// to be of a type that can be used in a ColumnPredicate for the column. If it returns false, the
+// row group definitely doesn't contain the value. If the column chunk has no Bloom filter,
+// RowGroupMightContain always returns true.
+func (f *FileReader) RowGroupMightContain(i int, colName string, value interface{}) (bool, error) {
+	if i < 0 || i >= len(f.meta.RowGroups) {
+		return false, errors.Errorf("row group %d out of range", i)
+	}
+
+	col := f.SchemaReader.GetColumnByName(colName)
+	if col == nil {
+		return false, errors.Errorf("column %q not found", colName)
+	}
+
+	v, err := convertPredicateValue(col.Element(), value)
+	if err != nil {
+		return false, err
+	}
+
+	bf, err := f.readBloomFilter(i, col)
+	if err != nil {
+		return false, err
+	}
+	if bf == nil {
+		return true, nil
+	}
+
+	return bf.check(bloomFilterHash(v)), nil
 }
 
 // CurrentRowGroup returns information about the current row group.
 func (f *FileReader) CurrentRowGroup() *parquet.RowGroup {
-	if f == nil || f.meta == nil || f.meta.RowGroups == nil || f.rowGroupPosition-1 >= len(f.meta.RowGroups) {
+	if f == nil || f.meta == nil || f.meta.RowGroups == nil || f.rowGroupPosition == 0 || f.rowGroupPosition-1 >= len(f.meta.RowGroups) {
 		return nil
 	}
 	return f.meta.RowGroups[f.rowGroupPosition-1]
@@ -104,7 +640,20 @@ func (f *FileReader) NextRow() (map[string]interface{}, error) {
 	}
 
 	f.currentRecord++
-	return f.SchemaReader.getData()
+	row, err := f.SchemaReader.getData()
+	if err != nil || f.projection == nil {
+		return row, err
+	}
+	return f.projection.project(row), nil
+}
+
+// GetSchemaDefinition returns the schema definition of the rows returned by the FileReader, which is
+// the target schema definition if one was set with WithTargetSchemaDefinition.
+func (f *FileReader) GetSchemaDefinition() *parquetschema.SchemaDefinition {
+	if f.projection != nil {
+		return f.projection.schemaDef
+	}
+	return f.SchemaReader.GetSchemaDefinition()
 }
 
 // SkipRowGroup skips the currently loaded row group and advances to the next row group.
@@ -117,6 +666,11 @@ func (f *FileReader) PreLoad() error {
 	return f.advanceIfNeeded()
 }
 
+// FileMetaData returns the file meta data of the parquet file.
+func (f *FileReader) FileMetaData() *parquet.FileMetaData {
+	return f.meta
+}
+
 // MetaData returns a map of metadata key-value pairs stored in the parquet file.
 func (f *FileReader) MetaData() map[string]string {
 	return keyValueMetaDataToMap(f.meta.KeyValueMetadata)
@@ -126,10 +680,16 @@ func (f *FileReader) MetaData() map[string]string {
 // row group. The column name has to be provided in its dotted notation.
 func (f *FileReader) ColumnMetaData(colName string) (map[string]string, error) {
 	for _, col := range f.CurrentRowGroup().Columns {
-		if colName == strings.Join(col.MetaData.PathInSchema, ".") {
+		if col.MetaData != nil && colName == strings.Join(col.MetaData.PathInSchema, ".") {
 			return keyValueMetaDataToMap(col.MetaData.KeyValueMetadata), nil
 		}
 	}
+	// the column meta data of encrypted columns is missing if their key is not available.
+	if col := f.SchemaReader.GetColumnByName(colName); col != nil {
+		if _, err := f.columnDecryptor(f.rowGroupPosition-1, col); err != nil {
+			return nil, err
+		}
+	}
 	return nil, fmt.Errorf("column %q not found", colName)
 }
 
@@ -3,12 +3,17 @@ package goparquet
 import (
 	"encoding/binary"
 	"errors"
+	"fmt"
 	"io"
 
 	"github.com/fraugster/parquet-go/parquet"
 	"github.com/fraugster/parquet-go/parquetschema"
 )
 
+// DefaultMaxDictionarySize is the maximum size in bytes of the dictionary of a column chunk
+// that is used if no other size is set with WithMaxDictionarySize.
+const DefaultMaxDictionarySize = 1024 * 1024
+
 // FileWriter is used to write data to a parquet file. Always use NewFileWriter
 // to create such an object.
 type FileWriter struct {
@@ -23,19 +28,67 @@ type FileWriter struct {
 
 	rowGroupFlushSize int64
 
+	maxPageSize     int64
+	maxPageRowCount int64
+
 	rowGroups []*parquet.RowGroup
+	// the number of row groups at the start of rowGroups that were read from an existing file,
+	// see NewAppendFileWriter.
+	existingRowGroups int
+	// the column orders of the existing file, which also apply to the statistics of its row groups.
+	existingColumnOrders []*parquet.ColumnOrder
+	// the minimum size of the file, if the old footer of an existing file couldn't be truncated.
+	minSize int64
+
+	writePageIndex bool
+	pageIndexes    [][]*pageIndex
+
+	bloomFilters map[string]bloomFilterOptions
+
+	maxDictSize int64
+
+	maxStatsLength int
+
+	schemaDef *parquetschema.SchemaDefinition
+	columns   map[string]*columnOptions
 
 	codec parquet.CompressionCodec
 
+	withCRC bool
+
+	encryption *encryptionOptions
+	encryptor  *fileEncryptor
+
+	concurrency int
+
 	newPage newDataPageFunc
+
+	// the file meta data that was written by Close.
+	meta *parquet.FileMetaData
+
+	// err is the error of setting the schema definition when the FileWriter was created with
+	// NewFileWriter. It is returned by AddData, FlushRowGroup and Close.
+	err error
 }
 
 // FileWriterOption describes an option function that is applied to a FileWriter when it is created.
 type FileWriterOption func(fw *FileWriter)
 
 // NewFileWriter creates a new FileWriter. You can provide FileWriterOptions to influence the
-// file writer's behaviour.
+// file writer's behaviour. If the schema definition set with WithSchemaDefinition is invalid,
+// or a column option refers to a column that is not part of it, the error is returned by the
+// first call of AddData, FlushRowGroup or Close. Use NewFileWriterWithError to get the error
+// right away.
 func NewFileWriter(w io.Writer, options ...FileWriterOption) *FileWriter {
+	fw, err := NewFileWriterWithError(w, options...)
+	fw.err = err
+	return fw
+}
+
+// NewFileWriterWithError creates a new FileWriter just like NewFileWriter, but returns an error if
+// the schema definition set with WithSchemaDefinition is invalid, or a column option refers to a
+// column that is not part of it. The FileWriter is returned even if there is an error.
+func NewFileWriterWithError(w io.Writer, options ...FileWriterOption) (*FileWriter, error) {
 	fw := &FileWriter{
 		w: &writePosStruct{
 			w:   w,
@@ -46,6 +99,7 @@ func NewFileWriter(w io.Writer, options ...FileWriterOption) *FileWriter {
 		kvStore:      make(map[string]string),
 		rowGroups:    []*parquet.RowGroup{},
 		createdBy:    "parquet-go",
+		maxDictSize:  DefaultMaxDictionarySize,
 		newPage:      newDataPageV1Writer,
 	}
 
@@ -53,7 +107,36 @@ func NewFileWriter(w io.Writer, options ...FileWriterOption) *FileWriter {
 		opt(fw)
 	}
 
-	return fw
+	// The schema definition is only set once all options have been applied, so that the
+	// column options are applied to its columns regardless of the order of the options.
+	if fw.schemaDef != nil {
+		if err := fw.SetSchemaDefinition(fw.schemaDef); err != nil {
+			return fw, err
+		}
+	}
+
+	return fw, nil
+}
+
+// columnOptions contains the settings of a single column that override the defaults of the
+// file writer. A nil field means that the default is used.
+type columnOptions struct {
+	encoding    *parquet.Encoding
+	dictionary  *bool
+	codec       *parquet.CompressionCodec
+	maxDictSize *int64
+}
+
+func (fw *FileWriter) columnOptions(column string) *columnOptions {
+	if fw.columns == nil {
+		fw.columns = make(map[string]*columnOptions)
+	}
+	opts, ok := fw.columns[column]
+	if !ok {
+		opts = &columnOptions{}
+		fw.columns[column] = opts
+	}
+	return opts
 }
 
 // FileVersion sets the version of the file itself.
@@ -98,12 +181,31 @@ func WithMaxRowGroupSize(size int64) FileWriterOption {
 	}
 }
 
+// WithMaxPageSize sets the rough maximum size of the values in a single data page. Once
+// a page reaches this size, a new page is started within the same column chunk. The
+// size is measured before encoding and compression, and pages are always split at row
+// boundaries, so the actual page size may differ. By default, all values of a column
+// chunk are written to a single data page.
+func WithMaxPageSize(size int64) FileWriterOption {
+	return func(fw *FileWriter) {
+		fw.maxPageSize = size
+	}
+}
+
+// WithMaxPageRowCount sets the maximum number of rows in a single data page. Once a page
+// contains this many rows, a new page is started within the same column chunk. It can be
+// combined with WithMaxPageSize, in which case a new page is started as soon as one of
+// the two limits is reached.
+func WithMaxPageRowCount(count int64) FileWriterOption {
+	return func(fw *FileWriter) {
+		fw.maxPageRowCount = count
+	}
+}
+
 // WithSchemaDefinition sets the schema definition to use for this parquet file.
 func WithSchemaDefinition(sd *parquetschema.SchemaDefinition) FileWriterOption {
 	return func(fw *FileWriter) {
-		if err := fw.SetSchemaDefinition(sd); err != nil {
-			panic(err)
-		}
+		fw.schemaDef = sd
 	}
 }
 
@@ -116,6 +218,183 @@ func WithDataPageV2() FileWriterOption {
 	}
 }
 
+// WithPageIndex enables writing the column index and the offset index (together called the
+// page index) for every column chunk. The page index contains the minimum and maximum value,
+// the number of null values and the location of every data page, and is written after the
+// last row group. Query engines can use it to skip data pages without reading them. It is
+// most useful in combination with WithMaxPageSize or WithMaxPageRowCount.
+func WithPageIndex() FileWriterOption {
+	return func(fw *FileWriter) {
+		fw.writePageIndex = true
+	}
+}
+
+// WithBloomFilter enables writing a split block Bloom filter for every column chunk of the
+// column that is identified by its full dotted-notation name. The column needs to be part of the
+// schema definition, otherwise setting the schema definition fails. ndv is the expected number of
+// distinct values within a row group; if it is 0, the actual number of distinct values of
+// each column chunk is used. fpp is the desired false positive probability; if it is 0,
+// DefaultBloomFilterFPP is used. Bloom filters allow readers to quickly determine whether a
+// row group may contain a particular value, which is especially useful for columns with a
+// high cardinality like IDs.
+func WithBloomFilter(column string, ndv int64, fpp float64) FileWriterOption {
+	return func(fw *FileWriter) {
+		if fw.bloomFilters == nil {
+			fw.bloomFilters = make(map[string]bloomFilterOptions)
+		}
+		fw.bloomFilters[column] = bloomFilterOptions{ndv: ndv, fpp: fpp}
+	}
+}
+
+// WithMaxDictionarySize sets the maximum size in bytes of the dictionary of a column chunk. The
+// data pages of a column chunk are dictionary encoded until the dictionary would exceed this
+// size, and all remaining data pages of the column chunk are written using the column's own
+// encoding instead. If size is 0 or less, the size of the dictionary is not limited. The
+// default is DefaultMaxDictionarySize.
+func WithMaxDictionarySize(size int64) FileWriterOption {
+	return func(fw *FileWriter) {
+		fw.maxDictSize = size
+	}
+}
+
+// WithColumnMaxDictionarySize sets the maximum size in bytes of the dictionary for the column
+// that is identified by its full dotted-notation name. It overrides the size set by
+// WithMaxDictionarySize for this column.
+func WithColumnMaxDictionarySize(column string, size int64) FileWriterOption {
+	return func(fw *FileWriter) {
+		fw.columnOptions(column).maxDictSize = &size
+	}
+}
+
+// WithColumnEncoding sets the encoding of the column that is identified by its full
+// dotted-notation name. If the column uses a dictionary, the encoding is used for the data
+// pages that are not dictionary encoded. The option only applies to columns created from a
+// schema definition, and the encoding needs to be supported by the column's type. By
+// default, columns use the PLAIN encoding.
+func WithColumnEncoding(column string, enc parquet.Encoding) FileWriterOption {
+	return func(fw *FileWriter) {
+		fw.columnOptions(column).encoding = &enc
+	}
+}
+
+// WithColumnDictionary sets whether the column that is identified by its full dotted-notation
+// name may use dictionary encoding. The option only applies to columns created from a schema
+// definition. By default, a dictionary is used if the column store's heuristics consider it
+// worthwhile. Boolean columns never use a dictionary.
+func WithColumnDictionary(column string, enabled bool) FileWriterOption {
+	return func(fw *FileWriter) {
+		fw.columnOptions(column).dictionary = &enabled
+	}
+}
+
+// WithColumnCompression sets the compression codec of the column that is identified by its
+// full dotted-notation name. It overrides the codec set by WithCompressionCodec for this
+// column.
+func WithColumnCompression(column string, codec parquet.CompressionCodec) FileWriterOption {
+	return func(fw *FileWriter) {
+		fw.columnOptions(column).codec = &codec
+	}
+}
+
+// WithMaxStatisticsLength sets the maximum length in bytes of the min and max values of byte
+// array columns in statistics and column indexes. Longer min values are truncated, and longer
+// max values are truncated and incremented, so that they remain a lower and an upper bound of
+// the values. This limits the size of the file metadata for columns with large values, e.g.
+// JSON documents. Values of DECIMAL columns and max values that can't be incremented are not
+// truncated. If length is 0 or less, which is the default, values are not truncated.
+func WithMaxStatisticsLength(length int) FileWriterOption {
+	return func(fw *FileWriter) {
+		fw.maxStatsLength = length
+	}
+}
+
+// WithCRC enables writing the CRC32 checksum of every page into its page header. The checksum
+// is computed over the page data as it is written to the file, i.e. after compression. Readers
+// can use it to detect corrupted pages, see WithCRC32Validation.
+func WithCRC() FileWriterOption {
+	return func(fw *FileWriter) {
+		fw.withCRC = true
+	}
+}
+
+// WithFooterKey enables the parquet modular encryption and sets the key that is used to encrypt
+// the footer, or to sign it if the footer is written in plaintext (see WithPlaintextFooter). The
+// key needs to be 16, 24 or 32 bytes long to use AES-128, AES-192 or AES-256. keyMetadata is
+// stored in the file in plaintext and can be used by readers to retrieve the key, e.g. a key ID.
+// If no column keys are set with WithColumnKey, all columns are encrypted with the footer key.
+// Otherwise, only the columns with a column key are encrypted, and all other columns are stored
+// in plaintext.
+func WithFooterKey(key, keyMetadata []byte) FileWriterOption {
+	return func(fw *FileWriter) {
+		opts := fw.encryptionOptions()
+		opts.footerKey = key
+		opts.footerKeyMetadata = keyMetadata
+	}
+}
+
+// WithColumnKey encrypts the column that is identified by its full dotted-notation name with its
+// own key, so that only readers that have the key can read the column. The key needs to be 16,
+// 24 or 32 bytes long, and keyMetadata is stored in the file in plaintext to help readers
+// retrieve the key. Encrypting columns requires a footer key, see WithFooterKey.
+func WithColumnKey(column string, key, keyMetadata []byte) FileWriterOption {
+	return func(fw *FileWriter) {
+		opts := fw.encryptionOptions()
+		if opts.columnKeys == nil {
+			opts.columnKeys = make(map[string]columnKey)
+		}
+		opts.columnKeys[column] = columnKey{key: key, keyMetadata: keyMetadata}
+	}
+}
+
+// WithPlaintextFooter writes the footer of an encrypted file in plaintext, signed with the
+// footer key. This allows readers without any keys to read the schema, the meta data and all
+// columns that aren't encrypted, while the integrity of the footer can still be verified by
+// readers that have the footer key. The statistics of encrypted columns are only stored
+// encrypted.
+func WithPlaintextFooter() FileWriterOption {
+	return func(fw *FileWriter) {
+		fw.encryptionOptions().plaintextFooter = true
+	}
+}
+
+// WithEncryptionAlgorithm sets the algorithm that is used to encrypt the file. The default is
+// AESGCMV1.
+func WithEncryptionAlgorithm(algorithm EncryptionAlgorithm) FileWriterOption {
+	return func(fw *FileWriter) {
+		fw.encryptionOptions().algorithm = algorithm
+	}
+}
+
+// WithAADPrefix sets a prefix of the additional authenticated data of all encrypted modules,
+// e.g. the name of the table that the file belongs to, which protects the file from being
+// replaced by another file encrypted with the same keys. If store is false, the prefix isn't
+// stored in the file and readers need to supply it.
+func WithAADPrefix(prefix []byte, store bool) FileWriterOption {
+	return func(fw *FileWriter) {
+		opts := fw.encryptionOptions()
+		opts.aadPrefix = prefix
+		opts.storeAADPrefix = store
+	}
+}
+
+func (fw *FileWriter) encryptionOptions() *encryptionOptions {
+	if fw.encryption == nil {
+		fw.encryption = &encryptionOptions{}
+	}
+	return fw.encryption
+}
+
+// WithEncodingConcurrency sets the number of column chunks that are encoded and compressed in
+// parallel when a row group is flushed. The column chunks are encoded into memory buffers, and
+// then written to the file in the order of the columns, so up to n encoded column chunks are
+// kept in memory at the same time. This speeds up writing files with many columns, especially
+// when using an expensive compression codec like GZIP.
+func WithEncodingConcurrency(n int) FileWriterOption {
+	return func(fw *FileWriter) {
+		fw.concurrency = n
+	}
+}
+
 type flushRowGroupOptionHandle struct {
 	cols   map[string]map[string]string
 	global map[string]string
@@ -176,13 +455,26 @@ func WithRowGroupMetaData(kv map[string]string) FlushRowGroupOption {
 
 // FlushRowGroup writes the current row group to the parquet file.
 func (fw *FileWriter) FlushRowGroup(opts ...FlushRowGroupOption) error {
+	if fw.err != nil {
+		return fw.err
+	}
+
 	// Write the entire row group
 	if fw.rowGroupNumRecords() == 0 {
 		return errors.New("nothing to write")
 	}
 
 	if fw.w.Pos() == 0 {
-		if err := writeFull(fw.w, magic); err != nil {
+		fileMagic := magic
+		if fw.encryption != nil {
+			fe, err := newFileEncryptor(fw.encryption, fw.SchemaWriter)
+			if err != nil {
+				return err
+			}
+			fw.encryptor = fe
+			fileMagic = fe.magic()
+		}
+		if err := writeFull(fw.w, fileMagic); err != nil {
 			return err
 		}
 	}
@@ -193,10 +485,29 @@ func (fw *FileWriter) FlushRowGroup(opts ...FlushRowGroupOption) error {
 		o(h)
 	}
 
-	cc, err := writeRowGroup(fw.w, fw.SchemaWriter, fw.codec, fw.newPage, h)
+	var encs []*columnEncryptor
+	if fw.encryptor != nil {
+		var err error
+		if encs, err = fw.encryptor.columnEncryptors(len(fw.rowGroups), fw.SchemaWriter.Columns()); err != nil {
+			return err
+		}
+	}
+
+	cc, indexes, err := fw.writeRowGroup(h, encs)
 	if err != nil {
 		return err
 	}
+	if err := fw.writeBloomFilters(cc, encs); err != nil {
+		return err
+	}
+	if fw.encryptor != nil {
+		if err := fw.encryptor.encryptColumnMetaData(cc, encs); err != nil {
+			return err
+		}
+	}
+	if fw.writePageIndex {
+		fw.pageIndexes = append(fw.pageIndexes, indexes)
+	}
 
 	fw.rowGroups = append(fw.rowGroups, &parquet.RowGroup{
 		Columns:        cc,
@@ -214,6 +525,10 @@ func (fw *FileWriter) FlushRowGroup(opts ...FlushRowGroupOption) error {
 // AddData adds a new record to the current row group and flushes it if auto-flush is enabled and the size
 // is equal to or greater than the configured maximum row group size.
 func (fw *FileWriter) AddData(m map[string]interface{}) error {
+	if fw.err != nil {
+		return fw.err
+	}
+
 	if err := fw.SchemaWriter.AddData(m); err != nil {
 		return err
 	}
@@ -231,6 +546,10 @@ func (fw *FileWriter) AddData(m map[string]interface{}) error {
 // provided a file as io.Writer when creating the FileWriter, you still need
 // to Close that file handle separately.
 func (fw *FileWriter) Close(opts ...FlushRowGroupOption) error {
+	if fw.err != nil {
+		return fw.err
+	}
+
 	if len(fw.rowGroups) == 0 || fw.rowGroupNumRecords() > 0 {
 		if err := fw.FlushRowGroup(opts...); err != nil {
 			return err
@@ -249,6 +568,23 @@ func (fw *FileWriter) Close(opts ...FlushRowGroupOption) error {
 			Value: addr,
 		})
 	}
+	if fw.writePageIndex {
+		if err := writePageIndexes(fw.w, fw.rowGroups[fw.existingRowGroups:], fw.pageIndexes); err != nil {
+			return err
+		}
+	}
+
+	// all statistics are written using the type defined order of their columns. The statistics of
+	// the row groups of an existing file may not be, so its column orders are kept, even if it had none.
+	columns := fw.Columns()
+	columnOrders := make([]*parquet.ColumnOrder, len(columns))
+	for i := range columns {
+		columnOrders[i] = &parquet.ColumnOrder{TYPE_ORDER: &parquet.TypeDefinedOrder{}}
+	}
+	if fw.existingRowGroups > 0 {
+		columnOrders = fw.existingColumnOrders
+	}
+
 	meta := &parquet.FileMetaData{
 		Version:          fw.version,
 		Schema:           fw.getSchemaArray(),
@@ -256,11 +592,23 @@ func (fw *FileWriter) Close(opts ...FlushRowGroupOption) error {
 		RowGroups:        fw.rowGroups,
 		KeyValueMetadata: kv,
 		CreatedBy:        &fw.createdBy,
-		ColumnOrders:     nil,
+		ColumnOrders:     columnOrders,
+	}
+
+	if fw.minSize > 0 {
+		if err := fw.padFooter(meta); err != nil {
+			return err
+		}
 	}
 
 	pos := fw.w.Pos()
-	if err := writeThrift(meta, fw.w); err != nil {
+	fileMagic := magic
+	if fw.encryptor != nil {
+		if err := fw.encryptor.writeFooter(fw.w, meta); err != nil {
+			return err
+		}
+		fileMagic = fw.encryptor.magic()
+	} else if err := writeThrift(meta, fw.w); err != nil {
 		return err
 	}
 
@@ -269,7 +617,19 @@ func (fw *FileWriter) Close(opts ...FlushRowGroupOption) error {
 		return err
 	}
 
-	return writeFull(fw.w, magic)
+	if err := writeFull(fw.w, fileMagic); err != nil {
+		return err
+	}
+
+	fw.meta = meta
+	return nil
+}
+
+// FileMetaData returns the file meta data that was written when the FileWriter was closed, or nil
+// if it wasn't closed yet. It can be used to write the summary files of a dataset, see
+// MergeFileMetaData.
+func (fw *FileWriter) FileMetaData() *parquet.FileMetaData {
+	return fw.meta
 }
 
 // CurrentRowGroupSize returns a rough estimation of the uncompressed size of the current row group data. If you selected
@@ -285,3 +645,67 @@ func (fw *FileWriter) CurrentRowGroupSize() int64 {
 func (fw *FileWriter) CurrentFileSize() int64 {
 	return fw.w.Pos()
 }
+
+// SetSchemaDefinition sets the schema definition to use for this parquet file. The column
+// options of the file writer, like WithColumnEncoding, are applied to the columns of the
+// schema definition. An error is returned if a column option refers to a column that is not
+// part of the schema definition, and so is a Bloom filter enabled with WithBloomFilter.
+func (fw *FileWriter) SetSchemaDefinition(sd *parquetschema.SchemaDefinition) error {
+	if err := fw.SchemaWriter.SetSchemaDefinition(sd); err != nil {
+		return err
+	}
+
+	for name := range fw.bloomFilters {
+		if fw.SchemaWriter.GetColumnByName(name) == nil {
+			return fmt.Errorf("bloom filter column %q not found in schema definition", name)
+		}
+	}
+
+	for name, opts := range fw.columns {
+		var col *Column
+		for _, c := range fw.Columns() {
+			if c.FlatName() == name {
+				col = c
+				break
+			}
+		}
+		if col == nil {
+			return fmt.Errorf("column %q from the column options not found in schema definition", name)
+		}
+		if opts.encoding == nil && opts.dictionary == nil {
+			continue
+		}
+
+		enc, allowDict := col.data.encoding(), col.data.allowDict
+		if opts.encoding != nil {
+			enc = *opts.encoding
+		}
+		if opts.dictionary != nil {
+			allowDict = *opts.dictionary
+		}
+		store, err := newColumnStore(col.Element(), col.params, enc, allowDict)
+		if err != nil {
+			return fmt.Errorf("column %q: %w", name, err)
+		}
+		store.reset(col.rep, col.maxR, col.maxD)
+		col.data = store
+	}
+
+	return nil
+}
+
+// maxDictionarySize returns the maximum dictionary size of the column.
+func (fw *FileWriter) maxDictionarySize(column string) int64 {
+	if opts, ok := fw.columns[column]; ok && opts.maxDictSize != nil {
+		return *opts.maxDictSize
+	}
+	return fw.maxDictSize
+}
+
+// compressionCodec returns the compression codec of the column.
+func (fw *FileWriter) compressionCodec(column string) parquet.CompressionCodec {
+	if opts, ok := fw.columns[column]; ok && opts.codec != nil {
+		return *opts.codec
+	}
+	return fw.codec
+}
@@ -0,0 +1,130 @@
+package goparquet
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"reflect"
+
+	"github.com/fraugster/parquet-go/parquet"
+	"github.com/pkg/errors"
+)
+
+// truncater is implemented by files that can be truncated, like *os.File.
+type truncater interface {
+	Truncate(size int64) error
+}
+
+// NewAppendFileWriter creates a new FileWriter that appends row groups to the existing parquet file
+// rws. The footer of the file is read and the writer continues at its position, so that the row
+// groups that are flushed with FlushRowGroup are written after the existing row groups. Close
+// writes a new footer that contains both the existing and the new row groups.
+//
+// If no schema definition is set with WithSchemaDefinition, the schema of the existing file is used,
+// otherwise it needs to be the same. The key-value meta data of the existing file is kept, unless
+// it is overwritten by the key-value meta data set with WithMetaData. Encrypted files are not
+// supported.
+//
+// The created_by field of the existing file is kept, and WithCreator has no effect. There is only
+// one created_by field for all row groups, and readers like parquet-mr use it to decide whether
+// they can trust the statistics of the row groups. Replacing it would make them trust statistics
+// that were written by an older, possibly buggy writer. For the same reason, the column orders of
+// the existing file are kept, and none are written if it had none.
+//
+// If rws has a Truncate method, like *os.File does, the old footer is truncated right away.
+// Otherwise it is overwritten, and the new footer is padded if the file would become shorter.
+// Until Close was called successfully, the file isn't a valid parquet file.
+func NewAppendFileWriter(rws io.ReadWriteSeeker, options ...FileWriterOption) (*FileWriter, error) {
+	size, err := rws.Seek(0, io.SeekEnd)
+	if err != nil {
+		return nil, errors.Wrap(err, "seek to the end of the file failed")
+	}
+	ra, ok := rws.(io.ReaderAt)
+	if !ok {
+		ra = newReadSeekerAt(rws)
+	}
+
+	meta, decryptor, err := readFileMetaData(ra, size, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	if decryptor != nil || meta.EncryptionAlgorithm != nil {
+		return nil, errors.New("appending to encrypted files is not supported")
+	}
+
+	// readFileMetaData already validated the footer length.
+	buf := make([]byte, 4)
+	if _, err := ra.ReadAt(buf, size-8); err != nil {
+		return nil, errors.Wrap(err, "read the footer len failed")
+	}
+	footerStart := size - 8 - int64(int32(binary.LittleEndian.Uint32(buf)))
+
+	fw, err := NewFileWriterWithError(rws, options...)
+	if err != nil {
+		return nil, err
+	}
+	if fw.encryption != nil {
+		return nil, errors.New("appending to encrypted files is not supported")
+	}
+
+	fileSchema, err := makeSchema(meta)
+	if err != nil {
+		return nil, err
+	}
+	if fw.schemaDef == nil {
+		if err := fw.SetSchemaDefinition(fileSchema.GetSchemaDefinition()); err != nil {
+			return nil, err
+		}
+	} else if !reflect.DeepEqual(fw.getSchemaArray()[1:], meta.Schema[1:]) {
+		return nil, errors.New("the schema definition differs from the schema of the existing file")
+	}
+
+	fw.rowGroups = append(fw.rowGroups, meta.RowGroups...)
+	fw.existingRowGroups = len(meta.RowGroups)
+	fw.existingColumnOrders = meta.ColumnOrders
+	fw.totalNumRecords = meta.NumRows
+	for _, kv := range meta.KeyValueMetadata {
+		if _, ok := fw.kvStore[kv.Key]; !ok {
+			fw.kvStore[kv.Key] = kv.GetValue()
+		}
+	}
+	if meta.Version > fw.version {
+		fw.version = meta.Version
+	}
+	if meta.CreatedBy != nil {
+		fw.createdBy = *meta.CreatedBy
+	}
+
+	if t, ok := rws.(truncater); ok {
+		if err := t.Truncate(footerStart); err != nil {
+			return nil, errors.Wrap(err, "truncate the footer failed")
+		}
+	} else {
+		fw.minSize = size
+	}
+	if _, err := rws.Seek(footerStart, io.SeekStart); err != nil {
+		return nil, errors.Wrap(err, "seek to the footer failed")
+	}
+	fw.w = &writePosStruct{
+		w:   rws,
+		pos: footerStart,
+	}
+
+	return fw, nil
+}
+
+// padFooter writes zeros before the footer meta, so that the file isn't shorter than minSize.
+// Otherwise, the remains of the old footer of a file that couldn't be truncated would follow the
+// new footer.
+func (fw *FileWriter) padFooter(meta *parquet.FileMetaData) error {
+	footer := &bytes.Buffer{}
+	if err := writeThrift(meta, footer); err != nil {
+		return err
+	}
+
+	padding := fw.minSize - fw.w.Pos() - int64(footer.Len()) - 8
+	if padding <= 0 {
+		return nil
+	}
+	return writeFull(fw.w, make([]byte, padding))
+}
@@ -0,0 +1,203 @@
+package goparquet
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/fraugster/parquet-go/parquet"
+	"github.com/fraugster/parquet-go/parquetschema"
+	"github.com/stretchr/testify/require"
+)
+
+// appendTestFile is an in-memory file that can't be truncated.
+type appendTestFile struct {
+	data []byte
+	pos  int64
+}
+
+func (f *appendTestFile) Read(p []byte) (int, error) {
+	if f.pos >= int64(len(f.data)) {
+		return 0, io.EOF
+	}
+	n := copy(p, f.data[f.pos:])
+	f.pos += int64(n)
+	return n, nil
+}
+
+func (f *appendTestFile) Write(p []byte) (int, error) {
+	if end := f.pos + int64(len(p)); end > int64(len(f.data)) {
+		f.data = append(f.data, make([]byte, end-int64(len(f.data)))...)
+	}
+	n := copy(f.data[f.pos:], p)
+	f.pos += int64(n)
+	return n, nil
+}
+
+func (f *appendTestFile) Seek(offset int64, whence int) (int64, error) {
+	switch whence {
+	case io.SeekCurrent:
+		offset += f.pos
+	case io.SeekEnd:
+		offset += int64(len(f.data))
+	}
+	f.pos = offset
+	return offset, nil
+}
+
+func appendTestSchema(t *testing.T) *parquetschema.SchemaDefinition {
+	sd, err := parquetschema.ParseSchemaDefinition(`message test {
+		required int64 id;
+		optional binary name (STRING);
+	}`)
+	require.NoError(t, err)
+	return sd
+}
+
+func writeAppendTestFile(t *testing.T, w io.Writer, options ...FileWriterOption) {
+	fw := NewFileWriter(w, append([]FileWriterOption{WithSchemaDefinition(appendTestSchema(t))}, options...)...)
+	require.NoError(t, fw.AddData(map[string]interface{}{"id": int64(1), "name": []byte("a")}))
+	require.NoError(t, fw.AddData(map[string]interface{}{"id": int64(2)}))
+	require.NoError(t, fw.Close())
+}
+
+func TestAppendFileWriter(t *testing.T) {
+	buf := &bytes.Buffer{}
+	writeAppendTestFile(t, buf, WithMetaData(map[string]string{"a": "1", "b": "2"}), WithCreator("test"))
+	file := &appendTestFile{data: buf.Bytes()}
+
+	fw, err := NewAppendFileWriter(file, WithMetaData(map[string]string{"b": "3"}), WithCreator("other"))
+	require.NoError(t, err)
+	require.Equal(t, appendTestSchema(t).String(), fw.GetSchemaDefinition().String())
+	require.NoError(t, fw.AddData(map[string]interface{}{"id": int64(3), "name": []byte("c")}))
+	require.NoError(t, fw.FlushRowGroup())
+	require.NoError(t, fw.AddData(map[string]interface{}{"id": int64(4)}))
+	require.NoError(t, fw.Close())
+
+	r, err := NewFileReader(bytes.NewReader(file.data))
+	require.NoError(t, err)
+	require.Equal(t, 3, r.RowGroupCount())
+	require.Equal(t, int64(4), r.NumRows())
+	require.Equal(t, map[string]string{"a": "1", "b": "3"}, r.MetaData())
+	require.Equal(t, "test", r.FileMetaData().GetCreatedBy())
+	require.Len(t, r.FileMetaData().ColumnOrders, 2)
+	require.Equal(t, []map[string]interface{}{
+		{"id": int64(1), "name": []byte("a")},
+		{"id": int64(2)},
+		{"id": int64(3), "name": []byte("c")},
+		{"id": int64(4)},
+	}, readAllRows(t, r))
+}
+
+func TestAppendFileWriterColumnOrders(t *testing.T) {
+	buf := &bytes.Buffer{}
+	writeAppendTestFile(t, buf)
+
+	// older writers didn't write column orders.
+	r, err := NewFileReader(bytes.NewReader(buf.Bytes()))
+	require.NoError(t, err)
+	meta := r.FileMetaData()
+	require.Len(t, meta.ColumnOrders, 2)
+	meta.ColumnOrders = nil
+	footerLen := binary.LittleEndian.Uint32(buf.Bytes()[buf.Len()-8:])
+	data := &bytes.Buffer{}
+	data.Write(buf.Bytes()[:buf.Len()-8-int(footerLen)])
+	footer := &bytes.Buffer{}
+	require.NoError(t, writeThrift(meta, footer))
+	data.Write(footer.Bytes())
+	require.NoError(t, binary.Write(data, binary.LittleEndian, int32(footer.Len())))
+	data.Write(magic)
+
+	file := &appendTestFile{data: data.Bytes()}
+	fw, err := NewAppendFileWriter(file)
+	require.NoError(t, err)
+	require.NoError(t, fw.AddData(map[string]interface{}{"id": int64(3)}))
+	require.NoError(t, fw.Close())
+
+	r, err = NewFileReader(bytes.NewReader(file.data))
+	require.NoError(t, err)
+	require.Nil(t, r.FileMetaData().ColumnOrders)
+	require.Len(t, readAllRows(t, r), 3)
+}
+
+func TestAppendFileWriterTruncate(t *testing.T) {
+	f, err := ioutil.TempFile("", "append")
+	require.NoError(t, err)
+	defer os.Remove(f.Name())
+	defer f.Close()
+
+	// the existing file has a long value in its key-value meta data, which makes the new footer
+	// shorter than the old one.
+	writeAppendTestFile(t, f, WithMetaData(map[string]string{"a": strings.Repeat("x", 5000)}))
+	size, err := f.Seek(0, io.SeekEnd)
+	require.NoError(t, err)
+
+	fw, err := NewAppendFileWriter(f, WithMetaData(map[string]string{"a": "1"}), WithPageIndex())
+	require.NoError(t, err)
+	require.NoError(t, fw.AddData(map[string]interface{}{"id": int64(3)}))
+	require.NoError(t, fw.Close())
+
+	newSize, err := f.Seek(0, io.SeekEnd)
+	require.NoError(t, err)
+	require.Less(t, newSize, size)
+
+	r, err := NewFileReader(f)
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{"a": "1"}, r.MetaData())
+	rowGroups := r.FileMetaData().RowGroups
+	require.Len(t, rowGroups, 2)
+	require.Nil(t, rowGroups[0].Columns[0].ColumnIndexOffset)
+	require.NotNil(t, rowGroups[1].Columns[0].ColumnIndexOffset)
+	require.Equal(t, []map[string]interface{}{
+		{"id": int64(1), "name": []byte("a")},
+		{"id": int64(2)},
+		{"id": int64(3)},
+	}, readAllRows(t, r))
+}
+
+func TestAppendFileWriterPadding(t *testing.T) {
+	buf := &bytes.Buffer{}
+	writeAppendTestFile(t, buf, WithMetaData(map[string]string{"a": strings.Repeat("x", 1000)}))
+	file := &appendTestFile{data: buf.Bytes()}
+	size := len(file.data)
+
+	fw, err := NewAppendFileWriter(file, WithMetaData(map[string]string{"a": "1"}))
+	require.NoError(t, err)
+	require.NoError(t, fw.AddData(map[string]interface{}{"id": int64(3)}))
+	require.NoError(t, fw.Close())
+	require.Len(t, file.data, size)
+
+	r, err := NewFileReader(bytes.NewReader(file.data))
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{"a": "1"}, r.MetaData())
+	require.Len(t, readAllRows(t, r), 3)
+}
+
+func TestAppendFileWriterErrors(t *testing.T) {
+	buf := &bytes.Buffer{}
+	writeAppendTestFile(t, buf)
+
+	sd, err := parquetschema.ParseSchemaDefinition(`message test {
+		required int64 id;
+		required binary name (STRING);
+	}`)
+	require.NoError(t, err)
+	_, err = NewAppendFileWriter(&appendTestFile{data: buf.Bytes()}, WithSchemaDefinition(sd))
+	require.EqualError(t, err, "the schema definition differs from the schema of the existing file")
+
+	fw, err := NewAppendFileWriter(&appendTestFile{data: buf.Bytes()}, WithSchemaDefinition(appendTestSchema(t)))
+	require.NoError(t, err)
+	require.NoError(t, fw.Close())
+
+	_, err = NewAppendFileWriter(&appendTestFile{data: buf.Bytes()}, WithSchemaDefinition(appendTestSchema(t)), WithColumnEncoding("foo", parquet.Encoding_PLAIN))
+	require.EqualError(t, err, `column "foo" from the column options not found in schema definition`)
+	_, err = NewAppendFileWriter(&appendTestFile{data: buf.Bytes()}, WithColumnEncoding("foo", parquet.Encoding_PLAIN))
+	require.EqualError(t, err, `column "foo" from the column options not found in schema definition`)
+
+	_, err = NewAppendFileWriter(&appendTestFile{data: []byte("invalid")})
+	require.Error(t, err)
+}
@@ -2,11 +2,16 @@ package goparquet
 
 import (
 	"bytes"
+	"encoding/binary"
 	"io"
 	"math/rand"
+	"os"
+	"sync"
 	"testing"
 
+	"github.com/fraugster/parquet-go/parquet"
 	"github.com/fraugster/parquet-go/parquetschema"
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
 
@@ -108,3 +113,156 @@ func TestByteReaderSelectedInnerFull(t *testing.T) {
 		require.Empty(t, y)
 	}
 }
+
+func TestReadWithConcurrency(t *testing.T) {
+	file := writePredicateTestFile(t, WithMaxPageRowCount(10), WithPageIndex(), WithCompressionCodec(parquet.CompressionCodec_SNAPPY))
+
+	r, err := NewFileReader(bytes.NewReader(file))
+	require.NoError(t, err)
+	expected := readAllRows(t, r)
+	require.Len(t, expected, 100)
+
+	// bytes.Reader implements io.ReaderAt, the wrapped reader only implements io.ReadSeeker.
+	readers := map[string]func() io.ReadSeeker{
+		"reader_at": func() io.ReadSeeker {
+			return bytes.NewReader(file)
+		},
+		"read_seeker": func() io.ReadSeeker {
+			return struct{ io.ReadSeeker }{bytes.NewReader(file)}
+		},
+	}
+
+	for name, newReader := range readers {
+		t.Run(name, func(t *testing.T) {
+			r, err := NewFileReaderWithOptions(newReader(), WithConcurrency(4))
+			require.NoError(t, err)
+			require.Equal(t, expected, readAllRows(t, r))
+
+			r, err = NewFileReaderWithOptions(newReader(), WithConcurrency(2), WithColumns("bar", "baz"))
+			require.NoError(t, err)
+			rows := readAllRows(t, r)
+			require.Len(t, rows, 100)
+			for i := range rows {
+				require.Equal(t, expected[i]["foo"], int64(i))
+				require.NotContains(t, rows[i], "foo")
+				require.Equal(t, expected[i]["bar"], rows[i]["bar"])
+				require.Equal(t, expected[i]["baz"], rows[i]["baz"])
+			}
+
+			r, err = NewFileReaderWithOptions(newReader(), WithConcurrency(3), WithPredicate(ColumnPredicate("foo", Equal, 42)))
+			require.NoError(t, err)
+			require.Len(t, readAllRows(t, r), 10)
+		})
+	}
+}
+
+func TestNewFileReaderAt(t *testing.T) {
+	file := writePredicateTestFile(t, WithMaxPageRowCount(10), WithPageIndex(), WithBloomFilter("foo", 0, 0))
+	ra := bytes.NewReader(file)
+
+	// all readers share the same io.ReaderAt.
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			var options []FileReaderOption
+			if i%2 == 1 {
+				options = append(options, WithStreaming())
+			}
+			r, err := NewFileReaderAt(ra, int64(len(file)), options...)
+			if !assert.NoError(t, err) {
+				return
+			}
+			for j := 0; j < 100; j++ {
+				row, err := r.NextRow()
+				if !assert.NoError(t, err) {
+					return
+				}
+				assert.Equal(t, predicateTestRow(j), row)
+			}
+			_, err = r.NextRow()
+			assert.Equal(t, io.EOF, err)
+		}(i)
+	}
+	wg.Wait()
+
+	r, err := NewFileReaderAt(ra, int64(len(file)), WithPredicate(ColumnPredicate("foo", Equal, 42)))
+	require.NoError(t, err)
+	require.Len(t, readAllRows(t, r), 10)
+
+	_, err = NewFileReaderAt(ra, 8)
+	require.Error(t, err)
+
+	_, err = NewFileReaderAt(ra, int64(len(file))-1)
+	require.Error(t, err)
+}
+
+// externalChunksFile returns a parquet file that only contains the file meta data of file, with all
+// column chunks referencing the file at path.
+func externalChunksFile(t *testing.T, file []byte, path string) []byte {
+	r, err := NewFileReader(bytes.NewReader(file))
+	require.NoError(t, err)
+	for _, rg := range r.meta.RowGroups {
+		for _, chunk := range rg.Columns {
+			chunk.FilePath = &path
+		}
+	}
+
+	meta := &bytes.Buffer{}
+	require.NoError(t, writeThrift(r.meta, meta))
+
+	buf := &bytes.Buffer{}
+	buf.Write(magic)
+	buf.Write(meta.Bytes())
+	require.NoError(t, binary.Write(buf, binary.LittleEndian, int32(meta.Len())))
+	buf.Write(magic)
+	return buf.Bytes()
+}
+
+func TestReadExternalColumnChunks(t *testing.T) {
+	file := writePredicateTestFile(t, WithMaxPageRowCount(10), WithPageIndex(), WithBloomFilter("bar", 0, 0))
+	metaFile := externalChunksFile(t, file, "part-0.parquet")
+
+	r, err := NewFileReader(bytes.NewReader(metaFile))
+	require.NoError(t, err)
+	_, err = r.NextRow()
+	require.EqualError(t, err, `column chunk is stored in file "part-0.parquet", but no FileOpener was provided`)
+
+	var opened []string
+	opener := FileOpenerFunc(func(path string) (io.ReaderAt, error) {
+		opened = append(opened, path)
+		if path != "part-0.parquet" {
+			return nil, os.ErrNotExist
+		}
+		return bytes.NewReader(file), nil
+	})
+
+	for _, options := range [][]FileReaderOption{{}, {WithStreaming()}, {WithConcurrency(2)}} {
+		r, err = NewFileReaderWithOptions(bytes.NewReader(metaFile), append(options, WithFileOpener(opener))...)
+		require.NoError(t, err)
+		rows := readAllRows(t, r)
+		require.Len(t, rows, 100)
+		for i, row := range rows {
+			require.Equal(t, predicateTestRow(i), row)
+		}
+		require.NoError(t, r.Close())
+	}
+	require.Equal(t, []string{"part-0.parquet", "part-0.parquet", "part-0.parquet"}, opened)
+
+	// the page indexes and Bloom filters are read from the file that contains the column chunk.
+	r, err = NewFileReaderWithOptions(bytes.NewReader(metaFile), WithFileOpener(opener), WithPredicate(ColumnPredicate("foo", Equal, 72)))
+	require.NoError(t, err)
+	rows := readAllRows(t, r)
+	require.Len(t, rows, 10)
+	require.Equal(t, predicateTestRow(70), rows[0])
+	contains, err := r.MightContain("bar", "unknown value")
+	require.NoError(t, err)
+	require.False(t, contains)
+
+	missing := externalChunksFile(t, file, "part-1.parquet")
+	r, err = NewFileReaderWithOptions(bytes.NewReader(missing), WithFileOpener(opener))
+	require.NoError(t, err)
+	_, err = r.NextRow()
+	require.Error(t, err)
+}
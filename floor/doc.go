@@ -201,5 +201,17 @@ needs to be a struct. Struct fields are matched up with parquet columns by conve
 to lowercase. If the struct field is equal to the parquet column name, it's a positive match. The exact
 mechanics of this may change in the future.
 
+Both the Writer and the Reader also support matching struct fields with parquet columns by their field
+ID, which is provided in the struct tag of the field. This way, columns that were renamed are still
+resolved correctly:
+
+	type yourRecord struct {
+		Name string `parquet:"name,id=7"`
+	}
+
+If the schema definition contains a column with field ID 7, the struct field is matched with it, regardless
+of its name. Otherwise, the struct field is matched by its name, unless the column with that name has a
+different field ID. A field ID that isn't a valid 32-bit integer makes reading and writing the record fail.
+
 */
 package floor
@@ -1,8 +1,12 @@
 package floor
 
 import (
+	"fmt"
 	"reflect"
+	"strconv"
 	"strings"
+
+	"github.com/fraugster/parquet-go/parquetschema"
 )
 
 var fieldNameFunc = fieldNameToLower
@@ -17,3 +21,54 @@ func fieldNameToLower(field reflect.StructField) string {
 
 	return strings.TrimSpace(parquetStructTagFields[0])
 }
+
+// fieldID returns the field ID of the column that the struct field is mapped to, as provided in
+// its struct tag like `parquet:"name,id=7"`. An error is returned if the field ID is not a valid
+// 32-bit integer.
+func fieldID(field reflect.StructField) (int32, bool, error) {
+	parquetStructTag, ok := field.Tag.Lookup("parquet")
+	if !ok {
+		return 0, false, nil
+	}
+
+	for _, option := range strings.Split(parquetStructTag, ",")[1:] {
+		option = strings.TrimSpace(option)
+		if !strings.HasPrefix(option, "id=") {
+			continue
+		}
+		value := strings.TrimPrefix(option, "id=")
+		id, err := strconv.ParseInt(value, 10, 32)
+		if err != nil {
+			return 0, false, fmt.Errorf("field %s has an invalid field ID %q in its struct tag", field.Name, value)
+		}
+		return int32(id), true, nil
+	}
+
+	return 0, false, nil
+}
+
+// fieldSchema returns the name and the schema definition of the column that the struct field is
+// mapped to. If the struct field has a field ID, the column with that field ID is used, so that
+// renamed columns are still found. Otherwise, the column with the name of the struct field is used,
+// unless it has a different field ID.
+func fieldSchema(schemaDef *parquetschema.SchemaDefinition, field reflect.StructField) (string, *parquetschema.SchemaDefinition, error) {
+	fieldName := fieldNameFunc(field)
+
+	id, ok, err := fieldID(field)
+	if err != nil {
+		return "", nil, err
+	}
+	if !ok {
+		return fieldName, schemaDef.SubSchema(fieldName), nil
+	}
+
+	if sd := schemaDef.SubSchemaByFieldID(id); sd != nil {
+		return sd.SchemaElement().Name, sd, nil
+	}
+
+	sd := schemaDef.SubSchema(fieldName)
+	if sd != nil && sd.SchemaElement().FieldID != nil {
+		return fieldName, nil, nil
+	}
+	return fieldName, sd, nil
+}
@@ -52,11 +52,14 @@ type Reader struct {
 
 // Close closes the reader.
 func (r *Reader) Close() error {
+	err := r.r.Close()
 	if r.f != nil {
-		return r.f.Close()
+		if ferr := r.f.Close(); err == nil {
+			err = ferr
+		}
 	}
 
-	return nil
+	return err
 }
 
 // Next reads the next object so that it is ready to be scanned.
@@ -121,9 +124,10 @@ func (um *reflectUnmarshaller) fillStruct(value reflect.Value, record interfaces
 	for i := 0; i < numFields; i++ {
 		fieldValue := value.Field(i)
 
-		fieldName := fieldNameFunc(typ.Field(i))
-
-		fieldSchemaDef := schemaDef.SubSchema(fieldName)
+		fieldName, fieldSchemaDef, err := fieldSchema(schemaDef, typ.Field(i))
+		if err != nil {
+			return err
+		}
 
 		if fieldSchemaDef == nil {
 			continue
@@ -480,3 +480,80 @@ func TestFillValue(t *testing.T) {
 	require.NoError(t, um.fillValue(reflect.ValueOf(&tt).Elem(), elem(int32(14620200)), sd.SubSchema("tmilli")))
 	require.Equal(t, tt, MustTime(NewTime(4, 3, 40, 200000000)).UTC())
 }
+
+func TestReadWriteFieldIDs(t *testing.T) {
+	_ = os.Mkdir("files", 0755)
+
+	sd, err := parquetschema.ParseSchemaDefinition(`message test {
+		required int64 id = 1;
+		optional binary full_name (STRING) = 7;
+		optional binary name (STRING) = 8;
+	}`)
+	require.NoError(t, err)
+
+	type record struct {
+		ID       int64  `parquet:"id,id=1"`
+		Name     string `parquet:"name,id=7"`
+		Nickname string `parquet:"nickname,id=8"`
+	}
+
+	w, err := NewFileWriter("files/field_ids.parquet", goparquet.WithSchemaDefinition(sd))
+	require.NoError(t, err)
+	require.NoError(t, w.Write(record{ID: 1, Name: "Jane Doe", Nickname: "Jane"}))
+	require.NoError(t, w.Close())
+
+	r, err := NewFileReader("files/field_ids.parquet")
+	require.NoError(t, err)
+	require.True(t, r.Next())
+
+	var read record
+	require.NoError(t, r.Scan(&read))
+	require.Equal(t, record{ID: 1, Name: "Jane Doe", Nickname: "Jane"}, read)
+
+	// struct fields without field ID are mapped by name.
+	var byName struct {
+		ID   int64
+		Name string
+	}
+	require.NoError(t, r.Scan(&byName))
+	require.Equal(t, int64(1), byName.ID)
+	require.Equal(t, "Jane", byName.Name)
+
+	// a struct field isn't mapped to a column with the same name but another field ID.
+	var otherID struct {
+		Name string `parquet:"name,id=9"`
+	}
+	require.NoError(t, r.Scan(&otherID))
+	require.Equal(t, "", otherID.Name)
+
+	type invalidID struct {
+		Name string `parquet:"name,id=abc"`
+	}
+	require.EqualError(t, r.Scan(&invalidID{}), `field Name has an invalid field ID "abc" in its struct tag`)
+	require.NoError(t, r.Close())
+
+	w, err = NewFileWriter("files/field_ids.parquet", goparquet.WithSchemaDefinition(sd))
+	require.NoError(t, err)
+	require.EqualError(t, w.Write(invalidID{Name: "Jane"}), `field Name has an invalid field ID "abc" in its struct tag`)
+	require.NoError(t, w.Write(record{ID: 2}))
+	require.NoError(t, w.Close())
+}
+
+func TestFieldID(t *testing.T) {
+	typ := reflect.TypeOf(struct {
+		A int64
+		B int64 `parquet:"b"`
+		C int64 `parquet:"c, id=7"`
+		D int64 `parquet:"d,id=x"`
+	}{})
+
+	for i, expected := range []int32{0, 0, 7} {
+		id, ok, err := fieldID(typ.Field(i))
+		require.NoError(t, err)
+		require.Equal(t, expected != 0, ok)
+		require.Equal(t, expected, id)
+	}
+
+	_, _, err := fieldID(typ.Field(3))
+	require.EqualError(t, err, `field D has an invalid field ID "x" in its struct tag`)
+}
@@ -30,7 +30,11 @@ func NewFileWriter(file string, opts ...goparquet.FileWriterOption) (*Writer, er
 		return nil, err
 	}
 
-	w := goparquet.NewFileWriter(f, opts...)
+	w, err := goparquet.NewFileWriterWithError(f, opts...)
+	if err != nil {
+		_ = f.Close()
+		return nil, err
+	}
 
 	return &Writer{
 		w: w,
@@ -101,14 +105,14 @@ func (m *reflectMarshaller) decodeStruct(record interfaces.MarshalObject, value
 	for i := 0; i < numFields; i++ {
 		fieldValue := value.Field(i)
 
-		fieldName := fieldNameFunc(typ.Field(i))
-
-		subSchemaDef := schemaDef.SubSchema(fieldName)
+		fieldName, subSchemaDef, err := fieldSchema(schemaDef, typ.Field(i))
+		if err != nil {
+			return err
+		}
 
 		field := record.AddField(fieldName)
 
-		err := m.decodeValue(field, fieldValue, subSchemaDef)
-		if err != nil {
+		if err := m.decodeValue(field, fieldValue, subSchemaDef); err != nil {
 			return err
 		}
 	}
@@ -0,0 +1,45 @@
+//go:build go1.16
+// +build go1.16
+
+package goparquet
+
+import (
+	"io"
+	"io/fs"
+	"path"
+
+	"github.com/pkg/errors"
+)
+
+// FSFileOpener returns a FileOpener that opens the files from fsys. The paths stored in the column
+// chunks are resolved relative to dir, which is the directory of the parquet file within fsys. The
+// files need to implement either io.ReaderAt or io.Seeker.
+func FSFileOpener(fsys fs.FS, dir string) FileOpener {
+	return FileOpenerFunc(func(name string) (io.ReaderAt, error) {
+		return openFSFile(fsys, path.Join(dir, name))
+	})
+}
+
+// fsFile is a file opened from an fs.FS.
+type fsFile struct {
+	io.ReaderAt
+	io.Closer
+}
+
+// openFSFile opens the file with the provided name from fsys for offset reads.
+func openFSFile(fsys fs.FS, name string) (fsFile, error) {
+	f, err := fsys.Open(name)
+	if err != nil {
+		return fsFile{}, err
+	}
+
+	switch r := f.(type) {
+	case io.ReaderAt:
+		return fsFile{ReaderAt: r, Closer: f}, nil
+	case io.ReadSeeker:
+		return fsFile{ReaderAt: newReadSeekerAt(r), Closer: f}, nil
+	}
+
+	_ = f.Close()
+	return fsFile{}, errors.Errorf("file %q supports neither io.ReaderAt nor io.Seeker", name)
+}
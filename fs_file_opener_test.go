@@ -0,0 +1,33 @@
+//go:build go1.16
+// +build go1.16
+
+package goparquet
+
+import (
+	"bytes"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFSFileOpener(t *testing.T) {
+	file := writePredicateTestFile(t)
+	fsys := fstest.MapFS{
+		"table/data/part-0.parquet": &fstest.MapFile{Data: file},
+	}
+
+	r, err := NewFileReaderWithOptions(bytes.NewReader(externalChunksFile(t, file, "data/part-0.parquet")),
+		WithFileOpener(FSFileOpener(fsys, "table")))
+	require.NoError(t, err)
+	rows := readAllRows(t, r)
+	require.Len(t, rows, 100)
+	require.Equal(t, predicateTestRow(99), rows[99])
+	require.NoError(t, r.Close())
+
+	r, err = NewFileReaderWithOptions(bytes.NewReader(externalChunksFile(t, file, "data/part-1.parquet")),
+		WithFileOpener(FSFileOpener(fsys, "table")))
+	require.NoError(t, err)
+	_, err = r.NextRow()
+	require.Error(t, err)
+}
@@ -3,9 +3,12 @@ module github.com/fraugster/parquet-go
 go 1.13
 
 require (
+	github.com/andybalholm/brotli v1.0.2
 	github.com/apache/thrift v0.13.0
+	github.com/cespare/xxhash/v2 v2.1.2
 	github.com/davecgh/go-spew v1.1.1
-	github.com/golang/snappy v0.0.1
+	github.com/golang/snappy v0.0.3
+	github.com/klauspost/compress v1.13.4
 	github.com/kr/pretty v0.1.0 // indirect
 	github.com/pkg/errors v0.9.1
 	github.com/simo7/protoc-gen-parquet v0.4.0
@@ -6,6 +6,7 @@ import (
 	"io"
 	"math"
 	"math/bits"
+	"sync"
 
 	"github.com/apache/thrift/lib/go/thrift"
 	"github.com/pkg/errors"
@@ -61,6 +62,39 @@ func (o *offsetReader) Count() int64 {
 	return o.count
 }
 
+// readSeekerAt implements io.ReaderAt on top of an io.ReadSeeker. Since the io.ReadSeeker only
+// has a single cursor, concurrent reads are serialized.
+type readSeekerAt struct {
+	mu  sync.Mutex
+	r   io.ReadSeeker
+	pos int64
+}
+
+func newReadSeekerAt(r io.ReadSeeker) *readSeekerAt {
+	return &readSeekerAt{r: r, pos: -1}
+}
+
+func (r *readSeekerAt) ReadAt(p []byte, off int64) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// only seek if the position changed, since seeking can be expensive for some readers.
+	if off != r.pos {
+		if _, err := r.r.Seek(off, io.SeekStart); err != nil {
+			r.pos = -1
+			return 0, err
+		}
+		r.pos = off
+	}
+
+	n, err := io.ReadFull(r.r, p)
+	r.pos += int64(n)
+	if err == io.ErrUnexpectedEOF {
+		err = io.EOF
+	}
+	return n, err
+}
+
 func decodeRLEValue(bytes []byte) int32 {
 	switch len(bytes) {
 	case 0:
@@ -283,7 +317,7 @@ func encodeLevelsV2(w io.Writer, max uint16, values *packedArray) error {
 
 func mapKey(a interface{}) interface{} {
 	switch v := a.(type) {
-	case int, int32, int64, string, bool, float64, float32:
+	case int, int32, int64, uint32, uint64, string, bool, float64, float32:
 		return a
 	case []byte:
 		return DefaultHashFunc(v)
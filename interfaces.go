@@ -16,14 +16,16 @@ type pageReader interface {
 	numValues() int32
 }
 
-// pageReader is an internal interface used only internally to read the pages
+// pageWriter is an internal interface used only internally to write the pages
 type pageWriter interface {
-	init(schema SchemaWriter, col *Column, codec parquet.CompressionCodec) error
+	init(col *Column, codec parquet.CompressionCodec, page *dataPage) error
 
 	write(w io.Writer) (int, int, error)
+
+	pageType() parquet.PageType
 }
 
-type newDataPageFunc func(useDict bool) pageWriter
+type newDataPageFunc func(useDict bool, opts pageWriteOptions) pageWriter
 
 type valuesDecoder interface {
 	init(io.Reader) error
@@ -60,11 +62,7 @@ type parquetColumn interface {
 type typedColumnStore interface {
 	parquetColumn
 	reset(repetitionType parquet.FieldRepetitionType)
-	// Min and Max in parquet byte
-	maxValue() []byte
-	minValue() []byte
-
-	// Should extract the value, turn it into an array and check for min and max on all values in this
+	// Should extract the value and turn it into an array
 	getValues(v interface{}) ([]interface{}, error)
 	sizeOf(v interface{}) int
 	// the tricky append. this is a way of creating new "typed" array. the first interface is nil or an []T (T is the type,
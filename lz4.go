@@ -0,0 +1,255 @@
+package goparquet
+
+import (
+	"encoding/binary"
+	"math"
+
+	"github.com/pkg/errors"
+)
+
+// This file contains an implementation of the LZ4 block format as described in
+// https://github.com/lz4/lz4/blob/dev/doc/lz4_Block_format.md and of the framing
+// that is used by Hadoop's Lz4Codec.
+
+const (
+	lz4MinMatch     = 4
+	lz4MFLimit      = 12 // the last match must start at least 12 bytes before the end of the block
+	lz4LastLiterals = 5  // the last 5 bytes of a block are always literals
+	lz4MaxOffset    = 65535
+	lz4HashLog      = 16
+	// every byte of a block decompresses to at most 255 bytes, since match lengths are encoded in
+	// steps of 255.
+	lz4MaxRatio = 255
+)
+
+// lz4MaxSize returns the maximum size of the decompressed data if the uncompressed size of the
+// compressed data src is unknown.
+func lz4MaxSize(src []byte) int {
+	if len(src) > math.MaxInt32/lz4MaxRatio {
+		return math.MaxInt32
+	}
+	return len(src) * lz4MaxRatio
+}
+
+// lz4CompressBlock compresses src into a single LZ4 block.
+func lz4CompressBlock(src []byte) []byte {
+	dst := make([]byte, 0, len(src)+len(src)/255+16)
+
+	anchor := 0
+	if len(src) > lz4MFLimit {
+		table := make([]int32, 1<<lz4HashLog)
+		limit := len(src) - lz4MFLimit
+		for i := 0; i < limit; {
+			seq := binary.LittleEndian.Uint32(src[i:])
+			h := (seq * 2654435761) >> (32 - lz4HashLog)
+			ref := int(table[h]) - 1
+			table[h] = int32(i + 1)
+
+			if ref < 0 || i-ref > lz4MaxOffset || binary.LittleEndian.Uint32(src[ref:]) != seq {
+				i++
+				continue
+			}
+
+			matchLen := lz4MinMatch
+			maxLen := len(src) - lz4LastLiterals - i
+			for matchLen < maxLen && src[i+matchLen] == src[ref+matchLen] {
+				matchLen++
+			}
+
+			dst = lz4AppendSequence(dst, src[anchor:i], i-ref, matchLen)
+			i += matchLen
+			anchor = i
+		}
+	}
+
+	return lz4AppendSequence(dst, src[anchor:], 0, 0)
+}
+
+// lz4AppendSequence appends a sequence of literals followed by a match to dst. If matchLen is 0,
+// only the literals are appended, which is only valid for the last sequence of a block.
+func lz4AppendSequence(dst []byte, literals []byte, offset, matchLen int) []byte {
+	var token byte
+	if len(literals) >= 15 {
+		token = 15 << 4
+	} else {
+		token = byte(len(literals)) << 4
+	}
+	if matchLen > 0 {
+		if matchLen-lz4MinMatch >= 15 {
+			token |= 15
+		} else {
+			token |= byte(matchLen - lz4MinMatch)
+		}
+	}
+
+	dst = append(dst, token)
+	if len(literals) >= 15 {
+		dst = lz4AppendLength(dst, len(literals)-15)
+	}
+	dst = append(dst, literals...)
+
+	if matchLen == 0 {
+		return dst
+	}
+
+	dst = append(dst, byte(offset), byte(offset>>8))
+	if matchLen-lz4MinMatch >= 15 {
+		dst = lz4AppendLength(dst, matchLen-lz4MinMatch-15)
+	}
+	return dst
+}
+
+func lz4AppendLength(dst []byte, n int) []byte {
+	for n >= 255 {
+		dst = append(dst, 255)
+		n -= 255
+	}
+	return append(dst, byte(n))
+}
+
+// lz4DecompressBlock decompresses a single LZ4 block and appends the result to dst. An error is
+// returned if the decompressed data is larger than maxSize bytes, so dst should have enough
+// capacity for maxSize additional bytes if it is known how large the decompressed data is.
+func lz4DecompressBlock(dst, src []byte, maxSize int) ([]byte, error) {
+	readLength := func(i int) (int, int, error) {
+		n := 0
+		for {
+			if i >= len(src) {
+				return 0, 0, errors.New("lz4: unexpected end of block")
+			}
+			b := src[i]
+			i++
+			n += int(b)
+			if b != 255 {
+				return n, i, nil
+			}
+		}
+	}
+
+	start := len(dst)
+	for i := 0; i < len(src); {
+		token := src[i]
+		i++
+
+		litLen := int(token >> 4)
+		if litLen == 15 {
+			n, next, err := readLength(i)
+			if err != nil {
+				return nil, err
+			}
+			litLen += n
+			i = next
+		}
+		if litLen > len(src)-i {
+			return nil, errors.New("lz4: literals exceed block size")
+		}
+		if litLen > maxSize-(len(dst)-start) {
+			return nil, errors.Errorf("lz4: decompressed data exceeds %d bytes", maxSize)
+		}
+		dst = append(dst, src[i:i+litLen]...)
+		i += litLen
+
+		if i == len(src) {
+			// the last sequence only contains literals.
+			break
+		}
+
+		if i+2 > len(src) {
+			return nil, errors.New("lz4: unexpected end of block")
+		}
+		offset := int(src[i]) | int(src[i+1])<<8
+		i += 2
+		if offset == 0 || offset > len(dst)-start {
+			return nil, errors.Errorf("lz4: invalid match offset %d", offset)
+		}
+
+		matchLen := int(token & 15)
+		if matchLen == 15 {
+			n, next, err := readLength(i)
+			if err != nil {
+				return nil, err
+			}
+			matchLen += n
+			i = next
+		}
+		matchLen += lz4MinMatch
+		if matchLen > maxSize-(len(dst)-start) {
+			return nil, errors.Errorf("lz4: decompressed data exceeds %d bytes", maxSize)
+		}
+
+		pos := len(dst) - offset
+		if offset >= matchLen {
+			dst = append(dst, dst[pos:pos+matchLen]...)
+			continue
+		}
+		// the match overlaps with the data it produces, so it needs to be copied byte by byte.
+		for j := 0; j < matchLen; j++ {
+			dst = append(dst, dst[pos+j])
+		}
+	}
+
+	return dst, nil
+}
+
+// lz4HadoopCompressBlock compresses src into a single Hadoop LZ4 frame, which consists of the big-endian
+// uncompressed size and the big-endian compressed size, followed by the compressed LZ4 block.
+func lz4HadoopCompressBlock(src []byte) []byte {
+	if len(src) == 0 {
+		// a frame without any blocks.
+		return make([]byte, 4)
+	}
+
+	compressed := lz4CompressBlock(src)
+	dst := make([]byte, 8, 8+len(compressed))
+	binary.BigEndian.PutUint32(dst, uint32(len(src)))
+	binary.BigEndian.PutUint32(dst[4:], uint32(len(compressed)))
+	return append(dst, compressed...)
+}
+
+// lz4HadoopDecompressBlock decompresses data that consists of one or more Hadoop LZ4 frames. Every frame
+// starts with the big-endian uncompressed size, followed by one or more LZ4 blocks that are each prefixed
+// by their big-endian compressed size. If size isn't negative, it's the uncompressed size of the data,
+// and the sizes of the frames may not exceed it.
+func lz4HadoopDecompressBlock(src []byte, size int) ([]byte, error) {
+	var dst []byte
+	if size < 0 {
+		size = lz4MaxSize(src)
+	} else {
+		dst = make([]byte, 0, size)
+	}
+
+	for i := 0; i < len(src); {
+		if i+4 > len(src) {
+			return nil, errors.New("lz4: invalid hadoop frame header")
+		}
+		frameSize := int(binary.BigEndian.Uint32(src[i:]))
+		i += 4
+		if frameSize > size-len(dst) {
+			return nil, errors.Errorf("lz4: hadoop frame size %d exceeds the decompressed size %d", frameSize, size)
+		}
+
+		frameStart := len(dst)
+		for len(dst)-frameStart < frameSize {
+			if i+4 > len(src) {
+				return nil, errors.New("lz4: invalid hadoop block header")
+			}
+			blockSize := int(binary.BigEndian.Uint32(src[i:]))
+			i += 4
+			if blockSize == 0 || blockSize > len(src)-i {
+				return nil, errors.Errorf("lz4: invalid hadoop block size %d", blockSize)
+			}
+
+			var err error
+			if dst, err = lz4DecompressBlock(dst, src[i:i+blockSize], frameSize-(len(dst)-frameStart)); err != nil {
+				return nil, err
+			}
+			i += blockSize
+		}
+
+		if len(dst)-frameStart != frameSize {
+			return nil, errors.Errorf("lz4: hadoop frame should be %d bytes but is %d bytes", frameSize, len(dst)-frameStart)
+		}
+	}
+
+	return dst, nil
+}
@@ -0,0 +1,115 @@
+package goparquet
+
+import (
+	"bytes"
+	"math/rand"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLZ4RoundTrip(t *testing.T) {
+	rnd := rand.New(rand.NewSource(42))
+	random := make([]byte, 100000)
+	rnd.Read(random)
+
+	text := []byte(strings.Repeat("lorem ipsum dolor sit amet, consectetur adipiscing elit. ", 2000))
+
+	mixed := make([]byte, 0, 200000)
+	for i := 0; i < 1000; i++ {
+		mixed = append(mixed, random[i*10:i*10+rnd.Intn(20)]...)
+		mixed = append(mixed, text[:rnd.Intn(300)]...)
+	}
+
+	inputs := [][]byte{
+		{},
+		[]byte("a"),
+		[]byte("abcdefghijkl"),
+		[]byte("abcdabcdabcdabcd"),
+		bytes.Repeat([]byte{0}, 100000),
+		random,
+		text,
+		mixed,
+	}
+
+	for _, input := range inputs {
+		compressed := lz4CompressBlock(input)
+		decompressed, err := lz4DecompressBlock(nil, compressed, len(input))
+		require.NoError(t, err)
+		require.Equal(t, len(input), len(decompressed))
+		require.True(t, bytes.Equal(input, decompressed))
+
+		framed := lz4HadoopCompressBlock(input)
+		decompressed, err = lz4HadoopDecompressBlock(framed, -1)
+		require.NoError(t, err)
+		require.True(t, bytes.Equal(input, decompressed))
+
+		decompressed, err = lz4HadoopDecompressBlock(framed, len(input))
+		require.NoError(t, err)
+		require.True(t, bytes.Equal(input, decompressed))
+	}
+
+	require.Less(t, len(lz4CompressBlock(text)), len(text)/10)
+}
+
+func TestLZ4DecompressBlock(t *testing.T) {
+	// a single literal followed by an overlapping match of 29 bytes and 5 trailing literals.
+	block := []byte{0x1f, 'a', 0x01, 0x00, 0x0a, 0x50, 'a', 'a', 'a', 'a', 'a'}
+	res, err := lz4DecompressBlock(nil, block, 35)
+	require.NoError(t, err)
+	require.Equal(t, bytes.Repeat([]byte("a"), 35), res)
+
+	// the decompressed data may not exceed the expected size.
+	_, err = lz4DecompressBlock(nil, block, 34)
+	require.EqualError(t, err, "lz4: decompressed data exceeds 34 bytes")
+	_, err = lz4DecompressBlock(nil, block, 20)
+	require.EqualError(t, err, "lz4: decompressed data exceeds 20 bytes")
+	_, err = lz4DecompressBlock(nil, []byte{0x1f, 'a', 0x01, 0x00, 0xff, 0xff, 0xff, 0xff, 0x00}, 100)
+	require.EqualError(t, err, "lz4: decompressed data exceeds 100 bytes")
+
+	invalid := [][]byte{
+		{0x10},
+		{0xf0},
+		{0x10, 'a', 0x01},
+		{0x10, 'a', 0x02, 0x00},
+		{0x10, 'a', 0x00, 0x00},
+		{0x1f, 'a', 0x01, 0x00},
+	}
+	for _, block := range invalid {
+		_, err := lz4DecompressBlock(nil, block, lz4MaxSize(block))
+		require.Error(t, err, "%v", block)
+	}
+}
+
+func TestLZ4HadoopDecompressBlock(t *testing.T) {
+	// a frame with two blocks, followed by a second frame.
+	var data []byte
+	data = append(data, 0, 0, 0, 6)
+	data = append(data, 0, 0, 0, 4, 0x30, 'f', 'o', 'o')
+	data = append(data, 0, 0, 0, 4, 0x30, 'b', 'a', 'r')
+	data = append(data, 0, 0, 0, 3)
+	data = append(data, 0, 0, 0, 4, 0x30, 'b', 'a', 'z')
+
+	res, err := lz4HadoopDecompressBlock(data, -1)
+	require.NoError(t, err)
+	require.Equal(t, []byte("foobarbaz"), res)
+
+	_, err = lz4HadoopDecompressBlock(data, 8)
+	require.EqualError(t, err, "lz4: hadoop frame size 3 exceeds the decompressed size 8")
+
+	_, err = lz4HadoopDecompressBlock(data[:10], -1)
+	require.Error(t, err)
+
+	_, err = lz4HadoopDecompressBlock([]byte{0, 0, 0, 2, 0, 0, 0, 4, 0x30, 'f', 'o', 'o'}, -1)
+	require.Error(t, err)
+
+	// a frame that claims to be larger than the page.
+	_, err = lz4HadoopDecompressBlock([]byte{0x7f, 0xff, 0xff, 0xff, 0, 0, 0, 4, 0x30, 'f', 'o', 'o'}, 3)
+	require.EqualError(t, err, "lz4: hadoop frame size 2147483647 exceeds the decompressed size 3")
+
+	// raw LZ4 blocks are accepted for the LZ4 codec as well.
+	res, err = lz4HadoopCompressor{}.DecompressBlock([]byte{0x30, 'f', 'o', 'o'})
+	require.NoError(t, err)
+	require.Equal(t, []byte("foo"), res)
+}
@@ -95,3 +95,15 @@ func (pa *packedArray) appendArray(other *packedArray) {
 		pa.appendSingle(v)
 	}
 }
+
+// slice returns a new packed array that contains the values from position start up to
+// (but not including) position end.
+func (pa *packedArray) slice(start, end int) *packedArray {
+	ret := &packedArray{}
+	ret.reset(pa.bw)
+	for i := start; i < end; i++ {
+		v, _ := pa.at(i)
+		ret.appendSingle(v)
+	}
+	return ret
+}
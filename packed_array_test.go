@@ -57,3 +57,14 @@ func TestPackedArrayAppendArray(t *testing.T) {
 	notFixed.appendArray(fixed)
 	require.Equal(t, append(data2, data1...), notFixed.toArray())
 }
+
+func TestPackedArraySlice(t *testing.T) {
+	const bw = 5
+
+	packed, data := newRandomPacked(bw, 8*10+3)
+
+	require.Equal(t, data[3:50], packed.slice(3, 50).toArray())
+	require.Equal(t, data[80:], packed.slice(80, packed.count).toArray())
+	require.Equal(t, data, packed.slice(0, packed.count).toArray())
+	require.Empty(t, packed.slice(7, 7).toArray())
+}
@@ -66,12 +66,18 @@ func (dp *dictPageReader) read(r io.Reader, ph *parquet.PageHeader, codec parque
 type dictPageWriter struct {
 	col *Column
 
+	// values contains the dictionary values, which may only be a prefix of the values in the
+	// column store if the dictionary reached its maximum size.
+	values []interface{}
+
 	codec parquet.CompressionCodec
+	opts  pageWriteOptions
 }
 
-func (dp *dictPageWriter) init(schema SchemaWriter, col *Column, codec parquet.CompressionCodec) error {
+func (dp *dictPageWriter) init(col *Column, codec parquet.CompressionCodec, values []interface{}) error {
 	dp.col = col
 	dp.codec = codec
+	dp.values = values
 	return nil
 }
 
@@ -82,7 +88,7 @@ func (dp *dictPageWriter) getHeader(comp, unComp int) *parquet.PageHeader {
 		CompressedPageSize:   int32(comp),
 		Crc:                  nil,
 		DictionaryPageHeader: &parquet.DictionaryPageHeader{
-			NumValues: dp.col.data.values.numDistinctValues(),
+			NumValues: int32(len(dp.values)),
 			Encoding:  parquet.Encoding_PLAIN, // PLAIN_DICTIONARY is deprecated in the Parquet 2.0 specification
 			IsSorted:  nil,
 		},
@@ -99,7 +105,7 @@ func (dp *dictPageWriter) write(w io.Writer) (int, int, error) {
 		return 0, 0, err
 	}
 
-	err = encodeValue(dataBuf, encoder, dp.col.data.values.values)
+	err = encodeValue(dataBuf, encoder, dp.values)
 	if err != nil {
 		return 0, 0, err
 	}
@@ -111,9 +117,6 @@ func (dp *dictPageWriter) write(w io.Writer) (int, int, error) {
 	compSize, unCompSize := len(comp), len(dataBuf.Bytes())
 
 	header := dp.getHeader(compSize, unCompSize)
-	if err := writeThrift(header, w); err != nil {
-		return 0, 0, err
-	}
-
-	return compSize, unCompSize, writeFull(w, comp)
+	compSize, err = dp.opts.writePage(w, header, comp)
+	return compSize, unCompSize, err
 }
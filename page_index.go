@@ -0,0 +1,135 @@
+package goparquet
+
+import (
+	"github.com/fraugster/parquet-go/parquet"
+)
+
+// pageIndex collects the column index and the offset index of a single column chunk
+// while its data pages are written.
+type pageIndex struct {
+	columnIndex *parquet.ColumnIndex
+	offsetIndex *parquet.OffsetIndex
+
+	// the minimum and maximum values of all non-null pages, used to determine the boundary order.
+	mins, maxs []interface{}
+	// compare orders the min and max values according to the column's type defined order.
+	compare func(a, b interface{}) int
+
+	// enc encrypts the column index and the offset index, it is nil if the column chunk isn't
+	// encrypted.
+	enc *columnEncryptor
+
+	// invalid is set when a page contains values but no min and max value could be determined
+	// (e.g. because all values are NaN). No column index is written in that case.
+	invalid bool
+}
+
+func newPageIndex() *pageIndex {
+	return &pageIndex{
+		columnIndex: &parquet.ColumnIndex{
+			NullPages:     []bool{},
+			MinValues:     [][]byte{},
+			MaxValues:     [][]byte{},
+			BoundaryOrder: parquet.BoundaryOrder_UNORDERED,
+			NullCounts:    []int64{},
+		},
+		offsetIndex: &parquet.OffsetIndex{
+			PageLocations: []*parquet.PageLocation{},
+		},
+	}
+}
+
+// addPage adds a data page that starts at offset, has a total size (including the page header)
+// of size bytes and starts with the row firstRow within the row group.
+func (idx *pageIndex) addPage(offset int64, size int32, firstRow int64, p *dataPage) {
+	idx.offsetIndex.PageLocations = append(idx.offsetIndex.PageLocations, &parquet.PageLocation{
+		Offset:             offset,
+		CompressedPageSize: size,
+		FirstRowIndex:      firstRow,
+	})
+
+	ci := idx.columnIndex
+	ci.NullCounts = append(ci.NullCounts, int64(p.numNulls))
+
+	if p.stats.min == nil {
+		if p.numNulls != p.numValues {
+			idx.invalid = true
+		}
+		ci.NullPages = append(ci.NullPages, true)
+		ci.MinValues = append(ci.MinValues, []byte{})
+		ci.MaxValues = append(ci.MaxValues, []byte{})
+		return
+	}
+
+	ci.NullPages = append(ci.NullPages, false)
+	ci.MinValues = append(ci.MinValues, p.stats.minValue())
+	ci.MaxValues = append(ci.MaxValues, p.stats.maxValue())
+	idx.mins = append(idx.mins, p.stats.min)
+	idx.maxs = append(idx.maxs, p.stats.max)
+	idx.compare = p.stats.compare
+}
+
+// boundaryOrder determines whether the min and max values of the pages are ordered.
+func (idx *pageIndex) boundaryOrder() parquet.BoundaryOrder {
+	if len(idx.mins) == 0 {
+		return parquet.BoundaryOrder_UNORDERED
+	}
+
+	ascending, descending := true, true
+	for i := 1; i < len(idx.mins); i++ {
+		minCmp := idx.compare(idx.mins[i-1], idx.mins[i])
+		maxCmp := idx.compare(idx.maxs[i-1], idx.maxs[i])
+		if minCmp > 0 || maxCmp > 0 {
+			ascending = false
+		}
+		if minCmp < 0 || maxCmp < 0 {
+			descending = false
+		}
+	}
+
+	switch {
+	case ascending:
+		return parquet.BoundaryOrder_ASCENDING
+	case descending:
+		return parquet.BoundaryOrder_DESCENDING
+	default:
+		return parquet.BoundaryOrder_UNORDERED
+	}
+}
+
+// writePageIndexes writes the column indexes and then the offset indexes of all column chunks
+// and updates the column chunks with their offsets and lengths. indexes contains the page
+// indexes for every row group, in the same order as the row groups' column chunks.
+func writePageIndexes(w writePos, rowGroups []*parquet.RowGroup, indexes [][]*pageIndex) error {
+	for i, rg := range rowGroups {
+		for j, chunk := range rg.Columns {
+			idx := indexes[i][j]
+			if idx.invalid {
+				continue
+			}
+			idx.columnIndex.BoundaryOrder = idx.boundaryOrder()
+
+			pos := w.Pos()
+			if err := idx.enc.writeThrift(idx.columnIndex, w, moduleColumnIndex, -1); err != nil {
+				return err
+			}
+			length := int32(w.Pos() - pos)
+			chunk.ColumnIndexOffset = &pos
+			chunk.ColumnIndexLength = &length
+		}
+	}
+
+	for i, rg := range rowGroups {
+		for j, chunk := range rg.Columns {
+			pos := w.Pos()
+			if err := indexes[i][j].enc.writeThrift(indexes[i][j].offsetIndex, w, moduleOffsetIndex, -1); err != nil {
+				return err
+			}
+			length := int32(w.Pos() - pos)
+			chunk.OffsetIndexOffset = &pos
+			chunk.OffsetIndexLength = &length
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,131 @@
+package goparquet
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+
+	"github.com/fraugster/parquet-go/parquet"
+	"github.com/fraugster/parquet-go/parquetschema"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWritePageIndex(t *testing.T) {
+	sd, err := parquetschema.ParseSchemaDefinition(
+		`message test_msg {
+			required int64 foo;
+			optional int32 bar;
+			optional double baz;
+		}`)
+	require.NoError(t, err)
+
+	buf := &bytes.Buffer{}
+	w := NewFileWriter(buf, WithSchemaDefinition(sd), WithMaxPageRowCount(10), WithPageIndex())
+
+	for i := 0; i < 30; i++ {
+		data := map[string]interface{}{
+			"foo": int64(i),
+			"baz": float64(-i),
+		}
+		if i >= 10 && i%2 == 0 {
+			data["bar"] = int32(100 - i)
+		}
+		require.NoError(t, w.AddData(data))
+	}
+	require.NoError(t, w.Close())
+
+	r, err := NewFileReader(bytes.NewReader(buf.Bytes()))
+	require.NoError(t, err)
+
+	file := buf.Bytes()
+	chunks := r.meta.RowGroups[0].Columns
+	require.Len(t, chunks, 3)
+
+	int32Bytes := func(v int32) []byte {
+		b := make([]byte, 4)
+		binary.LittleEndian.PutUint32(b, uint32(v))
+		return b
+	}
+	int64Bytes := func(v int64) []byte {
+		b := make([]byte, 8)
+		binary.LittleEndian.PutUint64(b, uint64(v))
+		return b
+	}
+
+	for _, chunk := range chunks {
+		require.NotNil(t, chunk.ColumnIndexOffset)
+		require.NotNil(t, chunk.ColumnIndexLength)
+		require.NotNil(t, chunk.OffsetIndexOffset)
+		require.NotNil(t, chunk.OffsetIndexLength)
+
+		oi := &parquet.OffsetIndex{}
+		require.NoError(t, readThrift(oi, bytes.NewReader(file[*chunk.OffsetIndexOffset:*chunk.OffsetIndexOffset+int64(*chunk.OffsetIndexLength)])))
+		require.Len(t, oi.PageLocations, 3)
+
+		headers := readDataPageHeaders(t, file, chunk)
+		for i, loc := range oi.PageLocations {
+			require.Equal(t, int64(i*10), loc.FirstRowIndex)
+
+			ph := &parquet.PageHeader{}
+			r := bytes.NewReader(file[loc.Offset : loc.Offset+int64(loc.CompressedPageSize)])
+			require.NoError(t, readThrift(ph, r))
+			require.Equal(t, headers[i], ph)
+			require.Equal(t, int(ph.CompressedPageSize), r.Len())
+		}
+	}
+
+	readColumnIndex := func(chunk *parquet.ColumnChunk) *parquet.ColumnIndex {
+		ci := &parquet.ColumnIndex{}
+		require.NoError(t, readThrift(ci, bytes.NewReader(file[*chunk.ColumnIndexOffset:*chunk.ColumnIndexOffset+int64(*chunk.ColumnIndexLength)])))
+		return ci
+	}
+
+	require.Equal(t, &parquet.ColumnIndex{
+		NullPages:     []bool{false, false, false},
+		MinValues:     [][]byte{int64Bytes(0), int64Bytes(10), int64Bytes(20)},
+		MaxValues:     [][]byte{int64Bytes(9), int64Bytes(19), int64Bytes(29)},
+		BoundaryOrder: parquet.BoundaryOrder_ASCENDING,
+		NullCounts:    []int64{0, 0, 0},
+	}, readColumnIndex(chunks[0]))
+
+	require.Equal(t, &parquet.ColumnIndex{
+		NullPages:     []bool{true, false, false},
+		MinValues:     [][]byte{{}, int32Bytes(82), int32Bytes(72)},
+		MaxValues:     [][]byte{{}, int32Bytes(90), int32Bytes(80)},
+		BoundaryOrder: parquet.BoundaryOrder_DESCENDING,
+		NullCounts:    []int64{10, 5, 5},
+	}, readColumnIndex(chunks[1]))
+
+	require.Equal(t, parquet.BoundaryOrder_DESCENDING, readColumnIndex(chunks[2]).BoundaryOrder)
+
+	headers := readDataPageHeaders(t, file, chunks[1])
+	require.Equal(t, &parquet.Statistics{
+		Min:       int32Bytes(82),
+		Max:       int32Bytes(90),
+		MinValue:  int32Bytes(82),
+		MaxValue:  int32Bytes(90),
+		NullCount: int64Ptr(5),
+	}, headers[1].DataPageHeader.Statistics)
+}
+
+func TestWriteWithoutPageIndex(t *testing.T) {
+	buf := &bytes.Buffer{}
+	w := NewFileWriter(buf)
+
+	s, err := NewInt64Store(parquet.Encoding_PLAIN, true, &ColumnParameters{})
+	require.NoError(t, err)
+	require.NoError(t, w.AddColumn("foo", NewDataColumn(s, parquet.FieldRepetitionType_REQUIRED)))
+	require.NoError(t, w.AddData(map[string]interface{}{"foo": int64(23)}))
+	require.NoError(t, w.Close())
+
+	r, err := NewFileReader(bytes.NewReader(buf.Bytes()))
+	require.NoError(t, err)
+
+	chunk := r.meta.RowGroups[0].Columns[0]
+	require.Nil(t, chunk.ColumnIndexOffset)
+	require.Nil(t, chunk.OffsetIndexOffset)
+}
+
+func int64Ptr(v int64) *int64 {
+	return &v
+}
@@ -104,15 +104,18 @@ func (dp *dataPageReaderV1) read(r io.Reader, ph *parquet.PageHeader, codec parq
 }
 
 type dataPageWriterV1 struct {
-	col *Column
+	col  *Column
+	page *dataPage
 
 	codec      parquet.CompressionCodec
 	dictionary bool
+	opts       pageWriteOptions
 }
 
-func (dp *dataPageWriterV1) init(schema SchemaWriter, col *Column, codec parquet.CompressionCodec) error {
+func (dp *dataPageWriterV1) init(col *Column, codec parquet.CompressionCodec, page *dataPage) error {
 	dp.col = col
 	dp.codec = codec
+	dp.page = page
 	return nil
 }
 
@@ -127,12 +130,12 @@ func (dp *dataPageWriterV1) getHeader(comp, unComp int) *parquet.PageHeader {
 		CompressedPageSize:   int32(comp),
 		Crc:                  nil,
 		DataPageHeader: &parquet.DataPageHeader{
-			NumValues: dp.col.data.values.numValues() + dp.col.data.values.nullValueCount(),
+			NumValues: dp.page.numValues,
 			Encoding:  enc,
 			// Only RLE supported for now, not sure if we need support for more encoding
 			DefinitionLevelEncoding: parquet.Encoding_RLE,
 			RepetitionLevelEncoding: parquet.Encoding_RLE,
-			Statistics:              nil,
+			Statistics:              dp.page.statistics(dp.col.Element()),
 		},
 	}
 	return ph
@@ -142,14 +145,14 @@ func (dp *dataPageWriterV1) write(w io.Writer) (int, int, error) {
 	dataBuf := &bytes.Buffer{}
 	// Only write repetition value higher than zero
 	if dp.col.MaxRepetitionLevel() > 0 {
-		if err := encodeLevelsV1(dataBuf, dp.col.MaxRepetitionLevel(), dp.col.data.rLevels); err != nil {
+		if err := encodeLevelsV1(dataBuf, dp.col.MaxRepetitionLevel(), dp.page.rLevels); err != nil {
 			return 0, 0, err
 		}
 	}
 
 	// Only write definition value higher than zero
 	if dp.col.MaxDefinitionLevel() > 0 {
-		if err := encodeLevelsV1(dataBuf, dp.col.MaxDefinitionLevel(), dp.col.data.dLevels); err != nil {
+		if err := encodeLevelsV1(dataBuf, dp.col.MaxDefinitionLevel(), dp.page.dLevels); err != nil {
 			return 0, 0, err
 		}
 	}
@@ -164,7 +167,7 @@ func (dp *dataPageWriterV1) write(w io.Writer) (int, int, error) {
 		return 0, 0, err
 	}
 
-	err = encodeValue(dataBuf, encoder, dp.col.data.values.assemble())
+	err = encodeValue(dataBuf, encoder, dp.page.values(dp.col.data.values))
 	if err != nil {
 		return 0, 0, err
 	}
@@ -176,15 +179,17 @@ func (dp *dataPageWriterV1) write(w io.Writer) (int, int, error) {
 	compSize, unCompSize := len(comp), len(dataBuf.Bytes())
 
 	header := dp.getHeader(compSize, unCompSize)
-	if err := writeThrift(header, w); err != nil {
-		return 0, 0, err
-	}
+	compSize, err = dp.opts.writePage(w, header, comp)
+	return compSize, unCompSize, err
+}
 
-	return compSize, unCompSize, writeFull(w, comp)
+func (dp *dataPageWriterV1) pageType() parquet.PageType {
+	return parquet.PageType_DATA_PAGE
 }
 
-func newDataPageV1Writer(useDict bool) pageWriter {
+func newDataPageV1Writer(useDict bool, opts pageWriteOptions) pageWriter {
 	return &dataPageWriterV1{
 		dictionary: useDict,
+		opts:       opts,
 	}
 }
@@ -129,17 +129,18 @@ func (dp *dataPageReaderV2) read(r io.Reader, ph *parquet.PageHeader, codec parq
 }
 
 type dataPageWriterV2 struct {
-	col    *Column
-	schema SchemaWriter
+	col  *Column
+	page *dataPage
 
 	codec      parquet.CompressionCodec
 	dictionary bool
+	opts       pageWriteOptions
 }
 
-func (dp *dataPageWriterV2) init(schema SchemaWriter, col *Column, codec parquet.CompressionCodec) error {
+func (dp *dataPageWriterV2) init(col *Column, codec parquet.CompressionCodec, page *dataPage) error {
 	dp.col = col
 	dp.codec = codec
-	dp.schema = schema
+	dp.page = page
 	return nil
 }
 
@@ -154,14 +155,14 @@ func (dp *dataPageWriterV2) getHeader(comp, unComp, defSize, repSize int, isComp
 		CompressedPageSize:   int32(comp + defSize + repSize),
 		Crc:                  nil,
 		DataPageHeaderV2: &parquet.DataPageHeaderV2{
-			NumValues:                  dp.col.data.values.numValues() + dp.col.data.values.nullValueCount(),
-			NumNulls:                   dp.col.data.values.nullValueCount(),
-			NumRows:                    int32(dp.schema.rowGroupNumRecords()),
+			NumValues:                  dp.page.numValues,
+			NumNulls:                   dp.page.numNulls,
+			NumRows:                    dp.page.numRows,
 			Encoding:                   enc,
 			DefinitionLevelsByteLength: int32(defSize),
 			RepetitionLevelsByteLength: int32(repSize),
 			IsCompressed:               isCompressed,
-			Statistics:                 nil,
+			Statistics:                 dp.page.statistics(dp.col.Element()),
 		},
 	}
 	return ph
@@ -172,7 +173,7 @@ func (dp *dataPageWriterV2) write(w io.Writer) (int, int, error) {
 
 	// Only write repetition value higher than zero
 	if dp.col.MaxRepetitionLevel() > 0 {
-		if err := encodeLevelsV2(rep, dp.col.MaxRepetitionLevel(), dp.col.data.rLevels); err != nil {
+		if err := encodeLevelsV2(rep, dp.col.MaxRepetitionLevel(), dp.page.rLevels); err != nil {
 			return 0, 0, err
 		}
 	}
@@ -181,7 +182,7 @@ func (dp *dataPageWriterV2) write(w io.Writer) (int, int, error) {
 
 	// Only write definition level higher than zero
 	if dp.col.MaxDefinitionLevel() > 0 {
-		if err := encodeLevelsV2(def, dp.col.MaxDefinitionLevel(), dp.col.data.dLevels); err != nil {
+		if err := encodeLevelsV2(def, dp.col.MaxDefinitionLevel(), dp.page.dLevels); err != nil {
 			return 0, 0, err
 		}
 	}
@@ -197,7 +198,7 @@ func (dp *dataPageWriterV2) write(w io.Writer) (int, int, error) {
 		return 0, 0, err
 	}
 
-	if err = encodeValue(dataBuf, encoder, dp.col.data.values.assemble()); err != nil {
+	if err = encodeValue(dataBuf, encoder, dp.page.values(dp.col.data.values)); err != nil {
 		return 0, 0, err
 	}
 
@@ -208,23 +209,18 @@ func (dp *dataPageWriterV2) write(w io.Writer) (int, int, error) {
 	compSize, unCompSize := len(comp), len(dataBuf.Bytes())
 	defLen, repLen := def.Len(), rep.Len()
 	header := dp.getHeader(compSize, unCompSize, defLen, repLen, dp.codec != parquet.CompressionCodec_UNCOMPRESSED)
-	if err := writeThrift(header, w); err != nil {
-		return 0, 0, err
-	}
-
-	if err := writeFull(w, rep.Bytes()); err != nil {
-		return 0, 0, err
-	}
-
-	if err := writeFull(w, def.Bytes()); err != nil {
-		return 0, 0, err
-	}
+	// the levels and the values are written, checksummed and encrypted together.
+	size, err := dp.opts.writePage(w, header, rep.Bytes(), def.Bytes(), comp)
+	return size, unCompSize + defLen + repLen, err
+}
 
-	return compSize + defLen + repLen, unCompSize + defLen + repLen, writeFull(w, comp)
+func (dp *dataPageWriterV2) pageType() parquet.PageType {
+	return parquet.PageType_DATA_PAGE_V2
 }
 
-func newDataPageV2Writer(useDict bool) pageWriter {
+func newDataPageV2Writer(useDict bool, opts pageWriteOptions) pageWriter {
 	return &dataPageWriterV2{
 		dictionary: useDict,
+		opts:       opts,
 	}
 }
@@ -449,6 +449,7 @@ const (
 	CompressionCodec_BROTLI       CompressionCodec = 4
 	CompressionCodec_LZ4          CompressionCodec = 5
 	CompressionCodec_ZSTD         CompressionCodec = 6
+	CompressionCodec_LZ4_RAW      CompressionCodec = 7
 )
 
 func (p CompressionCodec) String() string {
@@ -467,6 +468,8 @@ func (p CompressionCodec) String() string {
 		return "LZ4"
 	case CompressionCodec_ZSTD:
 		return "ZSTD"
+	case CompressionCodec_LZ4_RAW:
+		return "LZ4_RAW"
 	}
 	return "<UNSET>"
 }
@@ -487,6 +490,8 @@ func CompressionCodecFromString(s string) (CompressionCodec, error) {
 		return CompressionCodec_LZ4, nil
 	case "ZSTD":
 		return CompressionCodec_ZSTD, nil
+	case "LZ4_RAW":
+		return CompressionCodec_LZ4_RAW, nil
 	}
 	return CompressionCodec(0), fmt.Errorf("not a valid CompressionCodec string")
 }
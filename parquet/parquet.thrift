@@ -484,6 +484,7 @@ enum CompressionCodec {
   BROTLI = 4; // Added in 2.4
   LZ4 = 5;    // Added in 2.4
   ZSTD = 6;   // Added in 2.4
+  LZ4_RAW = 7; // Added in 2.9
 }
 
 enum PageType {
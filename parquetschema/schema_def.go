@@ -137,6 +137,20 @@ func (sd *SchemaDefinition) SubSchema(name string) *SchemaDefinition {
 	return nil
 }
 
+// SubSchemaByFieldID returns the direct child of the current schema
+// definition that has the provided field ID. If no such child exists,
+// nil is returned.
+func (sd *SchemaDefinition) SubSchemaByFieldID(id int32) *SchemaDefinition {
+	for _, c := range sd.RootColumn.Children {
+		if c.SchemaElement.FieldID != nil && *c.SchemaElement.FieldID == id {
+			return &SchemaDefinition{
+				RootColumn: c,
+			}
+		}
+	}
+	return nil
+}
+
 // SchemaElement returns the schema element associated with the current
 // schema definition. If no schema element is present, then nil is returned.
 func (sd *SchemaDefinition) SchemaElement() *parquet.SchemaElement {
@@ -123,3 +123,18 @@ func TestSubSchema(t *testing.T) {
 
 	require.Nil(t, schemaDef.SubSchema("does-not-exist"))
 }
+
+func TestSubSchemaByFieldID(t *testing.T) {
+	schema := `message foo {
+		required int64 bar = 1;
+		optional binary baz (STRING) = 7;
+		required int32 qux;
+	}`
+
+	schemaDef, err := ParseSchemaDefinition(schema)
+	require.NoError(t, err, "parsing schema definition failed")
+
+	require.Equal(t, "baz", schemaDef.SubSchemaByFieldID(7).SchemaElement().Name)
+
+	require.Nil(t, schemaDef.SubSchemaByFieldID(2))
+}
@@ -0,0 +1,669 @@
+package goparquet
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+
+	"github.com/fraugster/parquet-go/parquet"
+	"github.com/pkg/errors"
+)
+
+// Operator is a comparison operator that is used in column predicates.
+type Operator int
+
+// The comparison operators that can be used in column predicates.
+const (
+	Equal Operator = iota
+	NotEqual
+	LessThan
+	LessThanOrEqual
+	GreaterThan
+	GreaterThanOrEqual
+)
+
+func (op Operator) String() string {
+	switch op {
+	case Equal:
+		return "=="
+	case NotEqual:
+		return "!="
+	case LessThan:
+		return "<"
+	case LessThanOrEqual:
+		return "<="
+	case GreaterThan:
+		return ">"
+	case GreaterThanOrEqual:
+		return ">="
+	default:
+		return fmt.Sprintf("Operator(%d)", int(op))
+	}
+}
+
+// Predicate is a filter on column values. The FileReader uses predicates to skip
+// data that can't contain any matching rows, based on the statistics and indexes
+// stored in the parquet file. Please be aware that predicates only prune the data
+// that is read: rows that don't match the predicate can still be returned, since
+// the statistics only describe ranges of values. If you require exact results,
+// you still need to check every row that is returned.
+type Predicate interface {
+	// columns returns the names of all columns that are referenced by the predicate.
+	columns() []string
+
+	// evaluate returns the rows of the row group that might match the predicate,
+	// and the rows that definitely match the predicate.
+	evaluate(s *rowGroupStats) (maybe, all rowRanges, err error)
+}
+
+// ColumnPredicate returns a predicate that compares the values of a column with the provided
+// value. The column is identified by its full dotted-notation name and needs to be a data
+// column. The value needs to be convertible to the column's type: integer types can be used
+// for INT32 and INT64 columns, floating point types for FLOAT and DOUBLE columns, []byte or
+// string for BYTE_ARRAY and FIXED_LEN_BYTE_ARRAY columns, [12]byte for INT96 columns and bool
+// for BOOLEAN columns. Null values never match a column predicate.
+func ColumnPredicate(column string, op Operator, value interface{}) Predicate {
+	return &columnPredicate{
+		column: column,
+		op:     op,
+		value:  value,
+	}
+}
+
+type columnPredicate struct {
+	column string
+	op     Operator
+	value  interface{}
+}
+
+func (p *columnPredicate) columns() []string {
+	return []string{p.column}
+}
+
+func (p *columnPredicate) evaluate(s *rowGroupStats) (maybe, all rowRanges, err error) {
+	name := p.column
+	if c := s.projection.column(p.column); c != nil {
+		if _, err := convertPredicateValue(c.elem, p.value); err != nil {
+			return nil, nil, errors.Wrapf(err, "invalid value for predicate on column %q", p.column)
+		}
+		switch {
+		case c.filePath == "":
+			// the column is missing in the file, so all of its values are null.
+			return nil, nil, nil
+		case c.promoted:
+			// the statistics of the file can't be compared with a value of the promoted type.
+			return s.allRows(), nil, nil
+		}
+		name = c.filePath
+	}
+
+	col := s.schema.GetColumnByName(name)
+	if col == nil {
+		return nil, nil, errors.Errorf("predicate column %q not found", p.column)
+	}
+
+	value, err := convertPredicateValue(col.Element(), p.value)
+	if err != nil {
+		return nil, nil, errors.Wrapf(err, "invalid value for predicate on column %q", p.column)
+	}
+
+	parts, err := s.valueRanges(col)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if p.op == Equal && s.bloomFilter != nil && !isNaN(value) {
+		bf, err := s.bloomFilter(col)
+		if err != nil {
+			return nil, nil, err
+		}
+		if bf != nil && !bf.check(bloomFilterHash(value)) {
+			return nil, nil, nil
+		}
+	}
+
+	for _, part := range parts {
+		switch p.match(part.values, value) {
+		case allMatch:
+			all = all.union(rowRanges{part.rows})
+			maybe = maybe.union(rowRanges{part.rows})
+		case someMatch:
+			maybe = maybe.union(rowRanges{part.rows})
+		}
+	}
+
+	return maybe, all, nil
+}
+
+// match determines whether the values described by vr match the predicate.
+func (p *columnPredicate) match(vr valueRange, value interface{}) matchResult {
+	if vr.allNull {
+		return noMatch
+	}
+
+	// NaN can't be compared with the min and max values, and NaN values are not part of the
+	// statistics either.
+	if vr.min == nil || vr.max == nil || isNaN(value) {
+		return someMatch
+	}
+
+	minCmp, maxCmp := compareValues(vr.min, value), compareValues(vr.max, value)
+
+	// NaN values are not part of the statistics, so we can never be sure
+	// that all values of a floating point column match.
+	complete := vr.nullCount == 0 && !isFloat(value)
+
+	var none, every bool
+	switch p.op {
+	case Equal:
+		none = minCmp > 0 || maxCmp < 0
+		every = minCmp == 0 && maxCmp == 0
+	case NotEqual:
+		none = minCmp == 0 && maxCmp == 0
+		every = minCmp > 0 || maxCmp < 0
+	case LessThan:
+		none = minCmp >= 0
+		every = maxCmp < 0
+	case LessThanOrEqual:
+		none = minCmp > 0
+		every = maxCmp <= 0
+	case GreaterThan:
+		none = maxCmp <= 0
+		every = minCmp > 0
+	case GreaterThanOrEqual:
+		none = maxCmp < 0
+		every = minCmp >= 0
+	}
+
+	switch {
+	case none:
+		return noMatch
+	case every && complete:
+		return allMatch
+	default:
+		return someMatch
+	}
+}
+
+// And returns a predicate that matches all rows that are matched by all of the provided predicates.
+func And(predicates ...Predicate) Predicate {
+	return &andPredicate{predicates: predicates}
+}
+
+type andPredicate struct {
+	predicates []Predicate
+}
+
+func (p *andPredicate) columns() []string {
+	return predicateColumns(p.predicates)
+}
+
+func (p *andPredicate) evaluate(s *rowGroupStats) (maybe, all rowRanges, err error) {
+	maybe, all = s.allRows(), s.allRows()
+	for _, pred := range p.predicates {
+		m, a, err := pred.evaluate(s)
+		if err != nil {
+			return nil, nil, err
+		}
+		maybe, all = maybe.intersect(m), all.intersect(a)
+	}
+	return maybe, all, nil
+}
+
+// Or returns a predicate that matches all rows that are matched by at least one of the provided predicates.
+func Or(predicates ...Predicate) Predicate {
+	return &orPredicate{predicates: predicates}
+}
+
+type orPredicate struct {
+	predicates []Predicate
+}
+
+func (p *orPredicate) columns() []string {
+	return predicateColumns(p.predicates)
+}
+
+func (p *orPredicate) evaluate(s *rowGroupStats) (maybe, all rowRanges, err error) {
+	for _, pred := range p.predicates {
+		m, a, err := pred.evaluate(s)
+		if err != nil {
+			return nil, nil, err
+		}
+		maybe, all = maybe.union(m), all.union(a)
+	}
+	return maybe, all, nil
+}
+
+// Not returns a predicate that matches all rows that are not matched by the provided predicate. This
+// includes the rows where the predicate's columns are null.
+func Not(predicate Predicate) Predicate {
+	return &notPredicate{predicate: predicate}
+}
+
+type notPredicate struct {
+	predicate Predicate
+}
+
+func (p *notPredicate) columns() []string {
+	return p.predicate.columns()
+}
+
+func (p *notPredicate) evaluate(s *rowGroupStats) (maybe, all rowRanges, err error) {
+	m, a, err := p.predicate.evaluate(s)
+	if err != nil {
+		return nil, nil, err
+	}
+	return s.allRows().subtract(a), s.allRows().subtract(m), nil
+}
+
+func predicateColumns(predicates []Predicate) []string {
+	var columns []string
+	for _, p := range predicates {
+		columns = append(columns, p.columns()...)
+	}
+	return columns
+}
+
+func isFloat(v interface{}) bool {
+	switch v.(type) {
+	case float32, float64:
+		return true
+	default:
+		return false
+	}
+}
+
+// convertPredicateValue converts the value of a predicate to the Go type that is used
+// for values of the column described by elem.
+func convertPredicateValue(elem *parquet.SchemaElement, value interface{}) (interface{}, error) {
+	if value == nil {
+		return nil, errors.New("value is nil")
+	}
+
+	rv := reflect.ValueOf(value)
+	typ := elem.GetType()
+	switch typ {
+	case parquet.Type_BOOLEAN:
+		if b, ok := value.(bool); ok {
+			return b, nil
+		}
+	case parquet.Type_INT32, parquet.Type_INT64:
+		var (
+			i      int64
+			u      uint64
+			signed bool
+		)
+		switch rv.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			i, signed = rv.Int(), true
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			u = rv.Uint()
+		default:
+			return nil, errors.Errorf("can't use %T for %s column", value, typ)
+		}
+		return convertPredicateInt(typ, isUnsigned(elem), i, u, signed)
+	case parquet.Type_FLOAT:
+		switch rv.Kind() {
+		case reflect.Float32, reflect.Float64:
+			return float32(rv.Float()), nil
+		}
+	case parquet.Type_DOUBLE:
+		switch rv.Kind() {
+		case reflect.Float32, reflect.Float64:
+			return rv.Float(), nil
+		}
+	case parquet.Type_BYTE_ARRAY, parquet.Type_FIXED_LEN_BYTE_ARRAY:
+		switch v := value.(type) {
+		case []byte:
+			return v, nil
+		case string:
+			return []byte(v), nil
+		}
+	case parquet.Type_INT96:
+		if v, ok := value.([12]byte); ok {
+			return v, nil
+		}
+	}
+
+	return nil, errors.Errorf("can't use %T for %s column", value, typ)
+}
+
+func convertPredicateInt(typ parquet.Type, unsigned bool, i int64, u uint64, signed bool) (interface{}, error) {
+	if signed && i < 0 {
+		if unsigned {
+			return nil, errors.Errorf("negative value %d for unsigned column", i)
+		}
+	} else if signed {
+		u = uint64(i)
+	} else if u <= math.MaxInt64 {
+		i = int64(u)
+	} else if !unsigned {
+		return nil, errors.Errorf("value %d out of range", u)
+	}
+
+	switch {
+	case typ == parquet.Type_INT32 && unsigned:
+		if u > math.MaxUint32 {
+			return nil, errors.Errorf("value %d out of range", u)
+		}
+		return uint32(u), nil
+	case typ == parquet.Type_INT32:
+		if i > math.MaxInt32 || i < math.MinInt32 {
+			return nil, errors.Errorf("value %d out of range", i)
+		}
+		return int32(i), nil
+	case unsigned:
+		return u, nil
+	default:
+		return i, nil
+	}
+}
+
+type matchResult int
+
+const (
+	noMatch matchResult = iota
+	someMatch
+	allMatch
+)
+
+// valueRange describes the values of a column in a range of rows.
+type valueRange struct {
+	min, max  interface{} // nil if unknown
+	nullCount int64       // -1 if unknown
+	allNull   bool
+}
+
+// valueRangePart describes the values of a column within a range of rows of a row group.
+type valueRangePart struct {
+	rows   rowRange
+	values valueRange
+}
+
+// setMinMax decodes the min and max values. If the values can't be used to evaluate predicates,
+// min and max are left unset.
+func (vr *valueRange) setMinMax(elem *parquet.SchemaElement, minData, maxData []byte) error {
+	if !hasComparableStats(elem) {
+		return nil
+	}
+
+	min, err := decodeStatValue(elem, minData)
+	if err != nil {
+		return err
+	}
+	max, err := decodeStatValue(elem, maxData)
+	if err != nil {
+		return err
+	}
+
+	// Older writers like parquet-mr and Impala wrote NaN as min or max value of floating point
+	// columns, which can't be compared with other values.
+	if isNaN(min) || isNaN(max) {
+		return nil
+	}
+
+	// Older writers used the signed order for unsigned integers, which can result in min
+	// values that are greater than the max values.
+	if compareValues(min, max) > 0 {
+		return nil
+	}
+
+	vr.min, vr.max = min, max
+	return nil
+}
+
+// hasComparableStats returns true if the min and max values in the statistics of the column
+// described by elem are ordered in the way compareValues orders the decoded values.
+func hasComparableStats(elem *parquet.SchemaElement) bool {
+	switch elem.GetType() {
+	case parquet.Type_INT96:
+		return false
+	case parquet.Type_BYTE_ARRAY, parquet.Type_FIXED_LEN_BYTE_ARRAY:
+		if elem.ConvertedType != nil && (*elem.ConvertedType == parquet.ConvertedType_DECIMAL || *elem.ConvertedType == parquet.ConvertedType_INTERVAL) {
+			return false
+		}
+		return elem.LogicalType == nil || elem.LogicalType.DECIMAL == nil
+	default:
+		return true
+	}
+}
+
+// rowGroupStats provides access to the statistics and page indexes of a row group
+// to evaluate predicates.
+type rowGroupStats struct {
+	schema   SchemaReader
+	rowGroup *parquet.RowGroup
+
+	// the projection onto the target schema, if one is set. The predicates refer to the columns of
+	// the target schema then.
+	projection *schemaProjection
+
+	// column and offset indexes per column chunk, nil if they are not loaded or not available.
+	// If they are not available, the column chunk statistics are used instead.
+	columnIndexes []*parquet.ColumnIndex
+	offsetIndexes []*parquet.OffsetIndex
+
+	// bloomFilter returns the Bloom filter of the column chunk, or nil if there is none.
+	bloomFilter func(col *Column) (*bloomFilter, error)
+}
+
+func (s *rowGroupStats) allRows() rowRanges {
+	return rowRanges{{from: 0, to: s.rowGroup.NumRows}}
+}
+
+// valueRanges returns the value ranges of the provided column, as fine-grained as possible.
+func (s *rowGroupStats) valueRanges(col *Column) ([]valueRangePart, error) {
+	if s.columnIndexes != nil && s.offsetIndexes != nil {
+		ci, oi := s.columnIndexes[col.Index()], s.offsetIndexes[col.Index()]
+		if ci != nil && oi != nil {
+			return pageValueRanges(col, ci, oi, s.rowGroup.NumRows)
+		}
+	}
+
+	vr, err := chunkValueRange(col, s.rowGroup.Columns[col.Index()])
+	if err != nil {
+		return nil, err
+	}
+
+	return []valueRangePart{{
+		rows:   rowRange{from: 0, to: s.rowGroup.NumRows},
+		values: vr,
+	}}, nil
+}
+
+// chunkValueRange returns the value range of a column chunk as described by its statistics.
+func chunkValueRange(col *Column, chunk *parquet.ColumnChunk) (valueRange, error) {
+	vr := valueRange{nullCount: -1}
+	if chunk.MetaData == nil || chunk.MetaData.Statistics == nil {
+		return vr, nil
+	}
+
+	stats := chunk.MetaData.Statistics
+	if stats.NullCount != nil {
+		vr.nullCount = *stats.NullCount
+		vr.allNull = chunk.MetaData.NumValues > 0 && vr.nullCount == chunk.MetaData.NumValues
+	}
+
+	if vr.allNull {
+		return vr, nil
+	}
+
+	minData, maxData := stats.MinValue, stats.MaxValue
+	if minData == nil || maxData == nil {
+		// The deprecated min and max fields are only reliable for types with a signed sort order.
+		if !hasSignedStats(col.Element()) {
+			return vr, nil
+		}
+		minData, maxData = stats.Min, stats.Max
+	}
+
+	if minData == nil || maxData == nil {
+		return vr, nil
+	}
+
+	if err := vr.setMinMax(col.Element(), minData, maxData); err != nil {
+		return vr, errors.Wrapf(err, "invalid statistics for column %q", col.FlatName())
+	}
+
+	return vr, nil
+}
+
+// hasSignedStats returns true if the column described by elem uses a signed sort order for its statistics.
+func hasSignedStats(elem *parquet.SchemaElement) bool {
+	switch elem.GetType() {
+	case parquet.Type_BOOLEAN, parquet.Type_FLOAT, parquet.Type_DOUBLE:
+		return true
+	case parquet.Type_INT32, parquet.Type_INT64:
+		return !isUnsigned(elem)
+	default:
+		return false
+	}
+}
+
+// pageValueRanges returns the value ranges of all pages of a column chunk.
+func pageValueRanges(col *Column, ci *parquet.ColumnIndex, oi *parquet.OffsetIndex, numRows int64) ([]valueRangePart, error) {
+	numPages := len(oi.PageLocations)
+	if len(ci.NullPages) != numPages || len(ci.MinValues) != numPages || len(ci.MaxValues) != numPages {
+		return nil, errors.Errorf("column index of column %q doesn't match its offset index", col.FlatName())
+	}
+
+	parts := make([]valueRangePart, 0, numPages)
+	for i, loc := range oi.PageLocations {
+		part := valueRangePart{
+			rows: rowRange{from: loc.FirstRowIndex, to: numRows},
+			values: valueRange{
+				nullCount: -1,
+				allNull:   ci.NullPages[i],
+			},
+		}
+		if i+1 < numPages {
+			part.rows.to = oi.PageLocations[i+1].FirstRowIndex
+		}
+		if len(ci.NullCounts) == numPages {
+			part.values.nullCount = ci.NullCounts[i]
+		}
+		if !ci.NullPages[i] {
+			if err := part.values.setMinMax(col.Element(), ci.MinValues[i], ci.MaxValues[i]); err != nil {
+				return nil, errors.Wrapf(err, "invalid column index for column %q", col.FlatName())
+			}
+		}
+		parts = append(parts, part)
+	}
+
+	return parts, nil
+}
+
+// rowRange is a range of rows within a row group. from is inclusive, to is exclusive.
+type rowRange struct {
+	from, to int64
+}
+
+// rowRanges is a sorted list of non-overlapping row ranges.
+type rowRanges []rowRange
+
+// numRows returns the number of rows in all ranges.
+func (r rowRanges) numRows() int64 {
+	var n int64
+	for _, rr := range r {
+		n += rr.to - rr.from
+	}
+	return n
+}
+
+// contains returns true if the row is part of one of the ranges.
+func (r rowRanges) contains(row int64) bool {
+	for _, rr := range r {
+		if row < rr.from {
+			return false
+		}
+		if row < rr.to {
+			return true
+		}
+	}
+	return false
+}
+
+// overlaps returns true if any row of the provided range is part of one of the ranges.
+func (r rowRanges) overlaps(other rowRange) bool {
+	for _, rr := range r {
+		if rr.from < other.to && other.from < rr.to {
+			return true
+		}
+	}
+	return false
+}
+
+func (r rowRanges) union(other rowRanges) rowRanges {
+	var ret rowRanges
+	add := func(rr rowRange) {
+		if rr.from >= rr.to {
+			return
+		}
+		if n := len(ret); n > 0 && rr.from <= ret[n-1].to {
+			if rr.to > ret[n-1].to {
+				ret[n-1].to = rr.to
+			}
+			return
+		}
+		ret = append(ret, rr)
+	}
+
+	i, j := 0, 0
+	for i < len(r) || j < len(other) {
+		if j >= len(other) || (i < len(r) && r[i].from < other[j].from) {
+			add(r[i])
+			i++
+		} else {
+			add(other[j])
+			j++
+		}
+	}
+	return ret
+}
+
+func (r rowRanges) intersect(other rowRanges) rowRanges {
+	var ret rowRanges
+	i, j := 0, 0
+	for i < len(r) && j < len(other) {
+		from, to := r[i].from, r[i].to
+		if other[j].from > from {
+			from = other[j].from
+		}
+		if other[j].to < to {
+			to = other[j].to
+		}
+		if from < to {
+			ret = append(ret, rowRange{from: from, to: to})
+		}
+		if r[i].to < other[j].to {
+			i++
+		} else {
+			j++
+		}
+	}
+	return ret
+}
+
+// subtract returns the rows of r that are not part of other.
+func (r rowRanges) subtract(other rowRanges) rowRanges {
+	var ret rowRanges
+	j := 0
+	for _, rr := range r {
+		from := rr.from
+		for j < len(other) && other[j].to <= from {
+			j++
+		}
+		for k := j; k < len(other) && other[k].from < rr.to; k++ {
+			if other[k].from > from {
+				ret = append(ret, rowRange{from: from, to: other[k].from})
+			}
+			if other[k].to > from {
+				from = other[k].to
+			}
+		}
+		if from < rr.to {
+			ret = append(ret, rowRange{from: from, to: rr.to})
+		}
+	}
+	return ret
+}
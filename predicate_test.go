@@ -0,0 +1,394 @@
+package goparquet
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"testing"
+
+	"github.com/fraugster/parquet-go/parquet"
+	"github.com/fraugster/parquet-go/parquetschema"
+	"github.com/stretchr/testify/require"
+)
+
+func predicateTestSchema(t *testing.T) *parquetschema.SchemaDefinition {
+	sd, err := parquetschema.ParseSchemaDefinition(
+		`message test_msg {
+			required int64 foo;
+			optional binary bar (STRING);
+			repeated int32 baz;
+		}`)
+	require.NoError(t, err)
+	return sd
+}
+
+func writePredicateTestFile(t *testing.T, options ...FileWriterOption) []byte {
+	buf := &bytes.Buffer{}
+	w := NewFileWriter(buf, append([]FileWriterOption{WithSchemaDefinition(predicateTestSchema(t))}, options...)...)
+
+	for i := 0; i < 100; i++ {
+		require.NoError(t, w.AddData(predicateTestRow(i)))
+		if i == 49 {
+			require.NoError(t, w.FlushRowGroup())
+		}
+	}
+	require.NoError(t, w.Close())
+
+	return buf.Bytes()
+}
+
+func predicateTestRow(i int) map[string]interface{} {
+	data := map[string]interface{}{
+		"foo": int64(i),
+	}
+	if i%3 != 0 {
+		data["bar"] = []byte(fmt.Sprintf("value %d", i%7))
+	}
+	var baz []int32
+	for j := 0; j < i%4; j++ {
+		baz = append(baz, int32(i*10+j))
+	}
+	if len(baz) > 0 {
+		data["baz"] = baz
+	}
+	return data
+}
+
+func readAllRows(t *testing.T, r *FileReader) []map[string]interface{} {
+	var rows []map[string]interface{}
+	for {
+		row, err := r.NextRow()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		rows = append(rows, row)
+	}
+	return rows
+}
+
+func TestReadWithPredicate(t *testing.T) {
+	file := writePredicateTestFile(t, WithMaxPageRowCount(10), WithPageIndex())
+
+	tests := []struct {
+		name      string
+		predicate Predicate
+		columns   []string
+		expected  []int
+	}{
+		{"equal", ColumnPredicate("foo", Equal, 72), nil, []int{70, 71, 72, 73, 74, 75, 76, 77, 78, 79}},
+		{"less_than", ColumnPredicate("foo", LessThan, int64(12)), nil, []int{0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16, 17, 18, 19}},
+		{"greater_than_or_equal", ColumnPredicate("foo", GreaterThanOrEqual, uint8(99)), nil, []int{90, 91, 92, 93, 94, 95, 96, 97, 98, 99}},
+		{"not_equal", ColumnPredicate("foo", NotEqual, 5), nil, rowNumbers(0, 100)},
+		{"no_match", ColumnPredicate("foo", GreaterThan, 99), nil, nil},
+		{"other_column_selected", ColumnPredicate("foo", Equal, 31), []string{"baz"}, []int{30, 31, 32, 33, 34, 35, 36, 37, 38, 39}},
+		{"string_column", ColumnPredicate("bar", LessThan, "value 1"), nil, append(append(rowNumbers(0, 60), rowNumbers(70, 80)...), rowNumbers(90, 100)...)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, err := NewFileReaderWithOptions(bytes.NewReader(file), WithColumns(tt.columns...), WithPredicate(tt.predicate))
+			require.NoError(t, err)
+
+			rows := readAllRows(t, r)
+			require.Len(t, rows, len(tt.expected))
+			for i, row := range rows {
+				expected := predicateTestRow(tt.expected[i])
+				if len(tt.columns) > 0 {
+					for k := range expected {
+						if k != tt.columns[0] {
+							delete(expected, k)
+						}
+					}
+				}
+				require.Equal(t, expected, row)
+			}
+		})
+	}
+}
+
+func TestReadWithPredicateWithoutPageIndex(t *testing.T) {
+	file := writePredicateTestFile(t, WithMaxPageRowCount(10))
+
+	r, err := NewFileReaderWithOptions(bytes.NewReader(file), WithPredicate(ColumnPredicate("foo", Equal, 72)))
+	require.NoError(t, err)
+
+	// without page indexes, only the first row group can be skipped based on the column chunk statistics.
+	rows := readAllRows(t, r)
+	require.Len(t, rows, 50)
+	for i, row := range rows {
+		require.Equal(t, predicateTestRow(50+i), row)
+	}
+}
+
+func TestReadWithCombinedPredicates(t *testing.T) {
+	tests := []struct {
+		name      string
+		predicate Predicate
+		expected  []int
+	}{
+		{"and", And(ColumnPredicate("foo", GreaterThanOrEqual, 35), ColumnPredicate("foo", LessThan, 42)), rowNumbers(30, 50)},
+		{"or", Or(ColumnPredicate("foo", LessThan, 5), ColumnPredicate("foo", Equal, 95)), append(rowNumbers(0, 10), rowNumbers(90, 100)...)},
+		{"not", Not(ColumnPredicate("foo", LessThan, 70)), rowNumbers(70, 100)},
+		{"not_maybe", Not(ColumnPredicate("foo", LessThan, 75)), rowNumbers(70, 100)},
+		{"nested", And(Not(ColumnPredicate("foo", GreaterThanOrEqual, 20)), Or(ColumnPredicate("foo", Equal, 3), ColumnPredicate("foo", Equal, 200))), rowNumbers(0, 10)},
+		{"empty_and", And(), rowNumbers(0, 100)},
+		{"empty_or", Or(), nil},
+	}
+
+	file := writePredicateTestFile(t, WithMaxPageRowCount(10), WithPageIndex())
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, err := NewFileReaderWithOptions(bytes.NewReader(file), WithPredicate(tt.predicate))
+			require.NoError(t, err)
+
+			rows := readAllRows(t, r)
+			require.Len(t, rows, len(tt.expected))
+			for i, row := range rows {
+				require.Equal(t, predicateTestRow(tt.expected[i]), row)
+			}
+		})
+	}
+}
+
+func TestSkipRowGroupsWithPredicate(t *testing.T) {
+	file := writePredicateTestFile(t)
+
+	tests := []struct {
+		name      string
+		predicate Predicate
+		expected  []int
+	}{
+		{"first_row_group", ColumnPredicate("foo", LessThanOrEqual, 49), rowNumbers(0, 50)},
+		{"second_row_group", ColumnPredicate("foo", GreaterThan, 49), rowNumbers(50, 100)},
+		{"none", Or(ColumnPredicate("foo", LessThan, 0), ColumnPredicate("foo", GreaterThan, 99)), nil},
+		{"not", Not(ColumnPredicate("foo", GreaterThanOrEqual, 0)), nil},
+		{"binary", ColumnPredicate("bar", GreaterThan, "value 6"), nil},
+		{"repeated", ColumnPredicate("baz", Equal, 471), rowNumbers(0, 50)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, err := NewFileReaderWithOptions(bytes.NewReader(file), WithPredicate(tt.predicate))
+			require.NoError(t, err)
+
+			rows := readAllRows(t, r)
+			require.Len(t, rows, len(tt.expected))
+			for i, row := range rows {
+				require.Equal(t, predicateTestRow(tt.expected[i]), row)
+			}
+		})
+	}
+}
+
+func TestChunkValueRange(t *testing.T) {
+	int32Col := &Column{element: &parquet.SchemaElement{Type: parquet.TypePtr(parquet.Type_INT32)}}
+	uint32Col := &Column{element: &parquet.SchemaElement{Type: parquet.TypePtr(parquet.Type_INT32), ConvertedType: parquet.ConvertedTypePtr(parquet.ConvertedType_UINT_32)}}
+	doubleCol := &Column{element: &parquet.SchemaElement{Type: parquet.TypePtr(parquet.Type_DOUBLE)}}
+	decimalCol := &Column{element: &parquet.SchemaElement{Type: parquet.TypePtr(parquet.Type_FIXED_LEN_BYTE_ARRAY), ConvertedType: parquet.ConvertedTypePtr(parquet.ConvertedType_DECIMAL)}}
+
+	chunk := func(stats *parquet.Statistics) *parquet.ColumnChunk {
+		return &parquet.ColumnChunk{MetaData: &parquet.ColumnMetaData{NumValues: 10, Statistics: stats}}
+	}
+
+	vr, err := chunkValueRange(int32Col, chunk(nil))
+	require.NoError(t, err)
+	require.Equal(t, valueRange{nullCount: -1}, vr)
+
+	vr, err = chunkValueRange(int32Col, chunk(&parquet.Statistics{MinValue: []byte{0xff, 0xff, 0xff, 0xff}, MaxValue: []byte{5, 0, 0, 0}, NullCount: int64Ptr(2)}))
+	require.NoError(t, err)
+	require.Equal(t, valueRange{min: int32(-1), max: int32(5), nullCount: 2}, vr)
+
+	vr, err = chunkValueRange(int32Col, chunk(&parquet.Statistics{Min: []byte{1, 0, 0, 0}, Max: []byte{5, 0, 0, 0}}))
+	require.NoError(t, err)
+	require.Equal(t, valueRange{min: int32(1), max: int32(5), nullCount: -1}, vr)
+
+	vr, err = chunkValueRange(int32Col, chunk(&parquet.Statistics{NullCount: int64Ptr(10)}))
+	require.NoError(t, err)
+	require.Equal(t, valueRange{nullCount: 10, allNull: true}, vr)
+
+	// min and max in signed order are not usable for unsigned columns.
+	vr, err = chunkValueRange(uint32Col, chunk(&parquet.Statistics{MinValue: []byte{0xff, 0xff, 0xff, 0xff}, MaxValue: []byte{5, 0, 0, 0}}))
+	require.NoError(t, err)
+	require.Equal(t, valueRange{nullCount: -1}, vr)
+
+	vr, err = chunkValueRange(uint32Col, chunk(&parquet.Statistics{Min: []byte{1, 0, 0, 0}, Max: []byte{5, 0, 0, 0}}))
+	require.NoError(t, err)
+	require.Equal(t, valueRange{nullCount: -1}, vr)
+
+	vr, err = chunkValueRange(decimalCol, chunk(&parquet.Statistics{MinValue: []byte{0xff}, MaxValue: []byte{0x01}}))
+	require.NoError(t, err)
+	require.Equal(t, valueRange{nullCount: -1}, vr)
+
+	// NaN as min or max value, as written by older writers, can't be compared with other values.
+	nan := make([]byte, 8)
+	binary.LittleEndian.PutUint64(nan, math.Float64bits(math.NaN()))
+	one := make([]byte, 8)
+	binary.LittleEndian.PutUint64(one, math.Float64bits(1))
+	vr, err = chunkValueRange(doubleCol, chunk(&parquet.Statistics{MinValue: one, MaxValue: nan}))
+	require.NoError(t, err)
+	require.Equal(t, valueRange{nullCount: -1}, vr)
+	vr, err = chunkValueRange(doubleCol, chunk(&parquet.Statistics{MinValue: nan, MaxValue: one}))
+	require.NoError(t, err)
+	require.Equal(t, valueRange{nullCount: -1}, vr)
+	vr, err = chunkValueRange(doubleCol, chunk(&parquet.Statistics{MinValue: one, MaxValue: one}))
+	require.NoError(t, err)
+	require.Equal(t, valueRange{min: 1.0, max: 1.0, nullCount: -1}, vr)
+
+	_, err = chunkValueRange(int32Col, chunk(&parquet.Statistics{MinValue: []byte{1}, MaxValue: []byte{5, 0, 0, 0}}))
+	require.Error(t, err)
+}
+
+func TestReadWithInvalidPredicate(t *testing.T) {
+	file := writePredicateTestFile(t, WithPageIndex())
+
+	_, err := NewFileReaderWithOptions(bytes.NewReader(file), WithPredicate(ColumnPredicate("unknown", Equal, 1)))
+	require.Error(t, err)
+
+	r, err := NewFileReaderWithOptions(bytes.NewReader(file), WithPredicate(ColumnPredicate("foo", Equal, "foo")))
+	require.NoError(t, err)
+	_, err = r.NextRow()
+	require.Error(t, err)
+}
+
+func TestColumnPredicateMatch(t *testing.T) {
+	vr := valueRange{min: int64(10), max: int64(20)}
+	withNulls := valueRange{min: int64(10), max: int64(20), nullCount: 1}
+	single := valueRange{min: int64(10), max: int64(10)}
+
+	tests := []struct {
+		op       Operator
+		value    int64
+		vr       valueRange
+		expected matchResult
+	}{
+		{Equal, 5, vr, noMatch},
+		{Equal, 15, vr, someMatch},
+		{Equal, 10, single, allMatch},
+		{NotEqual, 10, single, noMatch},
+		{NotEqual, 25, vr, allMatch},
+		{NotEqual, 25, withNulls, someMatch},
+		{LessThan, 10, vr, noMatch},
+		{LessThan, 21, vr, allMatch},
+		{LessThanOrEqual, 9, vr, noMatch},
+		{LessThanOrEqual, 20, vr, allMatch},
+		{GreaterThan, 20, vr, noMatch},
+		{GreaterThan, 9, vr, allMatch},
+		{GreaterThan, 9, withNulls, someMatch},
+		{GreaterThanOrEqual, 21, vr, noMatch},
+		{GreaterThanOrEqual, 10, vr, allMatch},
+		{GreaterThanOrEqual, 15, vr, someMatch},
+		{Equal, 15, valueRange{allNull: true}, noMatch},
+		{Equal, 15, valueRange{nullCount: -1}, someMatch},
+	}
+
+	for _, tt := range tests {
+		p := &columnPredicate{op: tt.op}
+		require.Equal(t, tt.expected, p.match(tt.vr, tt.value), "%d %s %v", tt.value, tt.op, tt.vr)
+	}
+
+	p := &columnPredicate{op: LessThan}
+	require.Equal(t, someMatch, p.match(valueRange{min: 1.0, max: 2.0}, 3.0))
+
+	// NaN can't be compared with the min and max values.
+	for _, op := range []Operator{Equal, NotEqual, LessThan, LessThanOrEqual, GreaterThan, GreaterThanOrEqual} {
+		p := &columnPredicate{op: op}
+		require.Equal(t, someMatch, p.match(valueRange{min: 1.0, max: 2.0}, math.NaN()), "%s", op)
+	}
+}
+
+func TestReadWithNaNPredicate(t *testing.T) {
+	sd, err := parquetschema.ParseSchemaDefinition(`message test {
+		required double f;
+	}`)
+	require.NoError(t, err)
+
+	buf := &bytes.Buffer{}
+	w := NewFileWriter(buf, WithSchemaDefinition(sd), WithPageIndex())
+	for _, f := range []float64{1, 2, 3} {
+		require.NoError(t, w.AddData(map[string]interface{}{"f": f}))
+	}
+	require.NoError(t, w.Close())
+
+	for _, op := range []Operator{Equal, NotEqual, LessThan, GreaterThan} {
+		r, err := NewFileReaderWithOptions(bytes.NewReader(buf.Bytes()), WithPredicate(ColumnPredicate("f", op, math.NaN())))
+		require.NoError(t, err)
+		require.Len(t, readAllRows(t, r), 3, "%s", op)
+	}
+}
+
+func TestConvertPredicateValue(t *testing.T) {
+	int32Elem := &parquet.SchemaElement{Type: parquet.TypePtr(parquet.Type_INT32)}
+	uint32Elem := &parquet.SchemaElement{Type: parquet.TypePtr(parquet.Type_INT32), ConvertedType: parquet.ConvertedTypePtr(parquet.ConvertedType_UINT_32)}
+	int64Elem := &parquet.SchemaElement{Type: parquet.TypePtr(parquet.Type_INT64)}
+	floatElem := &parquet.SchemaElement{Type: parquet.TypePtr(parquet.Type_FLOAT)}
+	binaryElem := &parquet.SchemaElement{Type: parquet.TypePtr(parquet.Type_BYTE_ARRAY)}
+
+	v, err := convertPredicateValue(int32Elem, 42)
+	require.NoError(t, err)
+	require.Equal(t, int32(42), v)
+
+	v, err = convertPredicateValue(uint32Elem, int64(42))
+	require.NoError(t, err)
+	require.Equal(t, uint32(42), v)
+
+	v, err = convertPredicateValue(int64Elem, uint16(42))
+	require.NoError(t, err)
+	require.Equal(t, int64(42), v)
+
+	v, err = convertPredicateValue(floatElem, 1.5)
+	require.NoError(t, err)
+	require.Equal(t, float32(1.5), v)
+
+	v, err = convertPredicateValue(binaryElem, "foo")
+	require.NoError(t, err)
+	require.Equal(t, []byte("foo"), v)
+
+	_, err = convertPredicateValue(int32Elem, int64(1<<40))
+	require.Error(t, err)
+
+	_, err = convertPredicateValue(uint32Elem, -1)
+	require.Error(t, err)
+
+	_, err = convertPredicateValue(int64Elem, "foo")
+	require.Error(t, err)
+
+	_, err = convertPredicateValue(int64Elem, nil)
+	require.Error(t, err)
+}
+
+func TestRowRanges(t *testing.T) {
+	a := rowRanges{{0, 10}, {20, 30}}
+	b := rowRanges{{5, 25}, {40, 50}}
+
+	require.Equal(t, rowRanges{{0, 30}, {40, 50}}, a.union(b))
+	require.Equal(t, rowRanges{{5, 10}, {20, 25}}, a.intersect(b))
+	require.Nil(t, a.intersect(nil))
+	require.Equal(t, a, a.union(nil))
+	require.Equal(t, int64(20), a.numRows())
+
+	require.True(t, a.contains(0))
+	require.False(t, a.contains(10))
+	require.True(t, a.contains(29))
+	require.False(t, a.contains(30))
+
+	require.True(t, a.overlaps(rowRange{9, 20}))
+	require.False(t, a.overlaps(rowRange{10, 20}))
+
+	require.Equal(t, rowRanges{{0, 5}, {25, 30}}, a.subtract(b))
+	require.Equal(t, rowRanges{{10, 20}, {40, 50}}, b.subtract(a))
+	require.Equal(t, a, a.subtract(nil))
+	require.Nil(t, a.subtract(a))
+	require.Equal(t, rowRanges{{0, 2}, {3, 5}, {8, 10}}, rowRanges{{0, 10}}.subtract(rowRanges{{2, 3}, {5, 8}}))
+}
+
+func rowNumbers(from, to int) []int {
+	var ret []int
+	for i := from; i < to; i++ {
+		ret = append(ret, i)
+	}
+	return ret
+}
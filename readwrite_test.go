@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"strings"
 	"testing"
 	"time"
 
@@ -165,7 +166,8 @@ func TestWriteThenReadFileOptional(t *testing.T) {
 	root := r.SchemaReader.(*schema).root
 	for i := range data {
 		_, ok := data[i]["foo"]
-		rL, dL, b := root.getFirstRDLevel()
+		rL, dL, b, err := root.getFirstRDLevel()
+		require.NoError(t, err)
 		if ok {
 			assert.False(t, b)
 			assert.Equal(t, int32(0), rL)
@@ -863,6 +865,363 @@ func TestReadWriteColumeEncodings(t *testing.T) {
 	require.Equal(t, io.EOF, err)
 }
 
+func TestReadWriteByteStreamSplit(t *testing.T) {
+	buf := &bytes.Buffer{}
+
+	w := NewFileWriter(buf, WithCompressionCodec(parquet.CompressionCodec_GZIP), WithMaxPageRowCount(100))
+
+	s, err := NewFloatStore(parquet.Encoding_BYTE_STREAM_SPLIT, false, &ColumnParameters{})
+	require.NoError(t, err)
+	require.NoError(t, w.AddColumn("f", NewDataColumn(s, parquet.FieldRepetitionType_REQUIRED)))
+
+	s, err = NewDoubleStore(parquet.Encoding_BYTE_STREAM_SPLIT, false, &ColumnParameters{})
+	require.NoError(t, err)
+	require.NoError(t, w.AddColumn("d", NewDataColumn(s, parquet.FieldRepetitionType_OPTIONAL)))
+
+	_, err = NewInt64Store(parquet.Encoding_BYTE_STREAM_SPLIT, false, &ColumnParameters{})
+	require.Error(t, err)
+
+	var testData []map[string]interface{}
+	for i := 0; i < 1000; i++ {
+		data := map[string]interface{}{"f": float32(i) / 3}
+		if i%4 != 0 {
+			data["d"] = float64(i) * 1.5
+		}
+		testData = append(testData, data)
+		require.NoError(t, w.AddData(data))
+	}
+	require.NoError(t, w.Close())
+
+	r, err := NewFileReader(bytes.NewReader(buf.Bytes()))
+	require.NoError(t, err)
+
+	for _, chunk := range r.meta.RowGroups[0].Columns {
+		require.Contains(t, chunk.MetaData.Encodings, parquet.Encoding_BYTE_STREAM_SPLIT)
+	}
+
+	for i := range testData {
+		data, err := r.NextRow()
+		require.NoError(t, err)
+		require.Equal(t, testData[i], data)
+	}
+
+	_, err = r.NextRow()
+	require.Equal(t, io.EOF, err)
+}
+
+func TestWriteMultiplePagesThenRead(t *testing.T) {
+	sd, err := parquetschema.ParseSchemaDefinition(
+		`message test_msg {
+			required int64 foo;
+			optional binary bar (STRING);
+			repeated int32 baz;
+		}`)
+	require.NoError(t, err)
+
+	testFunc := func(opts ...FileWriterOption) map[string]int {
+		buf := &bytes.Buffer{}
+		w := NewFileWriter(buf, append([]FileWriterOption{WithSchemaDefinition(sd)}, opts...)...)
+
+		var testData []map[string]interface{}
+		for i := 0; i < 1000; i++ {
+			data := map[string]interface{}{
+				"foo": int64(i),
+				"baz": []int32{int32(i), int32(i % 7)},
+			}
+			if i%3 != 0 {
+				data["bar"] = []byte(fmt.Sprintf("value%d", i%10))
+			}
+			testData = append(testData, data)
+			require.NoError(t, w.AddData(data))
+		}
+		require.NoError(t, w.Close())
+
+		r, err := NewFileReader(bytes.NewReader(buf.Bytes()))
+		require.NoError(t, err)
+
+		numPages := make(map[string]int)
+		for _, chunk := range r.meta.RowGroups[0].Columns {
+			numPages[strings.Join(chunk.MetaData.PathInSchema, ".")] = len(readDataPageHeaders(t, buf.Bytes(), chunk))
+		}
+
+		for i := range testData {
+			data, err := r.NextRow()
+			require.NoError(t, err)
+			require.Equal(t, testData[i], data, "%d. row doesn't match", i)
+		}
+
+		_, err = r.NextRow()
+		require.Equal(t, io.EOF, err)
+
+		return numPages
+	}
+
+	require.Equal(t, map[string]int{"foo": 1, "bar": 1, "baz": 1}, testFunc())
+	require.Equal(t, map[string]int{"foo": 10, "bar": 10, "baz": 10}, testFunc(WithMaxPageRowCount(100)))
+	require.Equal(t, map[string]int{"foo": 10, "bar": 10, "baz": 10}, testFunc(WithMaxPageRowCount(100), WithDataPageV2(), WithCompressionCodec(parquet.CompressionCodec_SNAPPY)))
+	require.Equal(t, map[string]int{"foo": 4, "bar": 4, "baz": 4}, testFunc(WithMaxPageRowCount(300), WithMaxPageSize(1<<20)))
+	// foo and baz contain 8 bytes of values per row, bar contains less than that.
+	require.Equal(t, map[string]int{"foo": 20, "bar": 10, "baz": 20}, testFunc(WithMaxPageSize(400)))
+}
+
+// readDataPageHeaders returns the headers of all data pages in the provided column chunk.
+func readDataPageHeaders(t *testing.T, file []byte, chunk *parquet.ColumnChunk) []*parquet.PageHeader {
+	offset := chunk.MetaData.DataPageOffset
+	if chunk.MetaData.DictionaryPageOffset != nil {
+		offset = *chunk.MetaData.DictionaryPageOffset
+	}
+
+	r := bytes.NewReader(file[offset : offset+chunk.MetaData.TotalCompressedSize])
+	var headers []*parquet.PageHeader
+	for r.Len() > 0 {
+		ph := &parquet.PageHeader{}
+		require.NoError(t, readThrift(ph, r))
+		_, err := r.Seek(int64(ph.CompressedPageSize), io.SeekCurrent)
+		require.NoError(t, err)
+		if ph.Type != parquet.PageType_DICTIONARY_PAGE {
+			headers = append(headers, ph)
+		}
+	}
+	return headers
+}
+
 func strPtr(s string) *string {
 	return &s
 }
+
+func TestWriteWithEncodingConcurrency(t *testing.T) {
+	tests := [][]FileWriterOption{
+		nil,
+		{WithCompressionCodec(parquet.CompressionCodec_GZIP)},
+		{WithMaxPageRowCount(10), WithPageIndex(), WithBloomFilter("foo", 0, 0)},
+		{WithMaxPageRowCount(10), WithDataPageV2(), WithCompressionCodec(parquet.CompressionCodec_SNAPPY)},
+	}
+
+	for _, opts := range tests {
+		expected := writePredicateTestFile(t, opts...)
+		for _, n := range []int{1, 2, 3, 8} {
+			file := writePredicateTestFile(t, append(opts, WithEncodingConcurrency(n))...)
+			require.Equal(t, expected, file, "concurrency %d", n)
+		}
+
+		r, err := NewFileReader(bytes.NewReader(expected))
+		require.NoError(t, err)
+		rows := readAllRows(t, r)
+		require.Len(t, rows, 100)
+		for i := range rows {
+			require.Equal(t, predicateTestRow(i), rows[i])
+		}
+	}
+}
+
+func TestWriteDictionaryFallback(t *testing.T) {
+	sd, err := parquetschema.ParseSchemaDefinition(
+		`message test_msg {
+			required int64 foo;
+			required binary bar (STRING);
+		}`)
+	require.NoError(t, err)
+
+	for _, v2 := range []bool{false, true} {
+		opts := []FileWriterOption{
+			WithSchemaDefinition(sd),
+			WithMaxPageRowCount(100),
+			WithMaxDictionarySize(2000),
+			WithColumnMaxDictionarySize("foo", 100),
+		}
+		dataPageType := parquet.PageType_DATA_PAGE
+		if v2 {
+			opts = append(opts, WithDataPageV2())
+			dataPageType = parquet.PageType_DATA_PAGE_V2
+		}
+
+		buf := &bytes.Buffer{}
+		w := NewFileWriter(buf, opts...)
+
+		var testData []map[string]interface{}
+		for i := 0; i < 1000; i++ {
+			data := map[string]interface{}{
+				"foo": int64(i % 50),
+				"bar": []byte(fmt.Sprintf("value%03d", i%500)),
+			}
+			testData = append(testData, data)
+			require.NoError(t, w.AddData(data))
+		}
+		require.NoError(t, w.Close())
+
+		r, err := NewFileReader(bytes.NewReader(buf.Bytes()))
+		require.NoError(t, err)
+
+		// every page adds 50 values of 8 bytes to the dictionary of foo, so it never uses a dictionary.
+		foo := r.meta.RowGroups[0].Columns[0]
+		require.Nil(t, foo.MetaData.DictionaryPageOffset)
+		require.Equal(t, []parquet.Encoding{parquet.Encoding_RLE, parquet.Encoding_PLAIN}, foo.MetaData.Encodings)
+		require.Equal(t, []*parquet.PageEncodingStats{
+			{PageType: dataPageType, Encoding: parquet.Encoding_PLAIN, Count: 10},
+		}, foo.MetaData.EncodingStats)
+
+		// every page adds 100 values of 8 bytes to the dictionary of bar, so it fits the values of two pages.
+		bar := r.meta.RowGroups[0].Columns[1]
+		require.NotNil(t, bar.MetaData.DictionaryPageOffset)
+		require.Equal(t, []parquet.Encoding{parquet.Encoding_RLE, parquet.Encoding_PLAIN, parquet.Encoding_RLE_DICTIONARY}, bar.MetaData.Encodings)
+		require.Equal(t, []*parquet.PageEncodingStats{
+			{PageType: parquet.PageType_DICTIONARY_PAGE, Encoding: parquet.Encoding_PLAIN, Count: 1},
+			{PageType: dataPageType, Encoding: parquet.Encoding_RLE_DICTIONARY, Count: 2},
+			{PageType: dataPageType, Encoding: parquet.Encoding_PLAIN, Count: 8},
+		}, bar.MetaData.EncodingStats)
+
+		dictHeader := &parquet.PageHeader{}
+		require.NoError(t, readThrift(dictHeader, bytes.NewReader(buf.Bytes()[*bar.MetaData.DictionaryPageOffset:])))
+		require.Equal(t, int32(200), dictHeader.DictionaryPageHeader.NumValues)
+
+		for i, ph := range readDataPageHeaders(t, buf.Bytes(), bar) {
+			var enc parquet.Encoding
+			if v2 {
+				enc = ph.GetDataPageHeaderV2().GetEncoding()
+			} else {
+				enc = ph.GetDataPageHeader().GetEncoding()
+			}
+			if i < 2 {
+				require.Equal(t, parquet.Encoding_RLE_DICTIONARY, enc)
+			} else {
+				require.Equal(t, parquet.Encoding_PLAIN, enc)
+			}
+		}
+
+		for i := range testData {
+			data, err := r.NextRow()
+			require.NoError(t, err)
+			require.Equal(t, testData[i], data)
+		}
+		_, err = r.NextRow()
+		require.Equal(t, io.EOF, err)
+	}
+}
+
+func TestWriteColumnOptions(t *testing.T) {
+	sd, err := parquetschema.ParseSchemaDefinition(
+		`message test_msg {
+			required group a {
+				required int64 b;
+			}
+			optional binary c (STRING);
+			required double d;
+		}`)
+	require.NoError(t, err)
+
+	buf := &bytes.Buffer{}
+	w := NewFileWriter(buf,
+		WithColumnEncoding("a.b", parquet.Encoding_DELTA_BINARY_PACKED),
+		WithSchemaDefinition(sd),
+		WithColumnDictionary("a.b", false),
+		WithCompressionCodec(parquet.CompressionCodec_SNAPPY),
+		WithColumnCompression("c", parquet.CompressionCodec_GZIP),
+		WithColumnEncoding("d", parquet.Encoding_BYTE_STREAM_SPLIT),
+		WithColumnDictionary("d", false),
+	)
+
+	var testData []map[string]interface{}
+	for i := 0; i < 100; i++ {
+		data := map[string]interface{}{
+			"a": map[string]interface{}{"b": int64(i % 10)},
+			"d": float64(i) / 4,
+		}
+		if i%3 != 0 {
+			data["c"] = []byte(fmt.Sprintf("value%d", i%5))
+		}
+		testData = append(testData, data)
+		require.NoError(t, w.AddData(data))
+	}
+	require.NoError(t, w.Close())
+
+	r, err := NewFileReader(bytes.NewReader(buf.Bytes()))
+	require.NoError(t, err)
+
+	chunks := r.meta.RowGroups[0].Columns
+	require.Len(t, chunks, 3)
+
+	require.Equal(t, parquet.CompressionCodec_SNAPPY, chunks[0].MetaData.Codec)
+	require.Equal(t, []parquet.Encoding{parquet.Encoding_RLE, parquet.Encoding_DELTA_BINARY_PACKED}, chunks[0].MetaData.Encodings)
+
+	require.Equal(t, parquet.CompressionCodec_GZIP, chunks[1].MetaData.Codec)
+	require.Equal(t, []parquet.Encoding{parquet.Encoding_RLE, parquet.Encoding_PLAIN, parquet.Encoding_RLE_DICTIONARY}, chunks[1].MetaData.Encodings)
+
+	require.Equal(t, parquet.CompressionCodec_SNAPPY, chunks[2].MetaData.Codec)
+	require.Equal(t, []parquet.Encoding{parquet.Encoding_RLE, parquet.Encoding_BYTE_STREAM_SPLIT}, chunks[2].MetaData.Encodings)
+
+	for i := range testData {
+		data, err := r.NextRow()
+		require.NoError(t, err)
+		require.Equal(t, testData[i], data)
+	}
+	_, err = r.NextRow()
+	require.Equal(t, io.EOF, err)
+
+	w = NewFileWriter(&bytes.Buffer{}, WithColumnEncoding("c", parquet.Encoding_DELTA_BINARY_PACKED))
+	require.Error(t, w.SetSchemaDefinition(sd))
+
+	w = NewFileWriter(&bytes.Buffer{}, WithColumnDictionary("a", false))
+	require.Error(t, w.SetSchemaDefinition(sd))
+
+	_, err = NewFileWriterWithError(&bytes.Buffer{}, WithSchemaDefinition(sd), WithColumnEncoding("does.not.exist", parquet.Encoding_PLAIN))
+	require.EqualError(t, err, `column "does.not.exist" from the column options not found in schema definition`)
+
+	_, err = NewFileWriterWithError(&bytes.Buffer{}, WithSchemaDefinition(sd), WithColumnCompression("a.c", parquet.CompressionCodec_GZIP))
+	require.EqualError(t, err, `column "a.c" from the column options not found in schema definition`)
+
+	_, err = NewFileWriterWithError(&bytes.Buffer{}, WithSchemaDefinition(sd), WithColumnMaxDictionarySize("e", 10))
+	require.EqualError(t, err, `column "e" from the column options not found in schema definition`)
+
+	// NewFileWriter returns the error on the first write.
+	w = NewFileWriter(&bytes.Buffer{}, WithSchemaDefinition(sd), WithColumnCompression("e", parquet.CompressionCodec_GZIP))
+	require.EqualError(t, w.AddData(testData[0]), `column "e" from the column options not found in schema definition`)
+	require.EqualError(t, w.Close(), `column "e" from the column options not found in schema definition`)
+}
+
+func TestWriteDeltaBinaryPacked(t *testing.T) {
+	sd, err := parquetschema.ParseSchemaDefinition(
+		`message test_msg {
+			required int32 a;
+			required int32 b (INT(32, false));
+			optional int64 c;
+			required int64 d (INT(64, false));
+		}`)
+	require.NoError(t, err)
+
+	var options []FileWriterOption
+	for _, col := range []string{"a", "b", "c", "d"} {
+		options = append(options, WithColumnEncoding(col, parquet.Encoding_DELTA_BINARY_PACKED), WithColumnDictionary(col, false))
+	}
+	buf := &bytes.Buffer{}
+	w, err := NewFileWriterWithError(buf, append(options, WithSchemaDefinition(sd))...)
+	require.NoError(t, err)
+
+	// enough values for several blocks, with deltas of different bit widths.
+	var testData []map[string]interface{}
+	for i := 0; i < 1000; i++ {
+		data := map[string]interface{}{
+			"a": int32(i*i) - 5000,
+			"b": uint32(i * 7),
+			"d": uint64(i) << 40,
+		}
+		if i%7 != 0 {
+			data["c"] = -int64(i) * int64(i%13)
+		}
+		testData = append(testData, data)
+		require.NoError(t, w.AddData(data))
+	}
+	require.NoError(t, w.Close())
+
+	r, err := NewFileReader(bytes.NewReader(buf.Bytes()))
+	require.NoError(t, err)
+	for _, chunk := range r.meta.RowGroups[0].Columns {
+		require.Equal(t, []parquet.Encoding{parquet.Encoding_RLE, parquet.Encoding_DELTA_BINARY_PACKED}, chunk.MetaData.Encodings)
+	}
+	for i := range testData {
+		data, err := r.NextRow()
+		require.NoError(t, err)
+		require.Equal(t, testData[i], data)
+	}
+	_, err = r.NextRow()
+	require.Equal(t, io.EOF, err)
+}
@@ -211,25 +211,25 @@ func (c *Column) getNextData() (map[string]interface{}, int32, error) {
 	return ret, int32(c.maxD), nil
 }
 
-func (c *Column) getFirstRDLevel() (int32, int32, bool) {
+func (c *Column) getFirstRDLevel() (int32, int32, bool, error) {
 	if c.data != nil {
 		return c.data.getRDLevelAt(-1)
 	}
 
 	// there should be at lease 1 child,
 	for i := range c.children {
-		rl, dl, last := c.children[i].getFirstRDLevel()
-		if last {
-			return rl, dl, last
+		rl, dl, last, err := c.children[i].getFirstRDLevel()
+		if err != nil || last {
+			return rl, dl, last, err
 		}
 
 		// if this value is not nil, dLevel less than this level is not interesting
 		if dl == int32(c.children[i].maxD) {
-			return rl, dl, last
+			return rl, dl, last, nil
 		}
 	}
 
-	return -1, -1, false
+	return -1, -1, false, nil
 }
 
 func (c *Column) getData() (interface{}, int32, error) {
@@ -245,7 +245,10 @@ func (c *Column) getData() (interface{}, int32, error) {
 
 		ret := []map[string]interface{}{data}
 		for {
-			rl, _, last := c.getFirstRDLevel()
+			rl, _, last, err := c.getFirstRDLevel()
+			if err != nil {
+				return nil, maxD, err
+			}
 			if last || rl < int32(c.maxR) || rl == 0 {
 				// end of this object
 				return ret, maxD, nil
@@ -398,6 +401,7 @@ func (r *schema) SetSchemaDefinition(sd *parquetschema.SchemaDefinition) error {
 	for _, c := range r.root.children {
 		recursiveFix(c, "", 0, 0)
 	}
+	r.sortIndex()
 
 	return nil
 }
@@ -440,6 +444,12 @@ func createColumnFromColumnDefinition(root *parquetschema.ColumnDefinition) (*Co
 }
 
 func getColumnStore(elem *parquet.SchemaElement, params *ColumnParameters) (*ColumnStore, error) {
+	return newColumnStore(elem, params, parquet.Encoding_PLAIN, true)
+}
+
+// newColumnStore creates the column store for the type of the schema element, using the provided
+// encoding. allowDict is ignored for boolean columns, which never use a dictionary.
+func newColumnStore(elem *parquet.SchemaElement, params *ColumnParameters, enc parquet.Encoding, allowDict bool) (*ColumnStore, error) {
 	if elem.Type == nil {
 		return nil, nil
 	}
@@ -453,21 +463,21 @@ func getColumnStore(elem *parquet.SchemaElement, params *ColumnParameters) (*Col
 
 	switch typ {
 	case parquet.Type_BYTE_ARRAY:
-		colStore, err = NewByteArrayStore(parquet.Encoding_PLAIN, true, params)
+		colStore, err = NewByteArrayStore(enc, allowDict, params)
 	case parquet.Type_FLOAT:
-		colStore, err = NewFloatStore(parquet.Encoding_PLAIN, true, params)
+		colStore, err = NewFloatStore(enc, allowDict, params)
 	case parquet.Type_DOUBLE:
-		colStore, err = NewDoubleStore(parquet.Encoding_PLAIN, true, params)
+		colStore, err = NewDoubleStore(enc, allowDict, params)
 	case parquet.Type_BOOLEAN:
-		colStore, err = NewBooleanStore(parquet.Encoding_PLAIN, params)
+		colStore, err = NewBooleanStore(enc, params)
 	case parquet.Type_INT32:
-		colStore, err = NewInt32Store(parquet.Encoding_PLAIN, true, params)
+		colStore, err = NewInt32Store(enc, allowDict, params)
 	case parquet.Type_INT64:
-		colStore, err = NewInt64Store(parquet.Encoding_PLAIN, true, params)
+		colStore, err = NewInt64Store(enc, allowDict, params)
 	case parquet.Type_INT96:
-		colStore, err = NewInt96Store(parquet.Encoding_PLAIN, true, params)
+		colStore, err = NewInt96Store(enc, allowDict, params)
 	case parquet.Type_FIXED_LEN_BYTE_ARRAY:
-		colStore, err = NewFixedByteArrayStore(parquet.Encoding_PLAIN, true, params)
+		colStore, err = NewFixedByteArrayStore(enc, allowDict, params)
 	default:
 		return nil, fmt.Errorf("unsupported type %q when creating Column store", typ.String())
 	}
@@ -0,0 +1,345 @@
+package goparquet
+
+import (
+	"strings"
+
+	"github.com/fraugster/parquet-go/parquet"
+	"github.com/fraugster/parquet-go/parquetschema"
+	"github.com/pkg/errors"
+)
+
+// schemaProjection maps the rows read from a file onto a target schema.
+type schemaProjection struct {
+	schemaDef *parquetschema.SchemaDefinition
+	fields    []*projectedField
+	// match the columns by their field IDs instead of their names.
+	fieldIDs bool
+	// the columns of the file that are part of the target schema.
+	columns []*projectedColumn
+	// all primitive columns of the target schema by their flat names, including the ones that are
+	// missing in the file.
+	targetColumns map[string]*projectedColumn
+}
+
+// projectedColumn is a primitive column of the target schema.
+type projectedColumn struct {
+	// the flat names of the column in the target schema and in the file. filePath is empty if the
+	// column is missing in the file.
+	targetPath, filePath string
+	elem                 *parquet.SchemaElement
+	// the values of the file are converted to the type of the target schema.
+	promoted bool
+}
+
+// projectedField is a field of the target schema that is also present in the file.
+type projectedField struct {
+	name     string
+	fileName string
+	repeated bool
+	required bool
+	group    bool
+	// the fields of a group, or the conversion of the values of a primitive column, nil if the
+	// values are used as they are.
+	children []*projectedField
+	convert  func(v interface{}) interface{}
+}
+
+// newSchemaProjection reconciles the target schema with the schema of a file. Columns of the file that
+// are missing in the target schema are dropped, optional and repeated columns of the target schema
+// that are missing in the file are null, and INT32 columns are promoted to INT64 or DOUBLE and FLOAT
+// columns to DOUBLE. All other differences between the schemas result in an error. If fieldIDs is
+// true, the columns of the target schema that have a field ID are matched by it instead of their name.
+func newSchemaProjection(target, file *parquetschema.SchemaDefinition, fieldIDs bool) (*schemaProjection, error) {
+	if err := target.Validate(); err != nil {
+		return nil, errors.Wrap(err, "invalid target schema definition")
+	}
+
+	p := &schemaProjection{
+		schemaDef:     target,
+		fieldIDs:      fieldIDs,
+		targetColumns: make(map[string]*projectedColumn),
+	}
+	fields, err := p.projectGroup(target.RootColumn.Children, file.RootColumn.Children, "", "")
+	if err != nil {
+		return nil, err
+	}
+	p.fields = fields
+
+	return p, nil
+}
+
+func (p *schemaProjection) projectGroup(targetCols, fileCols []*parquetschema.ColumnDefinition, targetPath, filePath string) ([]*projectedField, error) {
+	var fields []*projectedField
+	for _, target := range targetCols {
+		name := target.SchemaElement.Name
+		path := joinPath(targetPath, name)
+
+		file := p.matchColumn(target, fileCols)
+		if file == nil {
+			if target.SchemaElement.GetRepetitionType() == parquet.FieldRepetitionType_REQUIRED {
+				return nil, errors.Errorf("column %q is required in the target schema, but missing in the file", path)
+			}
+			p.addMissingColumns(target, path)
+			continue
+		}
+
+		field, err := p.projectColumn(target, file, path, joinPath(filePath, file.SchemaElement.Name))
+		if err != nil {
+			return nil, err
+		}
+		if field != nil {
+			fields = append(fields, field)
+		}
+	}
+
+	return fields, nil
+}
+
+// addMissingColumns adds the primitive columns of the target schema at and below col, which is missing
+// in the file.
+func (p *schemaProjection) addMissingColumns(col *parquetschema.ColumnDefinition, path string) {
+	if col.SchemaElement.Type != nil {
+		p.targetColumns[path] = &projectedColumn{targetPath: path, elem: col.SchemaElement}
+		return
+	}
+	for _, c := range col.Children {
+		p.addMissingColumns(c, joinPath(path, c.SchemaElement.Name))
+	}
+}
+
+// matchColumn returns the column of the file that the column of the target schema is matched with,
+// or nil if it's missing in the file.
+func (p *schemaProjection) matchColumn(target *parquetschema.ColumnDefinition, fileCols []*parquetschema.ColumnDefinition) *parquetschema.ColumnDefinition {
+	for _, c := range fileCols {
+		if p.fieldIDs && target.SchemaElement.FieldID != nil {
+			if c.SchemaElement.FieldID != nil && *c.SchemaElement.FieldID == *target.SchemaElement.FieldID {
+				return c
+			}
+		} else if c.SchemaElement.Name == target.SchemaElement.Name {
+			return c
+		}
+	}
+	return nil
+}
+
+func (p *schemaProjection) projectColumn(target, file *parquetschema.ColumnDefinition, targetPath, filePath string) (*projectedField, error) {
+	targetRep, fileRep := target.SchemaElement.GetRepetitionType(), file.SchemaElement.GetRepetitionType()
+	switch {
+	case targetRep == parquet.FieldRepetitionType_REPEATED && fileRep != parquet.FieldRepetitionType_REPEATED:
+		return nil, errors.Errorf("column %q is repeated in the target schema, but %s in the file", targetPath, repetitionName(fileRep))
+	case targetRep != parquet.FieldRepetitionType_REPEATED && fileRep == parquet.FieldRepetitionType_REPEATED:
+		return nil, errors.Errorf("column %q is %s in the target schema, but repeated in the file", targetPath, repetitionName(targetRep))
+	case targetRep == parquet.FieldRepetitionType_REQUIRED && fileRep == parquet.FieldRepetitionType_OPTIONAL:
+		return nil, errors.Errorf("column %q is required in the target schema, but optional in the file", targetPath)
+	}
+
+	field := &projectedField{
+		name:     target.SchemaElement.Name,
+		fileName: file.SchemaElement.Name,
+		repeated: targetRep == parquet.FieldRepetitionType_REPEATED,
+		required: targetRep == parquet.FieldRepetitionType_REQUIRED,
+	}
+
+	targetGroup, fileGroup := target.SchemaElement.Type == nil, file.SchemaElement.Type == nil
+	switch {
+	case targetGroup && !fileGroup:
+		return nil, errors.Errorf("column %q is a group in the target schema, but a primitive column in the file", targetPath)
+	case !targetGroup && fileGroup:
+		return nil, errors.Errorf("column %q is a primitive column in the target schema, but a group in the file", targetPath)
+	case targetGroup:
+		children, err := p.projectGroup(target.Children, file.Children, targetPath, filePath)
+		if err != nil {
+			return nil, err
+		}
+		// a group whose columns are all missing in the file is null, or empty if it is required.
+		if len(children) == 0 && !field.required {
+			return nil, nil
+		}
+		field.group = true
+		field.children = children
+		return field, nil
+	}
+
+	convert, err := promotion(target.SchemaElement, file.SchemaElement)
+	if err != nil {
+		return nil, errors.Wrapf(err, "column %q", targetPath)
+	}
+	field.convert = convert
+	col := &projectedColumn{
+		targetPath: targetPath,
+		filePath:   filePath,
+		elem:       target.SchemaElement,
+		promoted:   convert != nil,
+	}
+	p.columns = append(p.columns, col)
+	p.targetColumns[targetPath] = col
+
+	return field, nil
+}
+
+// promotion returns the conversion of the values of a column of the file to the type of the column in
+// the target schema, or nil if no conversion is required.
+func promotion(target, file *parquet.SchemaElement) (func(v interface{}) interface{}, error) {
+	targetType, fileType := target.GetType(), file.GetType()
+	if targetType == fileType {
+		if targetType == parquet.Type_FIXED_LEN_BYTE_ARRAY && target.GetTypeLength() != file.GetTypeLength() {
+			return nil, errors.Errorf("the length %d of the fixed_len_byte_array in the file differs from the length %d in the target schema", file.GetTypeLength(), target.GetTypeLength())
+		}
+		return nil, nil
+	}
+
+	switch {
+	case fileType == parquet.Type_INT32 && targetType == parquet.Type_INT64 && isSignedInt(file) && isSignedInt(target):
+		return func(v interface{}) interface{} {
+			if values, ok := v.([]int32); ok {
+				converted := make([]int64, len(values))
+				for i := range values {
+					converted[i] = int64(values[i])
+				}
+				return converted
+			}
+			return int64(v.(int32))
+		}, nil
+	case fileType == parquet.Type_INT32 && targetType == parquet.Type_DOUBLE && isSignedInt(file):
+		return func(v interface{}) interface{} {
+			if values, ok := v.([]int32); ok {
+				converted := make([]float64, len(values))
+				for i := range values {
+					converted[i] = float64(values[i])
+				}
+				return converted
+			}
+			return float64(v.(int32))
+		}, nil
+	case fileType == parquet.Type_FLOAT && targetType == parquet.Type_DOUBLE:
+		return func(v interface{}) interface{} {
+			if values, ok := v.([]float32); ok {
+				converted := make([]float64, len(values))
+				for i := range values {
+					converted[i] = float64(values[i])
+				}
+				return converted
+			}
+			return float64(v.(float32))
+		}, nil
+	}
+
+	return nil, errors.Errorf("type %s in the file can't be converted to type %s of the target schema", getTypeName(file), getTypeName(target))
+}
+
+// isSignedInt returns true if the column described by elem is a signed integer without any other
+// logical type, like DATE or DECIMAL.
+func isSignedInt(elem *parquet.SchemaElement) bool {
+	if elem.LogicalType != nil {
+		return elem.LogicalType.INTEGER != nil && elem.LogicalType.INTEGER.IsSigned
+	}
+	if elem.ConvertedType != nil {
+		switch *elem.ConvertedType {
+		case parquet.ConvertedType_INT_8, parquet.ConvertedType_INT_16, parquet.ConvertedType_INT_32, parquet.ConvertedType_INT_64:
+			return true
+		}
+		return false
+	}
+	return true
+}
+
+// getTypeName returns the name of the type of elem as used in textual schema definitions.
+func getTypeName(elem *parquet.SchemaElement) string {
+	if elem.GetType() == parquet.Type_BYTE_ARRAY {
+		return "binary"
+	}
+	return strings.ToLower(elem.GetType().String())
+}
+
+func repetitionName(rep parquet.FieldRepetitionType) string {
+	return strings.ToLower(rep.String())
+}
+
+// fieldIDPath returns the flat name of the column below col with the field ID, or an empty string if
+// there is no such column.
+func fieldIDPath(col *parquetschema.ColumnDefinition, id int32) string {
+	for _, c := range col.Children {
+		if c.SchemaElement.FieldID != nil && *c.SchemaElement.FieldID == id {
+			return c.SchemaElement.Name
+		}
+		if path := fieldIDPath(c, id); path != "" {
+			return joinPath(c.SchemaElement.Name, path)
+		}
+	}
+	return ""
+}
+
+func joinPath(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
+
+// selectedColumns returns the columns of the file that need to be read. If columns isn't empty, only
+// the columns of the target schema that are selected by it are read, see WithColumns.
+func (p *schemaProjection) selectedColumns(s SchemaReader, columns []string) []string {
+	selected := make([]string, 0, len(p.columns))
+	s.setSelectedColumns(columns...)
+	for _, c := range p.columns {
+		if s.isSelected(c.targetPath) {
+			selected = append(selected, c.filePath)
+		}
+	}
+
+	// at least one column needs to be read to determine the rows of the file, even if none of the
+	// columns of the target schema are present in the file.
+	if len(selected) == 0 {
+		selected = append(selected, s.Columns()[0].FlatName())
+	}
+	return selected
+}
+
+// column returns the primitive column of the target schema with the flat name, or nil if there is no
+// such column or no target schema is set.
+func (p *schemaProjection) column(name string) *projectedColumn {
+	if p == nil {
+		return nil
+	}
+	return p.targetColumns[name]
+}
+
+// project maps a row read from the file onto the target schema.
+func (p *schemaProjection) project(row map[string]interface{}) map[string]interface{} {
+	return projectFields(p.fields, row)
+}
+
+func projectFields(fields []*projectedField, data map[string]interface{}) map[string]interface{} {
+	projected := make(map[string]interface{}, len(fields))
+	for _, field := range fields {
+		v, ok := data[field.fileName]
+		if !ok {
+			if field.group && field.required {
+				projected[field.name] = map[string]interface{}{}
+			}
+			continue
+		}
+		projected[field.name] = field.project(v)
+	}
+	return projected
+}
+
+func (f *projectedField) project(v interface{}) interface{} {
+	if !f.group {
+		if f.convert == nil {
+			return v
+		}
+		return f.convert(v)
+	}
+
+	if !f.repeated {
+		return projectFields(f.children, v.(map[string]interface{}))
+	}
+
+	groups := v.([]map[string]interface{})
+	projected := make([]map[string]interface{}, len(groups))
+	for i := range groups {
+		projected[i] = projectFields(f.children, groups[i])
+	}
+	return projected
+}
@@ -0,0 +1,238 @@
+package goparquet
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/fraugster/parquet-go/parquetschema"
+	"github.com/stretchr/testify/require"
+)
+
+func writeProjectionTestFile(t *testing.T, schema string, rows ...map[string]interface{}) []byte {
+	sd, err := parquetschema.ParseSchemaDefinition(schema)
+	require.NoError(t, err)
+
+	buf := &bytes.Buffer{}
+	w := NewFileWriter(buf, WithSchemaDefinition(sd))
+	for _, row := range rows {
+		require.NoError(t, w.AddData(row))
+	}
+	require.NoError(t, w.Close())
+	return buf.Bytes()
+}
+
+func TestReadWithTargetSchema(t *testing.T) {
+	file := writeProjectionTestFile(t, `message v1 {
+		required int32 id;
+		optional float score;
+		required binary name (STRING);
+		repeated int32 values;
+		optional group address {
+			required binary city (STRING);
+			optional binary street (STRING);
+		}
+		repeated group tags {
+			required binary key (STRING);
+			optional int32 weight;
+		}
+	}`,
+		map[string]interface{}{
+			"id":      int32(1),
+			"score":   float32(1.5),
+			"name":    []byte("a"),
+			"values":  []int32{1, 2},
+			"address": map[string]interface{}{"city": []byte("Berlin"), "street": []byte("Main")},
+			"tags":    []map[string]interface{}{{"key": []byte("x"), "weight": int32(3)}, {"key": []byte("y")}},
+		},
+		map[string]interface{}{"id": int32(2), "name": []byte("b")},
+	)
+
+	target, err := parquetschema.ParseSchemaDefinition(`message v2 {
+		required int64 id;
+		optional double score;
+		repeated int64 values;
+		optional group address {
+			optional binary city (STRING);
+			optional binary zip (STRING);
+		}
+		repeated group tags {
+			required binary key (STRING);
+			optional double weight;
+		}
+		optional binary comment (STRING);
+		optional group extra {
+			optional int32 a;
+		}
+	}`)
+	require.NoError(t, err)
+
+	r, err := NewFileReaderWithOptions(bytes.NewReader(file), WithTargetSchemaDefinition(target))
+	require.NoError(t, err)
+	require.Equal(t, target, r.GetSchemaDefinition())
+	require.Equal(t, []map[string]interface{}{
+		{
+			"id":      int64(1),
+			"score":   float64(1.5),
+			"values":  []int64{1, 2},
+			"address": map[string]interface{}{"city": []byte("Berlin")},
+			"tags":    []map[string]interface{}{{"key": []byte("x"), "weight": float64(3)}, {"key": []byte("y")}},
+		},
+		{"id": int64(2)},
+	}, readAllRows(t, r))
+
+	// only the columns of the target schema that are selected are read.
+	r, err = NewFileReaderWithOptions(bytes.NewReader(file), WithTargetSchemaDefinition(target), WithColumns("id", "address"))
+	require.NoError(t, err)
+	require.Equal(t, []map[string]interface{}{
+		{"id": int64(1), "address": map[string]interface{}{"city": []byte("Berlin")}},
+		{"id": int64(2)},
+	}, readAllRows(t, r))
+
+	// a file without any of the columns of the target schema still has rows.
+	onlyNew, err := parquetschema.ParseSchemaDefinition(`message v3 {
+		optional binary comment (STRING);
+	}`)
+	require.NoError(t, err)
+	r, err = NewFileReaderWithOptions(bytes.NewReader(file), WithTargetSchemaDefinition(onlyNew))
+	require.NoError(t, err)
+	require.Equal(t, []map[string]interface{}{{}, {}}, readAllRows(t, r))
+}
+
+func TestReadWithTargetSchemaPredicate(t *testing.T) {
+	sd, err := parquetschema.ParseSchemaDefinition(`message v1 {
+		required int64 id;
+		optional binary name (STRING);
+		optional int32 count;
+	}`)
+	require.NoError(t, err)
+
+	buf := &bytes.Buffer{}
+	w := NewFileWriter(buf, WithSchemaDefinition(sd))
+	for i := int64(1); i <= 4; i++ {
+		require.NoError(t, w.AddData(map[string]interface{}{"id": i, "name": []byte("a"), "count": int32(i)}))
+		if i == 2 {
+			require.NoError(t, w.FlushRowGroup())
+		}
+	}
+	require.NoError(t, w.Close())
+
+	target, err := parquetschema.ParseSchemaDefinition(`message v2 {
+		required int64 id;
+		optional binary comment (STRING);
+		optional int64 count;
+	}`)
+	require.NoError(t, err)
+
+	tests := []struct {
+		predicate Predicate
+		ids       []int64
+	}{
+		{ColumnPredicate("id", GreaterThan, 2), []int64{3, 4}},
+		// comment is missing in the file, so all of its values are null.
+		{ColumnPredicate("comment", Equal, "x"), nil},
+		{Not(ColumnPredicate("comment", Equal, "x")), []int64{1, 2, 3, 4}},
+		// the statistics of the promoted column can't be used to skip row groups.
+		{ColumnPredicate("count", GreaterThan, int64(1)<<40), []int64{1, 2, 3, 4}},
+	}
+	for _, tt := range tests {
+		r, err := NewFileReaderWithOptions(bytes.NewReader(buf.Bytes()), WithTargetSchemaDefinition(target), WithPredicate(tt.predicate))
+		require.NoError(t, err)
+		var ids []int64
+		for _, row := range readAllRows(t, r) {
+			ids = append(ids, row["id"].(int64))
+		}
+		require.Equal(t, tt.ids, ids)
+	}
+
+	// predicates refer to the columns of the target schema, not the file.
+	_, err = NewFileReaderWithOptions(bytes.NewReader(buf.Bytes()), WithTargetSchemaDefinition(target), WithPredicate(ColumnPredicate("name", Equal, "a")))
+	require.EqualError(t, err, `predicate column "name" not found`)
+
+	r, err := NewFileReaderWithOptions(bytes.NewReader(buf.Bytes()), WithTargetSchemaDefinition(target), WithPredicate(ColumnPredicate("comment", Equal, 1)))
+	require.NoError(t, err)
+	_, err = r.NextRow()
+	require.EqualError(t, err, `invalid value for predicate on column "comment": can't use int for BYTE_ARRAY column`)
+}
+
+func TestReadWithIncompatibleTargetSchema(t *testing.T) {
+	file := writeProjectionTestFile(t, `message v1 {
+		required int64 id;
+		optional int32 count;
+		optional int32 day (DATE);
+		repeated binary tags (STRING);
+		optional group address {
+			required binary city (STRING);
+		}
+		required fixed_len_byte_array(4) code;
+	}`, map[string]interface{}{"id": int64(1), "code": []byte("abcd")})
+
+	tests := []struct {
+		target string
+		err    string
+	}{
+		{`required int32 id;`, `column "id": type int64 in the file can't be converted to type int32 of the target schema`},
+		{`required int64 id; required int64 count;`, `column "count" is required in the target schema, but optional in the file`},
+		{`required int64 id; required binary name (STRING);`, `column "name" is required in the target schema, but missing in the file`},
+		{`optional int64 day;`, `column "day": type int32 in the file can't be converted to type int64 of the target schema`},
+		{`optional binary tags (STRING);`, `column "tags" is optional in the target schema, but repeated in the file`},
+		{`repeated int32 count;`, `column "count" is repeated in the target schema, but optional in the file`},
+		{`optional binary address;`, `column "address" is a primitive column in the target schema, but a group in the file`},
+		{`optional group id { optional int64 a; }`, `column "id" is a group in the target schema, but a primitive column in the file`},
+		{`optional group address { required binary city (STRING); required int32 zip; }`, `column "address.zip" is required in the target schema, but missing in the file`},
+		{`required fixed_len_byte_array(8) code;`, `column "code": the length 4 of the fixed_len_byte_array in the file differs from the length 8 in the target schema`},
+	}
+	for _, tt := range tests {
+		target, err := parquetschema.ParseSchemaDefinition("message target {\n" + tt.target + "\n}")
+		require.NoError(t, err)
+		_, err = NewFileReaderWithOptions(bytes.NewReader(file), WithTargetSchemaDefinition(target))
+		require.EqualError(t, err, "incompatible target schema: "+tt.err, tt.target)
+	}
+}
+
+func TestReadWithFieldIDs(t *testing.T) {
+	file := writeProjectionTestFile(t, `message v1 {
+		required int32 id = 1;
+		optional binary name (STRING) = 2;
+		optional binary city (STRING) = 3;
+	}`, map[string]interface{}{"id": int32(1), "name": []byte("Jane"), "city": []byte("Berlin")})
+
+	// name was renamed to full_name, and a new column uses the old name.
+	target, err := parquetschema.ParseSchemaDefinition(`message v2 {
+		required int64 id = 1;
+		optional binary full_name (STRING) = 2;
+		optional binary name (STRING) = 4;
+		optional binary city (STRING);
+	}`)
+	require.NoError(t, err)
+
+	r, err := NewFileReaderWithOptions(bytes.NewReader(file), WithTargetSchemaDefinition(target), WithFieldIDResolution())
+	require.NoError(t, err)
+	require.Equal(t, []map[string]interface{}{
+		{"id": int64(1), "full_name": []byte("Jane"), "city": []byte("Berlin")},
+	}, readAllRows(t, r))
+
+	r, err = NewFileReaderWithOptions(bytes.NewReader(file), WithTargetSchemaDefinition(target))
+	require.NoError(t, err)
+	require.Equal(t, []map[string]interface{}{
+		{"id": int64(1), "name": []byte("Jane"), "city": []byte("Berlin")},
+	}, readAllRows(t, r))
+
+	r, err = NewFileReaderWithOptions(bytes.NewReader(file), WithTargetSchemaDefinition(target), WithFieldIDResolution(),
+		WithColumnsByFieldID(2), WithColumns("id"))
+	require.NoError(t, err)
+	require.Equal(t, []map[string]interface{}{
+		{"id": int64(1), "full_name": []byte("Jane")},
+	}, readAllRows(t, r))
+
+	r, err = NewFileReaderWithOptions(bytes.NewReader(file), WithTargetSchemaDefinition(target), WithFieldIDResolution(),
+		WithPredicate(ColumnPredicate("full_name", Equal, "John")))
+	require.NoError(t, err)
+	require.Empty(t, readAllRows(t, r))
+
+	r, err = NewFileReaderWithOptions(bytes.NewReader(file), WithColumnsByFieldID(3))
+	require.NoError(t, err)
+	require.Equal(t, []map[string]interface{}{{"city": []byte("Berlin")}}, readAllRows(t, r))
+
+	_, err = NewFileReaderWithOptions(bytes.NewReader(file), WithColumnsByFieldID(4))
+	require.EqualError(t, err, "column with field ID 4 not found")
+}
@@ -0,0 +1,464 @@
+package goparquet
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"unicode/utf8"
+
+	"github.com/fraugster/parquet-go/parquet"
+	"github.com/pkg/errors"
+)
+
+// compareValues compares two non-null values of the same column and returns -1 if a is
+// less than b, 1 if a is greater than b, and 0 if both are equal.
+func compareValues(a, b interface{}) int {
+	switch va := a.(type) {
+	case bool:
+		vb := b.(bool)
+		switch {
+		case va == vb:
+			return 0
+		case !va:
+			return -1
+		default:
+			return 1
+		}
+	case int32:
+		return compareInt64(int64(va), int64(b.(int32)))
+	case uint32:
+		return compareUint64(uint64(va), uint64(b.(uint32)))
+	case int64:
+		return compareInt64(va, b.(int64))
+	case uint64:
+		return compareUint64(va, b.(uint64))
+	case float32:
+		return compareFloat64(float64(va), float64(b.(float32)))
+	case float64:
+		return compareFloat64(va, b.(float64))
+	case []byte:
+		return bytes.Compare(va, b.([]byte))
+	case [12]byte:
+		vb := b.([12]byte)
+		return bytes.Compare(va[:], vb[:])
+	default:
+		panic("not supported type")
+	}
+}
+
+func compareInt64(a, b int64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	default:
+		return 0
+	}
+}
+
+func compareUint64(a, b uint64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	default:
+		return 0
+	}
+}
+
+func compareFloat64(a, b float64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// isNaN returns true if the value is a floating point NaN. NaN values are not taken
+// into account when calculating statistics, since they can't be ordered.
+func isNaN(v interface{}) bool {
+	switch f := v.(type) {
+	case float32:
+		return f != f
+	case float64:
+		return math.IsNaN(f)
+	default:
+		return false
+	}
+}
+
+// encodeStatValue returns the plain encoding of a value as it is used in statistics.
+func encodeStatValue(v interface{}) []byte {
+	switch typed := v.(type) {
+	case bool:
+		if typed {
+			return []byte{1}
+		}
+		return []byte{0}
+	case int32:
+		ret := make([]byte, 4)
+		binary.LittleEndian.PutUint32(ret, uint32(typed))
+		return ret
+	case uint32:
+		ret := make([]byte, 4)
+		binary.LittleEndian.PutUint32(ret, typed)
+		return ret
+	case int64:
+		ret := make([]byte, 8)
+		binary.LittleEndian.PutUint64(ret, uint64(typed))
+		return ret
+	case uint64:
+		ret := make([]byte, 8)
+		binary.LittleEndian.PutUint64(ret, typed)
+		return ret
+	case float32:
+		ret := make([]byte, 4)
+		binary.LittleEndian.PutUint32(ret, math.Float32bits(typed))
+		return ret
+	case float64:
+		ret := make([]byte, 8)
+		binary.LittleEndian.PutUint64(ret, math.Float64bits(typed))
+		return ret
+	case []byte:
+		return typed
+	case [12]byte:
+		return typed[:]
+	default:
+		panic("not supported type")
+	}
+}
+
+// columnComparator returns the function that compares two non-null values of the column described
+// by the schema element according to the type defined order of the parquet format:
+//
+// - signed integers, FLOAT and DOUBLE values are compared as signed numbers,
+// - unsigned integers are compared as unsigned numbers,
+// - DECIMAL values stored in byte arrays are compared as signed big-endian two's complement numbers,
+// - INT96 timestamps are compared chronologically,
+// - all other byte arrays, like STRING columns, are compared lexicographically as unsigned bytes.
+func columnComparator(elem *parquet.SchemaElement) func(a, b interface{}) int {
+	switch elem.GetType() {
+	case parquet.Type_BYTE_ARRAY, parquet.Type_FIXED_LEN_BYTE_ARRAY:
+		if isDecimal(elem) {
+			return func(a, b interface{}) int {
+				return compareDecimal(a.([]byte), b.([]byte))
+			}
+		}
+	case parquet.Type_INT96:
+		return func(a, b interface{}) int {
+			return compareInt96(a.([12]byte), b.([12]byte))
+		}
+	}
+	return compareValues
+}
+
+// compareDecimal compares two big-endian two's complement numbers of possibly different length.
+func compareDecimal(a, b []byte) int {
+	negA := len(a) > 0 && a[0]&0x80 != 0
+	negB := len(b) > 0 && b[0]&0x80 != 0
+	if negA != negB {
+		if negA {
+			return -1
+		}
+		return 1
+	}
+
+	// both numbers have the same sign, so after sign-extending them to the same length
+	// they are ordered like unsigned numbers.
+	var pad byte
+	if negA {
+		pad = 0xff
+	}
+	n := len(a)
+	if len(b) > n {
+		n = len(b)
+	}
+	for i := 0; i < n; i++ {
+		ca, cb := pad, pad
+		if j := i - (n - len(a)); j >= 0 {
+			ca = a[j]
+		}
+		if j := i - (n - len(b)); j >= 0 {
+			cb = b[j]
+		}
+		if ca != cb {
+			return compareInt64(int64(ca), int64(cb))
+		}
+	}
+	return 0
+}
+
+// compareInt96 compares two INT96 timestamps, which consist of the nanoseconds of the day in the
+// first 8 bytes followed by the julian day in the last 4 bytes, both little-endian.
+func compareInt96(a, b [12]byte) int {
+	dayA := int64(int32(binary.LittleEndian.Uint32(a[8:])))
+	dayB := int64(int32(binary.LittleEndian.Uint32(b[8:])))
+	if c := compareInt64(dayA, dayB); c != 0 {
+		return c
+	}
+	return compareInt64(int64(binary.LittleEndian.Uint64(a[:8])), int64(binary.LittleEndian.Uint64(b[:8])))
+}
+
+// isDecimal returns true if the column described by the schema element holds DECIMAL values.
+func isDecimal(elem *parquet.SchemaElement) bool {
+	if elem.LogicalType != nil && elem.LogicalType.DECIMAL != nil {
+		return true
+	}
+	return elem.ConvertedType != nil && *elem.ConvertedType == parquet.ConvertedType_DECIMAL
+}
+
+// isString returns true if the byte array column described by the schema element holds UTF-8
+// encoded strings.
+func isString(elem *parquet.SchemaElement) bool {
+	if lt := elem.LogicalType; lt != nil && (lt.STRING != nil || lt.ENUM != nil || lt.JSON != nil) {
+		return true
+	}
+	if elem.ConvertedType == nil {
+		return false
+	}
+	switch *elem.ConvertedType {
+	case parquet.ConvertedType_UTF8, parquet.ConvertedType_ENUM, parquet.ConvertedType_JSON:
+		return true
+	default:
+		return false
+	}
+}
+
+// truncateMin truncates a byte array to at most n bytes. The result is less than or equal to v.
+// Valid UTF-8 strings are only truncated at character boundaries.
+func truncateMin(v []byte, n int, isString bool) []byte {
+	if len(v) <= n {
+		return v
+	}
+	if isString && utf8.Valid(v) {
+		for n > 0 && !utf8.RuneStart(v[n]) {
+			n--
+		}
+	}
+	return v[:n]
+}
+
+// truncateMax truncates a byte array to at most n bytes and increments it, so that the result
+// is greater than v. Valid UTF-8 strings are only truncated at character boundaries and their last
+// character is incremented to the next valid character. If no such value exists, v is returned.
+func truncateMax(v []byte, n int, isString bool) []byte {
+	if len(v) <= n {
+		return v
+	}
+
+	if isString && utf8.Valid(v) {
+		prefix := truncateMin(v, n, true)
+		for len(prefix) > 0 {
+			r, size := utf8.DecodeLastRune(prefix)
+			prefix = prefix[:len(prefix)-size]
+			next := r + 1
+			if next >= 0xd800 && next <= 0xdfff {
+				next = 0xe000
+			}
+			if next > utf8.MaxRune || len(prefix)+utf8.RuneLen(next) > n {
+				continue
+			}
+			ret := make([]byte, len(prefix), len(prefix)+utf8.RuneLen(next))
+			copy(ret, prefix)
+			return append(ret, string(next)...)
+		}
+		return v
+	}
+
+	ret := append([]byte(nil), v[:n]...)
+	for i := n - 1; i >= 0; i-- {
+		if ret[i] < 0xff {
+			ret[i]++
+			return ret[:i+1]
+		}
+	}
+	return v
+}
+
+// minMaxTracker keeps track of the minimum and maximum of the values that are added to it.
+type minMaxTracker struct {
+	compare  func(a, b interface{}) int
+	min, max interface{}
+
+	// maxLength is the maximum length of byte array min and max values, 0 if they are not truncated.
+	maxLength int
+	isString  bool
+	// no min and max values are tracked for columns whose sort order is undefined.
+	undefinedOrder bool
+}
+
+// newMinMaxTracker returns a tracker for the values of the column described by elem. Byte array
+// min and max values longer than maxLength bytes are truncated, unless maxLength is 0 or less or
+// the column holds DECIMAL values, which can't be truncated without changing their order.
+func newMinMaxTracker(elem *parquet.SchemaElement, maxLength int) minMaxTracker {
+	if isDecimal(elem) {
+		maxLength = 0
+	}
+	return minMaxTracker{
+		compare:        columnComparator(elem),
+		maxLength:      maxLength,
+		isString:       isString(elem),
+		undefinedOrder: !hasDefinedOrder(elem),
+	}
+}
+
+// hasDefinedOrder returns false for INT96 and INTERVAL columns, whose sort order is undefined by the
+// parquet format. No min and max values are written for them, and readers ignore them anyway.
+func hasDefinedOrder(elem *parquet.SchemaElement) bool {
+	if elem.GetType() == parquet.Type_INT96 {
+		return false
+	}
+	return elem.ConvertedType == nil || *elem.ConvertedType != parquet.ConvertedType_INTERVAL
+}
+
+func (m *minMaxTracker) add(v interface{}) {
+	if v == nil || isNaN(v) || m.undefinedOrder {
+		return
+	}
+	if m.min == nil || m.compare(v, m.min) < 0 {
+		m.min = v
+	}
+	if m.max == nil || m.compare(v, m.max) > 0 {
+		m.max = v
+	}
+}
+
+// merge adds the minimum and maximum of another tracker of the same column.
+func (m *minMaxTracker) merge(o *minMaxTracker) {
+	m.add(o.min)
+	m.add(o.max)
+}
+
+// minValue returns the encoded minimum. A minimum of zero is written as -0.0 for floating point
+// columns, so that readers don't skip pages that contain a negative zero. Byte arrays are
+// truncated to maxLength bytes.
+func (m *minMaxTracker) minValue() []byte {
+	if m.min == nil {
+		return nil
+	}
+	switch typed := m.min.(type) {
+	case float32:
+		if typed == 0 {
+			return encodeStatValue(float32(math.Copysign(0, -1)))
+		}
+	case float64:
+		if typed == 0 {
+			return encodeStatValue(math.Copysign(0, -1))
+		}
+	case []byte:
+		if m.maxLength > 0 {
+			return truncateMin(typed, m.maxLength, m.isString)
+		}
+	}
+	return encodeStatValue(m.min)
+}
+
+// maxValue returns the encoded maximum. A maximum of zero is written as +0.0 for floating point
+// columns, so that readers don't skip pages that contain a positive zero. Byte arrays are
+// truncated to maxLength bytes and incremented, so that the result is still an upper bound.
+func (m *minMaxTracker) maxValue() []byte {
+	if m.max == nil {
+		return nil
+	}
+	switch typed := m.max.(type) {
+	case float32:
+		if typed == 0 {
+			return encodeStatValue(float32(0))
+		}
+	case float64:
+		if typed == 0 {
+			return encodeStatValue(float64(0))
+		}
+	case []byte:
+		if m.maxLength > 0 {
+			return truncateMax(typed, m.maxLength, m.isString)
+		}
+	}
+	return encodeStatValue(m.max)
+}
+
+// statistics returns the statistics of the tracked values. The deprecated min and max fields are
+// only set for columns whose type defined order is the signed order they were specified with.
+func (m *minMaxTracker) statistics(elem *parquet.SchemaElement, nullCount int64) *parquet.Statistics {
+	stats := &parquet.Statistics{
+		MinValue:  m.minValue(),
+		MaxValue:  m.maxValue(),
+		NullCount: &nullCount,
+	}
+	if hasSignedStats(elem) {
+		stats.Min, stats.Max = stats.MinValue, stats.MaxValue
+	}
+	return stats
+}
+
+// isUnsigned returns true if the integer column described by the schema element holds unsigned values.
+func isUnsigned(elem *parquet.SchemaElement) bool {
+	return isUnsignedInt(elem.ConvertedType, elem.LogicalType)
+}
+
+// isUnsignedInt returns true if the converted or logical type describes an unsigned integer.
+func isUnsignedInt(convertedType *parquet.ConvertedType, logicalType *parquet.LogicalType) bool {
+	if convertedType != nil {
+		switch *convertedType {
+		case parquet.ConvertedType_UINT_8, parquet.ConvertedType_UINT_16, parquet.ConvertedType_UINT_32, parquet.ConvertedType_UINT_64:
+			return true
+		}
+	}
+	return logicalType != nil && logicalType.INTEGER != nil && !logicalType.INTEGER.IsSigned
+}
+
+// decodeStatValue decodes a plain encoded value as found in statistics and column indexes.
+func decodeStatValue(elem *parquet.SchemaElement, data []byte) (interface{}, error) {
+	typ := elem.GetType()
+	switch typ {
+	case parquet.Type_BOOLEAN:
+		if len(data) != 1 {
+			return nil, errors.Errorf("invalid %s statistics value of length %d", typ, len(data))
+		}
+		return data[0] != 0, nil
+	case parquet.Type_INT32:
+		if len(data) != 4 {
+			return nil, errors.Errorf("invalid %s statistics value of length %d", typ, len(data))
+		}
+		v := binary.LittleEndian.Uint32(data)
+		if isUnsigned(elem) {
+			return v, nil
+		}
+		return int32(v), nil
+	case parquet.Type_INT64:
+		if len(data) != 8 {
+			return nil, errors.Errorf("invalid %s statistics value of length %d", typ, len(data))
+		}
+		v := binary.LittleEndian.Uint64(data)
+		if isUnsigned(elem) {
+			return v, nil
+		}
+		return int64(v), nil
+	case parquet.Type_FLOAT:
+		if len(data) != 4 {
+			return nil, errors.Errorf("invalid %s statistics value of length %d", typ, len(data))
+		}
+		return math.Float32frombits(binary.LittleEndian.Uint32(data)), nil
+	case parquet.Type_DOUBLE:
+		if len(data) != 8 {
+			return nil, errors.Errorf("invalid %s statistics value of length %d", typ, len(data))
+		}
+		return math.Float64frombits(binary.LittleEndian.Uint64(data)), nil
+	case parquet.Type_INT96:
+		if len(data) != 12 {
+			return nil, errors.Errorf("invalid %s statistics value of length %d", typ, len(data))
+		}
+		var v [12]byte
+		copy(v[:], data)
+		return v, nil
+	case parquet.Type_BYTE_ARRAY, parquet.Type_FIXED_LEN_BYTE_ARRAY:
+		return data, nil
+	default:
+		return nil, errors.Errorf("unsupported type %s", typ)
+	}
+}
@@ -0,0 +1,260 @@
+package goparquet
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/fraugster/parquet-go/parquet"
+	"github.com/fraugster/parquet-go/parquetschema"
+	"github.com/stretchr/testify/require"
+)
+
+func TestColumnComparator(t *testing.T) {
+	decimal := parquet.ConvertedType_DECIMAL
+	int96 := func(day int32, nanos int64) [12]byte {
+		var v [12]byte
+		binary.LittleEndian.PutUint64(v[:8], uint64(nanos))
+		binary.LittleEndian.PutUint32(v[8:], uint32(day))
+		return v
+	}
+
+	tests := []struct {
+		name string
+		elem *parquet.SchemaElement
+		a, b interface{}
+		want int
+	}{
+		{"int32", &parquet.SchemaElement{Type: parquet.TypePtr(parquet.Type_INT32)}, int32(-1), int32(1), -1},
+		{"uint32", &parquet.SchemaElement{Type: parquet.TypePtr(parquet.Type_INT32)}, uint32(math.MaxUint32), uint32(1), 1},
+		{"string", &parquet.SchemaElement{Type: parquet.TypePtr(parquet.Type_BYTE_ARRAY)}, []byte{0xc3, 0xa4}, []byte("z"), 1},
+		{"string prefix", &parquet.SchemaElement{Type: parquet.TypePtr(parquet.Type_BYTE_ARRAY)}, []byte("ab"), []byte("abc"), -1},
+		{"decimal negative", &parquet.SchemaElement{Type: parquet.TypePtr(parquet.Type_BYTE_ARRAY), ConvertedType: &decimal}, []byte{0xff}, []byte{0x01}, -1},
+		{"decimal sign extended", &parquet.SchemaElement{Type: parquet.TypePtr(parquet.Type_BYTE_ARRAY), ConvertedType: &decimal}, []byte{0xff, 0x00}, []byte{0x80}, -1},
+		{"decimal equal", &parquet.SchemaElement{Type: parquet.TypePtr(parquet.Type_BYTE_ARRAY), ConvertedType: &decimal}, []byte{0x00, 0x7f}, []byte{0x7f}, 0},
+		{"decimal logical type", &parquet.SchemaElement{
+			Type:        parquet.TypePtr(parquet.Type_FIXED_LEN_BYTE_ARRAY),
+			LogicalType: &parquet.LogicalType{DECIMAL: &parquet.DecimalType{Precision: 4, Scale: 2}},
+		}, []byte{0x80, 0x00}, []byte{0x7f, 0xff}, -1},
+		{"int96 day", &parquet.SchemaElement{Type: parquet.TypePtr(parquet.Type_INT96)}, int96(2440589, 0), int96(2440588, 1000), 1},
+		{"int96 nanos", &parquet.SchemaElement{Type: parquet.TypePtr(parquet.Type_INT96)}, int96(2440588, 256), int96(2440588, 1), 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			compare := columnComparator(tt.elem)
+			require.Equal(t, tt.want, compare(tt.a, tt.b))
+			require.Equal(t, -tt.want, compare(tt.b, tt.a))
+		})
+	}
+}
+
+func TestMinMaxTrackerFloatZeroAndNaN(t *testing.T) {
+	elem := &parquet.SchemaElement{Type: parquet.TypePtr(parquet.Type_DOUBLE)}
+
+	m := newMinMaxTracker(elem, 0)
+	for _, v := range []float64{0, math.NaN(), math.Copysign(0, -1)} {
+		m.add(v)
+	}
+	require.Equal(t, encodeStatValue(math.Copysign(0, -1)), m.minValue())
+	require.Equal(t, encodeStatValue(float64(0)), m.maxValue())
+
+	m = newMinMaxTracker(elem, 0)
+	m.add(math.NaN())
+	require.Nil(t, m.minValue())
+	require.Nil(t, m.maxValue())
+
+	m = newMinMaxTracker(elem, 0)
+	m.add(float64(-3))
+	m.add(float64(2.5))
+	require.Equal(t, encodeStatValue(float64(-3)), m.minValue())
+	require.Equal(t, encodeStatValue(float64(2.5)), m.maxValue())
+}
+
+func TestWriteTypeDefinedStatistics(t *testing.T) {
+	sd, err := parquetschema.ParseSchemaDefinition(
+		`message test_msg {
+			required int32 u32 (INT(32, false));
+			required int64 u64 (UINT_64);
+			required fixed_len_byte_array(2) dec (DECIMAL(3, 2));
+			required binary str (STRING);
+		}`)
+	require.NoError(t, err)
+
+	buf := &bytes.Buffer{}
+	w := NewFileWriter(buf, WithSchemaDefinition(sd), WithMaxPageRowCount(1), WithPageIndex())
+
+	rows := []map[string]interface{}{
+		{"u32": uint32(math.MaxUint32), "u64": uint64(1), "dec": []byte{0x00, 0x01}, "str": []byte("z")},
+		{"u32": int32(-2), "u64": uint64(math.MaxUint64), "dec": []byte{0xff, 0xff}, "str": []byte("\xc3\xa4")},
+		{"u32": uint32(1), "u64": uint64(2), "dec": []byte{0x80, 0x00}, "str": []byte("a")},
+	}
+	for _, row := range rows {
+		require.NoError(t, w.AddData(row))
+	}
+	require.NoError(t, w.Close())
+
+	r, err := NewFileReader(bytes.NewReader(buf.Bytes()))
+	require.NoError(t, err)
+
+	require.Len(t, r.meta.ColumnOrders, 4)
+	for _, order := range r.meta.ColumnOrders {
+		require.NotNil(t, order.TYPE_ORDER)
+	}
+
+	expected := map[string][2]interface{}{
+		"u32": {uint32(1), uint32(math.MaxUint32)},
+		"u64": {uint64(1), uint64(math.MaxUint64)},
+		"dec": {[]byte{0x80, 0x00}, []byte{0x00, 0x01}},
+		"str": {[]byte("a"), []byte("\xc3\xa4")},
+	}
+	for _, chunk := range r.meta.RowGroups[0].Columns {
+		name := chunk.MetaData.PathInSchema[0]
+		stats := chunk.MetaData.Statistics
+		require.Equal(t, encodeStatValue(expected[name][0]), stats.MinValue, name)
+		require.Equal(t, encodeStatValue(expected[name][1]), stats.MaxValue, name)
+		require.Nil(t, stats.Min, name)
+		require.Nil(t, stats.Max, name)
+	}
+
+	// the pages of u32 are only ordered when the values are compared as unsigned integers.
+	ci := &parquet.ColumnIndex{}
+	chunk := r.meta.RowGroups[0].Columns[0]
+	require.NoError(t, readIndex(r.reader, ci, *chunk.ColumnIndexOffset, *chunk.ColumnIndexLength, nil, moduleColumnIndex))
+	require.Equal(t, parquet.BoundaryOrder_DESCENDING, ci.BoundaryOrder)
+
+	for i := range rows {
+		row, err := r.NextRow()
+		require.NoError(t, err)
+		if i == 1 {
+			require.Equal(t, uint32(math.MaxUint32-1), row["u32"])
+		} else {
+			require.Equal(t, rows[i]["u32"], row["u32"])
+		}
+		require.Equal(t, rows[i]["u64"], row["u64"])
+		require.Equal(t, rows[i]["dec"], row["dec"])
+	}
+
+	w = NewFileWriter(&bytes.Buffer{}, WithSchemaDefinition(sd))
+	require.Error(t, w.AddData(map[string]interface{}{"u32": uint32(1), "u64": int32(1), "dec": []byte{0, 0}, "str": []byte{}}))
+}
+
+func TestWriteUndefinedOrderStatistics(t *testing.T) {
+	sd, err := parquetschema.ParseSchemaDefinition(
+		`message test_msg {
+			required int96 ts;
+			required fixed_len_byte_array(12) iv (INTERVAL);
+			required int64 id;
+		}`)
+	require.NoError(t, err)
+
+	buf := &bytes.Buffer{}
+	w := NewFileWriter(buf, WithSchemaDefinition(sd), WithPageIndex())
+	for i := 0; i < 3; i++ {
+		require.NoError(t, w.AddData(map[string]interface{}{"ts": [12]byte{byte(i)}, "iv": make([]byte, 12), "id": int64(i)}))
+	}
+	require.NoError(t, w.Close())
+
+	r, err := NewFileReader(bytes.NewReader(buf.Bytes()))
+	require.NoError(t, err)
+
+	// the sort order of INT96 and INTERVAL is undefined, so no min and max values are written.
+	for _, chunk := range r.meta.RowGroups[0].Columns[:2] {
+		stats := chunk.MetaData.Statistics
+		require.Nil(t, stats.MinValue)
+		require.Nil(t, stats.MaxValue)
+		require.Nil(t, stats.Min)
+		require.Nil(t, stats.Max)
+		require.Equal(t, int64(0), stats.GetNullCount())
+		require.Nil(t, chunk.ColumnIndexOffset)
+		require.NotNil(t, chunk.OffsetIndexOffset)
+	}
+	stats := r.meta.RowGroups[0].Columns[2].MetaData.Statistics
+	require.Equal(t, encodeStatValue(int64(0)), stats.MinValue)
+	require.Equal(t, encodeStatValue(int64(2)), stats.MaxValue)
+	require.NotNil(t, r.meta.RowGroups[0].Columns[2].ColumnIndexOffset)
+	require.Len(t, readAllRows(t, r), 3)
+}
+
+func TestTruncateStatistics(t *testing.T) {
+	tests := []struct {
+		name     string
+		v        []byte
+		n        int
+		isString bool
+		min, max []byte
+	}{
+		{"short", []byte("abc"), 3, false, []byte("abc"), []byte("abc")},
+		{"bytes", []byte("abcdef"), 3, false, []byte("abc"), []byte("abd")},
+		{"bytes carry", []byte{0x01, 0xff, 0xff, 0x02}, 3, false, []byte{0x01, 0xff, 0xff}, []byte{0x02}},
+		{"bytes overflow", []byte{0xff, 0xff, 0x02}, 2, false, []byte{0xff, 0xff}, []byte{0xff, 0xff, 0x02}},
+		{"string", []byte("abcdef"), 3, true, []byte("abc"), []byte("abd")},
+		{"string character boundary", []byte("aäb"), 2, true, []byte("a"), []byte("b")},
+		{"string multi-byte increment", []byte("äää"), 4, true, []byte("ää"), []byte("äå")},
+		{"string longer increment", []byte("a\u007fb"), 2, true, []byte("a\u007f"), []byte("b")},
+		{"string max rune", []byte("\U0010ffff\U0010ffffa"), 8, true, []byte("\U0010ffff\U0010ffff"), []byte("\U0010ffff\U0010ffffa")},
+		{"string surrogates", []byte("\ud7ffa"), 3, true, []byte("\ud7ff"), []byte("\ue000")},
+		{"invalid string", []byte{'a', 0xff, 0xff, 'b'}, 3, true, []byte{'a', 0xff, 0xff}, []byte{'b'}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			min, max := truncateMin(tt.v, tt.n, tt.isString), truncateMax(tt.v, tt.n, tt.isString)
+			require.Equal(t, tt.min, min)
+			require.Equal(t, tt.max, max)
+			require.LessOrEqual(t, bytes.Compare(min, tt.v), 0)
+			require.GreaterOrEqual(t, bytes.Compare(max, tt.v), 0)
+		})
+	}
+}
+
+func TestWriteMaxStatisticsLength(t *testing.T) {
+	sd, err := parquetschema.ParseSchemaDefinition(
+		`message test_msg {
+			required binary doc (JSON);
+			required fixed_len_byte_array(20) dec (DECIMAL(40, 0));
+		}`)
+	require.NoError(t, err)
+
+	buf := &bytes.Buffer{}
+	w := NewFileWriter(buf, WithSchemaDefinition(sd), WithMaxStatisticsLength(16), WithMaxPageRowCount(10), WithPageIndex())
+
+	doc := func(i int) []byte {
+		return []byte(fmt.Sprintf(`{"id": %03d, "data": "%s"}`, i, strings.Repeat("x", 1000)))
+	}
+	dec := func(i int) []byte {
+		v := make([]byte, 20)
+		binary.BigEndian.PutUint64(v[12:], uint64(i))
+		return v
+	}
+	for i := 0; i < 50; i++ {
+		require.NoError(t, w.AddData(map[string]interface{}{"doc": doc(i), "dec": dec(i)}))
+	}
+	require.NoError(t, w.Close())
+
+	r, err := NewFileReader(bytes.NewReader(buf.Bytes()))
+	require.NoError(t, err)
+
+	chunks := r.meta.RowGroups[0].Columns
+	require.Equal(t, []byte(`{"id": 000, "dat`), chunks[0].MetaData.Statistics.MinValue)
+	require.Equal(t, []byte(`{"id": 049, "dau`), chunks[0].MetaData.Statistics.MaxValue)
+	require.Equal(t, dec(0), chunks[1].MetaData.Statistics.MinValue)
+	require.Equal(t, dec(49), chunks[1].MetaData.Statistics.MaxValue)
+
+	ci := &parquet.ColumnIndex{}
+	require.NoError(t, readIndex(r.reader, ci, *chunks[0].ColumnIndexOffset, *chunks[0].ColumnIndexLength, nil, moduleColumnIndex))
+	require.Equal(t, parquet.BoundaryOrder_ASCENDING, ci.BoundaryOrder)
+	for i := range ci.MinValues {
+		require.Len(t, ci.MinValues[i], 16)
+		require.Len(t, ci.MaxValues[i], 16)
+	}
+
+	r, err = NewFileReaderWithOptions(bytes.NewReader(buf.Bytes()), WithPredicate(ColumnPredicate("doc", Equal, doc(23))))
+	require.NoError(t, err)
+	rows := readAllRows(t, r)
+	require.Len(t, rows, 10)
+	require.Equal(t, doc(20), rows[0]["doc"])
+}
@@ -0,0 +1,145 @@
+package goparquet
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"path"
+	"reflect"
+
+	"github.com/fraugster/parquet-go/parquet"
+	"github.com/pkg/errors"
+)
+
+// The names of the summary files of a dataset. The _metadata file contains the row groups of all
+// files of the dataset, and the _common_metadata file only contains their schema and key-value
+// meta data.
+const (
+	MetadataFileName       = "_metadata"
+	CommonMetadataFileName = "_common_metadata"
+)
+
+// SummaryFile is a file of a dataset whose file meta data is merged into the summary files.
+type SummaryFile struct {
+	// Path is the slash-separated path of the file, relative to the directory of the summary files.
+	Path string
+	// MetaData is the file meta data of the file, as returned by FileWriter.FileMetaData or
+	// FileReader.FileMetaData.
+	MetaData *parquet.FileMetaData
+}
+
+// MergeFileMetaData merges the file meta data of the files of a dataset into the file meta data of
+// a _metadata summary file. All files need to have the same schema. The row groups of all files are
+// concatenated in the order of the files, and the file path of all their column chunks is set to
+// the path of the file they are stored in. Only the key-value meta data that has the same value in
+// all files is kept. Encrypted files are not supported.
+func MergeFileMetaData(files ...SummaryFile) (*parquet.FileMetaData, error) {
+	if len(files) == 0 {
+		return nil, errors.New("no files to merge")
+	}
+
+	first := files[0].MetaData
+	meta := &parquet.FileMetaData{
+		Version:      first.Version,
+		Schema:       first.Schema,
+		CreatedBy:    first.CreatedBy,
+		ColumnOrders: first.ColumnOrders,
+	}
+
+	kv := keyValueMetaDataToMap(first.KeyValueMetadata)
+	for _, file := range files {
+		fileMeta := file.MetaData
+		if fileMeta == nil {
+			return nil, errors.Errorf("file %q: missing file meta data", file.Path)
+		}
+		if fileMeta.EncryptionAlgorithm != nil {
+			return nil, errors.Errorf("file %q: summary files of encrypted files are not supported", file.Path)
+		}
+		if !reflect.DeepEqual(fileMeta.Schema, first.Schema) {
+			return nil, errors.Errorf("file %q: schema differs from the schema of file %q", file.Path, files[0].Path)
+		}
+		if !reflect.DeepEqual(fileMeta.ColumnOrders, first.ColumnOrders) {
+			meta.ColumnOrders = nil
+		}
+		if fileMeta.Version > meta.Version {
+			meta.Version = fileMeta.Version
+		}
+
+		fileKV := keyValueMetaDataToMap(fileMeta.KeyValueMetadata)
+		for k, v := range kv {
+			if fv, ok := fileKV[k]; !ok || fv != v {
+				delete(kv, k)
+			}
+		}
+
+		for _, rowGroup := range fileMeta.RowGroups {
+			rg, err := summaryRowGroup(file.Path, rowGroup)
+			if err != nil {
+				return nil, err
+			}
+			meta.RowGroups = append(meta.RowGroups, rg)
+		}
+		meta.NumRows += fileMeta.NumRows
+	}
+
+	// the key-value meta data is kept in the order of the first file.
+	for _, item := range first.KeyValueMetadata {
+		if v, ok := kv[item.Key]; ok && item.Value != nil && *item.Value == v {
+			meta.KeyValueMetadata = append(meta.KeyValueMetadata, item)
+		}
+	}
+
+	return meta, nil
+}
+
+// summaryRowGroup returns a copy of the row group of the file at filePath, whose column chunks
+// reference the file.
+func summaryRowGroup(filePath string, rowGroup *parquet.RowGroup) (*parquet.RowGroup, error) {
+	rg := *rowGroup
+	rg.Columns = make([]*parquet.ColumnChunk, len(rowGroup.Columns))
+	for i, chunk := range rowGroup.Columns {
+		if chunk.CryptoMetadata != nil {
+			return nil, errors.Errorf("file %q: summary files of encrypted files are not supported", filePath)
+		}
+
+		c := *chunk
+		p := filePath
+		// the column chunk may already be stored in another file, whose path is relative to filePath.
+		if chunk.FilePath != nil && *chunk.FilePath != "" {
+			p = path.Join(path.Dir(filePath), *chunk.FilePath)
+		}
+		c.FilePath = &p
+		rg.Columns[i] = &c
+	}
+
+	return &rg, nil
+}
+
+// WriteMetadataFile writes a parquet file that only consists of the file meta data, like the
+// _metadata summary file of a dataset whose file meta data was merged using MergeFileMetaData.
+func WriteMetadataFile(w io.Writer, meta *parquet.FileMetaData) error {
+	buf := &bytes.Buffer{}
+	if err := writeThrift(meta, buf); err != nil {
+		return err
+	}
+
+	if err := writeFull(w, magic); err != nil {
+		return err
+	}
+	if err := writeFull(w, buf.Bytes()); err != nil {
+		return err
+	}
+	if err := binary.Write(w, binary.LittleEndian, int32(buf.Len())); err != nil {
+		return err
+	}
+	return writeFull(w, magic)
+}
+
+// WriteCommonMetadataFile writes the _common_metadata summary file of a dataset, which only
+// contains the schema and the key-value meta data of the file meta data, but no row groups.
+func WriteCommonMetadataFile(w io.Writer, meta *parquet.FileMetaData) error {
+	common := *meta
+	common.NumRows = 0
+	common.RowGroups = []*parquet.RowGroup{}
+	return WriteMetadataFile(w, &common)
+}
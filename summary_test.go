@@ -0,0 +1,106 @@
+package goparquet
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSummaryFiles(t *testing.T) {
+	buf := &bytes.Buffer{}
+	w := NewFileWriter(buf, WithSchemaDefinition(predicateTestSchema(t)), WithMaxPageRowCount(10), WithPageIndex(),
+		WithMetaData(map[string]string{"shared": "value", "writer": "first"}))
+	for i := 0; i < 100; i++ {
+		require.NoError(t, w.AddData(predicateTestRow(i)))
+	}
+	require.NoError(t, w.Close())
+	file0 := buf.Bytes()
+
+	file1 := writePredicateTestFile(t, WithMaxPageRowCount(10), WithPageIndex(),
+		WithMetaData(map[string]string{"shared": "value", "writer": "second"}))
+	r, err := NewFileReader(bytes.NewReader(file1))
+	require.NoError(t, err)
+
+	files := map[string][]byte{
+		"part-0.parquet":     file0,
+		"a=1/part-1.parquet": file1,
+	}
+	meta, err := MergeFileMetaData(
+		SummaryFile{Path: "part-0.parquet", MetaData: w.FileMetaData()},
+		SummaryFile{Path: "a=1/part-1.parquet", MetaData: r.FileMetaData()},
+	)
+	require.NoError(t, err)
+	require.Equal(t, int64(200), meta.NumRows)
+	require.Len(t, meta.RowGroups, 3)
+	require.Equal(t, "a=1/part-1.parquet", *meta.RowGroups[2].Columns[0].FilePath)
+
+	metadata := &bytes.Buffer{}
+	require.NoError(t, WriteMetadataFile(metadata, meta))
+
+	// the file meta data of the original files is not modified.
+	require.Nil(t, w.FileMetaData().RowGroups[0].Columns[0].FilePath)
+
+	var opened []string
+	opener := FileOpenerFunc(func(path string) (io.ReaderAt, error) {
+		opened = append(opened, path)
+		file, ok := files[path]
+		if !ok {
+			return nil, os.ErrNotExist
+		}
+		return bytes.NewReader(file), nil
+	})
+
+	r, err = NewFileReaderWithOptions(bytes.NewReader(metadata.Bytes()), WithFileOpener(opener))
+	require.NoError(t, err)
+	require.Equal(t, int64(200), r.NumRows())
+	require.Equal(t, map[string]string{"shared": "value"}, r.MetaData())
+	rows := readAllRows(t, r)
+	require.Len(t, rows, 200)
+	for i, row := range rows {
+		require.Equal(t, predicateTestRow(i%100), row)
+	}
+	require.NoError(t, r.Close())
+	require.Equal(t, []string{"part-0.parquet", "a=1/part-1.parquet"}, opened)
+
+	// the rows are selected using the statistics of the summary file, and only the files that
+	// contain selected rows are opened.
+	opened = nil
+	r, err = NewFileReaderWithOptions(bytes.NewReader(metadata.Bytes()), WithFileOpener(opener), WithPredicate(ColumnPredicate("foo", Equal, 72)))
+	require.NoError(t, err)
+	rows = readAllRows(t, r)
+	require.Len(t, rows, 20)
+	require.Equal(t, predicateTestRow(70), rows[0])
+	require.Equal(t, predicateTestRow(70), rows[10])
+	require.NoError(t, r.Close())
+	require.Equal(t, []string{"part-0.parquet", "a=1/part-1.parquet"}, opened)
+
+	common := &bytes.Buffer{}
+	require.NoError(t, WriteCommonMetadataFile(common, meta))
+	r, err = NewFileReader(bytes.NewReader(common.Bytes()))
+	require.NoError(t, err)
+	require.Equal(t, 0, r.RowGroupCount())
+	require.Equal(t, int64(0), r.NumRows())
+	require.Equal(t, meta.Schema, r.FileMetaData().Schema)
+	require.Equal(t, map[string]string{"shared": "value"}, r.MetaData())
+	_, err = r.NextRow()
+	require.Equal(t, io.EOF, err)
+}
+
+func TestMergeFileMetaDataErrors(t *testing.T) {
+	_, err := MergeFileMetaData()
+	require.EqualError(t, err, "no files to merge")
+
+	r, err := NewFileReader(bytes.NewReader(writePredicateTestFile(t)))
+	require.NoError(t, err)
+	other, err := NewFileReader(bytes.NewReader(buildTestStream(t)))
+	require.NoError(t, err)
+
+	_, err = MergeFileMetaData(
+		SummaryFile{Path: "part-0.parquet", MetaData: r.FileMetaData()},
+		SummaryFile{Path: "part-1.parquet", MetaData: other.FileMetaData()},
+	)
+	require.EqualError(t, err, `file "part-1.parquet": schema differs from the schema of file "part-0.parquet"`)
+}
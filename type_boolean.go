@@ -175,14 +175,6 @@ func (b *booleanStore) reset(repetitionType parquet.FieldRepetitionType) {
 	b.repTyp = repetitionType
 }
 
-func (b *booleanStore) maxValue() []byte {
-	return nil
-}
-
-func (b *booleanStore) minValue() []byte {
-	return nil
-}
-
 func (b *booleanStore) getValues(v interface{}) ([]interface{}, error) {
 	var vals []interface{}
 	switch typed := v.(type) {
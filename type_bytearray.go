@@ -170,12 +170,7 @@ func (b *byteArrayDeltaLengthEncoder) encodeValues(values []interface{}) error {
 }
 
 func (b *byteArrayDeltaLengthEncoder) Close() error {
-	enc := &int32DeltaBPEncoder{
-		deltaBitPackEncoder32: deltaBitPackEncoder32{
-			blockSize:      128,
-			miniBlockCount: 4,
-		},
-	}
+	enc := newInt32DeltaBPEncoder(false)
 
 	if err := encodeValue(b.w, enc, b.lens); err != nil {
 		return err
@@ -275,12 +270,7 @@ func (b *byteArrayDeltaEncoder) encodeValues(values []interface{}) error {
 
 func (b *byteArrayDeltaEncoder) Close() error {
 	// write the lens first
-	enc := &int32DeltaBPEncoder{
-		deltaBitPackEncoder32: deltaBitPackEncoder32{
-			blockSize:      128,
-			miniBlockCount: 4,
-		},
-	}
+	enc := newInt32DeltaBPEncoder(false)
 
 	if err := encodeValue(b.w, enc, b.prefixLens); err != nil {
 		return err
@@ -290,8 +280,7 @@ func (b *byteArrayDeltaEncoder) Close() error {
 }
 
 type byteArrayStore struct {
-	repTyp   parquet.FieldRepetitionType
-	min, max []byte
+	repTyp parquet.FieldRepetitionType
 
 	*ColumnParameters
 }
@@ -320,39 +309,12 @@ func (is *byteArrayStore) repetitionType() parquet.FieldRepetitionType {
 
 func (is *byteArrayStore) reset(repetitionType parquet.FieldRepetitionType) {
 	is.repTyp = repetitionType
-	is.min = nil
-	is.max = nil
-}
-
-func (is *byteArrayStore) maxValue() []byte {
-	return is.max
 }
 
-func (is *byteArrayStore) minValue() []byte {
-	return is.min
-}
-
-func (is *byteArrayStore) setMinMax(j []byte) error {
+func (is *byteArrayStore) checkLength(j []byte) error {
 	if is.TypeLength != nil && *is.TypeLength > 0 && int32(len(j)) != *is.TypeLength {
 		return errors.Errorf("the size of data should be %d but is %d", *is.TypeLength, len(j))
 	}
-	// For nil value there is no need to set the min/max
-	if j == nil {
-		return nil
-	}
-	if is.max == nil || is.min == nil {
-		is.min = j
-		is.max = j
-		return nil
-	}
-
-	if bytes.Compare(j, is.min) < 0 {
-		is.min = j
-	}
-	if bytes.Compare(j, is.max) > 0 {
-		is.max = j
-	}
-
 	return nil
 }
 
@@ -360,6 +322,9 @@ func (is *byteArrayStore) getValues(v interface{}) ([]interface{}, error) {
 	var vals []interface{}
 	switch typed := v.(type) {
 	case []byte:
+		if err := is.checkLength(typed); err != nil {
+			return nil, err
+		}
 		vals = []interface{}{typed}
 	case [][]byte:
 		if is.repTyp != parquet.FieldRepetitionType_REPEATED {
@@ -367,6 +332,9 @@ func (is *byteArrayStore) getValues(v interface{}) ([]interface{}, error) {
 		}
 		vals = make([]interface{}, len(typed))
 		for j := range typed {
+			if err := is.checkLength(typed[j]); err != nil {
+				return nil, err
+			}
 			vals[j] = typed[j]
 		}
 	default:
@@ -76,6 +76,7 @@ func (d *dictStore) init() {
 	d.nullCount = 0
 	d.readPos = 0
 	d.size = 0
+	d.valueSize = 0
 }
 
 func (d *dictStore) assemble() []interface{} {
@@ -182,7 +183,13 @@ func (d *dictEncoder) Close() error {
 
 func (d *dictEncoder) init(w io.Writer) error {
 	d.w = w
-	d.dictStore.init()
+	if d.indices == nil {
+		d.dictStore.init()
+	}
+	// The dictionary values are shared by all pages of a column chunk, only the
+	// indices are written per page. Never reuse the slice, since it belongs to the
+	// column store.
+	d.data = nil
 
 	return nil
 }
@@ -54,9 +54,46 @@ func (d *doublePlainEncoder) encodeValues(values []interface{}) error {
 	return binary.Write(d.w, binary.LittleEndian, data)
 }
 
+type doubleByteStreamSplitDecoder struct {
+	byteStreamSplitDecoder
+}
+
+func newDoubleByteStreamSplitDecoder() *doubleByteStreamSplitDecoder {
+	return &doubleByteStreamSplitDecoder{byteStreamSplitDecoder{width: 8}}
+}
+
+func (d *doubleByteStreamSplitDecoder) decodeValues(dst []interface{}) (int, error) {
+	buf := make([]byte, 8)
+	for i := range dst {
+		if err := d.next(buf); err != nil {
+			return i, err
+		}
+		dst[i] = math.Float64frombits(binary.LittleEndian.Uint64(buf))
+	}
+
+	return len(dst), nil
+}
+
+type doubleByteStreamSplitEncoder struct {
+	byteStreamSplitEncoder
+}
+
+func newDoubleByteStreamSplitEncoder() *doubleByteStreamSplitEncoder {
+	return &doubleByteStreamSplitEncoder{byteStreamSplitEncoder{width: 8}}
+}
+
+func (d *doubleByteStreamSplitEncoder) encodeValues(values []interface{}) error {
+	buf := make([]byte, 8)
+	for i := range values {
+		binary.LittleEndian.PutUint64(buf, math.Float64bits(values[i].(float64)))
+		d.add(buf)
+	}
+
+	return nil
+}
+
 type doubleStore struct {
-	repTyp   parquet.FieldRepetitionType
-	min, max float64
+	repTyp parquet.FieldRepetitionType
 
 	*ColumnParameters
 }
@@ -82,42 +119,12 @@ func (f *doubleStore) repetitionType() parquet.FieldRepetitionType {
 
 func (f *doubleStore) reset(rep parquet.FieldRepetitionType) {
 	f.repTyp = rep
-	f.min = math.MaxFloat64
-	f.max = -math.MaxFloat64
-}
-
-func (f *doubleStore) maxValue() []byte {
-	if f.max == -math.MaxFloat64 {
-		return nil
-	}
-	ret := make([]byte, 8)
-	binary.LittleEndian.PutUint64(ret, math.Float64bits(f.max))
-	return ret
-}
-
-func (f *doubleStore) minValue() []byte {
-	if f.min == math.MaxFloat64 {
-		return nil
-	}
-	ret := make([]byte, 8)
-	binary.LittleEndian.PutUint64(ret, math.Float64bits(f.min))
-	return ret
-}
-
-func (f *doubleStore) setMinMax(j float64) {
-	if j < f.min {
-		f.min = j
-	}
-	if j > f.max {
-		f.max = j
-	}
 }
 
 func (f *doubleStore) getValues(v interface{}) ([]interface{}, error) {
 	var vals []interface{}
 	switch typed := v.(type) {
 	case float64:
-		f.setMinMax(typed)
 		vals = []interface{}{typed}
 	case []float64:
 		if f.repTyp != parquet.FieldRepetitionType_REPEATED {
@@ -125,7 +132,6 @@ func (f *doubleStore) getValues(v interface{}) ([]interface{}, error) {
 		}
 		vals = make([]interface{}, len(typed))
 		for j := range typed {
-			f.setMinMax(typed[j])
 			vals[j] = typed[j]
 		}
 	default:
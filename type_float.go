@@ -55,9 +55,46 @@ func (d *floatPlainEncoder) encodeValues(values []interface{}) error {
 	return binary.Write(d.w, binary.LittleEndian, data)
 }
 
+type floatByteStreamSplitDecoder struct {
+	byteStreamSplitDecoder
+}
+
+func newFloatByteStreamSplitDecoder() *floatByteStreamSplitDecoder {
+	return &floatByteStreamSplitDecoder{byteStreamSplitDecoder{width: 4}}
+}
+
+func (f *floatByteStreamSplitDecoder) decodeValues(dst []interface{}) (int, error) {
+	buf := make([]byte, 4)
+	for i := range dst {
+		if err := f.next(buf); err != nil {
+			return i, err
+		}
+		dst[i] = math.Float32frombits(binary.LittleEndian.Uint32(buf))
+	}
+
+	return len(dst), nil
+}
+
+type floatByteStreamSplitEncoder struct {
+	byteStreamSplitEncoder
+}
+
+func newFloatByteStreamSplitEncoder() *floatByteStreamSplitEncoder {
+	return &floatByteStreamSplitEncoder{byteStreamSplitEncoder{width: 4}}
+}
+
+func (f *floatByteStreamSplitEncoder) encodeValues(values []interface{}) error {
+	buf := make([]byte, 4)
+	for i := range values {
+		binary.LittleEndian.PutUint32(buf, math.Float32bits(values[i].(float32)))
+		f.add(buf)
+	}
+
+	return nil
+}
+
 type floatStore struct {
-	repTyp   parquet.FieldRepetitionType
-	min, max float32
+	repTyp parquet.FieldRepetitionType
 
 	*ColumnParameters
 }
@@ -83,42 +120,12 @@ func (f *floatStore) repetitionType() parquet.FieldRepetitionType {
 
 func (f *floatStore) reset(rep parquet.FieldRepetitionType) {
 	f.repTyp = rep
-	f.min = math.MaxFloat32
-	f.max = -math.MaxFloat32
-}
-
-func (f *floatStore) maxValue() []byte {
-	if f.max == -math.MaxFloat32 {
-		return nil
-	}
-	ret := make([]byte, 4)
-	binary.LittleEndian.PutUint32(ret, math.Float32bits(f.max))
-	return ret
-}
-
-func (f *floatStore) minValue() []byte {
-	if f.min == math.MaxFloat32 {
-		return nil
-	}
-	ret := make([]byte, 4)
-	binary.LittleEndian.PutUint32(ret, math.Float32bits(f.min))
-	return ret
-}
-
-func (f *floatStore) setMinMax(j float32) {
-	if j < f.min {
-		f.min = j
-	}
-	if j > f.max {
-		f.max = j
-	}
 }
 
 func (f *floatStore) getValues(v interface{}) ([]interface{}, error) {
 	var vals []interface{}
 	switch typed := v.(type) {
 	case float32:
-		f.setMinMax(typed)
 		vals = []interface{}{typed}
 	case []float32:
 		if f.repTyp != parquet.FieldRepetitionType_REPEATED {
@@ -126,7 +133,6 @@ func (f *floatStore) getValues(v interface{}) ([]interface{}, error) {
 		}
 		vals = make([]interface{}, len(typed))
 		for j := range typed {
-			f.setMinMax(typed[j])
 			vals[j] = typed[j]
 		}
 	default:
@@ -3,7 +3,6 @@ package goparquet
 import (
 	"encoding/binary"
 	"io"
-	"math"
 
 	"github.com/fraugster/parquet-go/parquet"
 	"github.com/pkg/errors"
@@ -91,6 +90,18 @@ type int32DeltaBPEncoder struct {
 	deltaBitPackEncoder32
 }
 
+// newInt32DeltaBPEncoder creates a DELTA_BINARY_PACKED encoder with blocks of 128 values that
+// are split into 4 mini blocks, like the reference implementation.
+func newInt32DeltaBPEncoder(unSigned bool) *int32DeltaBPEncoder {
+	return &int32DeltaBPEncoder{
+		unSigned: unSigned,
+		deltaBitPackEncoder32: deltaBitPackEncoder32{
+			blockSize:      128,
+			miniBlockCount: 4,
+		},
+	}
+}
+
 func (d *int32DeltaBPEncoder) encodeValues(values []interface{}) error {
 	if d.unSigned {
 		for i := range values {
@@ -110,8 +121,7 @@ func (d *int32DeltaBPEncoder) encodeValues(values []interface{}) error {
 }
 
 type int32Store struct {
-	repTyp   parquet.FieldRepetitionType
-	min, max int32
+	repTyp parquet.FieldRepetitionType
 
 	*ColumnParameters
 }
@@ -137,42 +147,18 @@ func (is *int32Store) repetitionType() parquet.FieldRepetitionType {
 
 func (is *int32Store) reset(rep parquet.FieldRepetitionType) {
 	is.repTyp = rep
-	is.min = math.MaxInt32
-	is.max = math.MinInt32
-}
-
-func (is *int32Store) maxValue() []byte {
-	if is.max == math.MinInt32 {
-		return nil
-	}
-	ret := make([]byte, 4)
-	binary.LittleEndian.PutUint32(ret, uint32(is.max))
-	return ret
-}
-
-func (is *int32Store) minValue() []byte {
-	if is.min == math.MaxInt32 {
-		return nil
-	}
-	ret := make([]byte, 4)
-	binary.LittleEndian.PutUint32(ret, uint32(is.min))
-	return ret
-}
-
-func (is *int32Store) setMinMax(j int32) {
-	if j < is.min {
-		is.min = j
-	}
-	if j > is.max {
-		is.max = j
-	}
 }
 
 func (is *int32Store) getValues(v interface{}) ([]interface{}, error) {
+	unsigned := isUnsignedInt(is.ConvertedType, is.LogicalType)
 	var vals []interface{}
 	switch typed := v.(type) {
 	case int32:
-		is.setMinMax(typed)
+		vals = []interface{}{is.value(typed, unsigned)}
+	case uint32:
+		if !unsigned {
+			return nil, errors.Errorf("unsupported type for storing in signed int32 column: %T => %+v", v, v)
+		}
 		vals = []interface{}{typed}
 	case []int32:
 		if is.repTyp != parquet.FieldRepetitionType_REPEATED {
@@ -180,7 +166,17 @@ func (is *int32Store) getValues(v interface{}) ([]interface{}, error) {
 		}
 		vals = make([]interface{}, len(typed))
 		for j := range typed {
-			is.setMinMax(typed[j])
+			vals[j] = is.value(typed[j], unsigned)
+		}
+	case []uint32:
+		if !unsigned {
+			return nil, errors.Errorf("unsupported type for storing in signed int32 column: %T => %+v", v, v)
+		}
+		if is.repTyp != parquet.FieldRepetitionType_REPEATED {
+			return nil, errors.Errorf("the value is not repeated but it is an array")
+		}
+		vals = make([]interface{}, len(typed))
+		for j := range typed {
 			vals[j] = typed[j]
 		}
 	default:
@@ -190,7 +186,22 @@ func (is *int32Store) getValues(v interface{}) ([]interface{}, error) {
 	return vals, nil
 }
 
+// value returns the value as it is stored in the column. Values of unsigned columns are stored
+// as uint32, so int32 values are reinterpreted as unsigned for them.
+func (*int32Store) value(v int32, unsigned bool) interface{} {
+	if unsigned {
+		return uint32(v)
+	}
+	return v
+}
+
 func (*int32Store) append(arrayIn interface{}, value interface{}) interface{} {
+	if u, ok := value.(uint32); ok {
+		if arrayIn == nil {
+			arrayIn = make([]uint32, 0, 1)
+		}
+		return append(arrayIn.([]uint32), u)
+	}
 	if arrayIn == nil {
 		arrayIn = make([]int32, 0, 1)
 	}
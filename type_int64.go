@@ -3,7 +3,6 @@ package goparquet
 import (
 	"encoding/binary"
 	"io"
-	"math"
 
 	"github.com/fraugster/parquet-go/parquet"
 	"github.com/pkg/errors"
@@ -92,6 +91,18 @@ type int64DeltaBPEncoder struct {
 	deltaBitPackEncoder64
 }
 
+// newInt64DeltaBPEncoder creates a DELTA_BINARY_PACKED encoder with blocks of 128 values that
+// are split into 4 mini blocks, like the reference implementation.
+func newInt64DeltaBPEncoder(unSigned bool) *int64DeltaBPEncoder {
+	return &int64DeltaBPEncoder{
+		unSigned: unSigned,
+		deltaBitPackEncoder64: deltaBitPackEncoder64{
+			blockSize:      128,
+			miniBlockCount: 4,
+		},
+	}
+}
+
 func (d *int64DeltaBPEncoder) encodeValues(values []interface{}) error {
 	if d.unSigned {
 		for i := range values {
@@ -111,8 +122,7 @@ func (d *int64DeltaBPEncoder) encodeValues(values []interface{}) error {
 }
 
 type int64Store struct {
-	repTyp   parquet.FieldRepetitionType
-	min, max int64
+	repTyp parquet.FieldRepetitionType
 
 	*ColumnParameters
 }
@@ -138,42 +148,18 @@ func (is *int64Store) repetitionType() parquet.FieldRepetitionType {
 
 func (is *int64Store) reset(rep parquet.FieldRepetitionType) {
 	is.repTyp = rep
-	is.min = math.MaxInt64
-	is.max = math.MinInt64
-}
-
-func (is *int64Store) maxValue() []byte {
-	if is.max == math.MinInt64 {
-		return nil
-	}
-	ret := make([]byte, 8)
-	binary.LittleEndian.PutUint64(ret, uint64(is.max))
-	return ret
-}
-
-func (is *int64Store) minValue() []byte {
-	if is.min == math.MaxInt64 {
-		return nil
-	}
-	ret := make([]byte, 8)
-	binary.LittleEndian.PutUint64(ret, uint64(is.min))
-	return ret
-}
-
-func (is *int64Store) setMinMax(j int64) {
-	if j < is.min {
-		is.min = j
-	}
-	if j > is.max {
-		is.max = j
-	}
 }
 
 func (is *int64Store) getValues(v interface{}) ([]interface{}, error) {
+	unsigned := isUnsignedInt(is.ConvertedType, is.LogicalType)
 	var vals []interface{}
 	switch typed := v.(type) {
 	case int64:
-		is.setMinMax(typed)
+		vals = []interface{}{is.value(typed, unsigned)}
+	case uint64:
+		if !unsigned {
+			return nil, errors.Errorf("unsupported type for storing in signed int64 column: %T => %+v", v, v)
+		}
 		vals = []interface{}{typed}
 	case []int64:
 		if is.repTyp != parquet.FieldRepetitionType_REPEATED {
@@ -181,7 +167,17 @@ func (is *int64Store) getValues(v interface{}) ([]interface{}, error) {
 		}
 		vals = make([]interface{}, len(typed))
 		for j := range typed {
-			is.setMinMax(typed[j])
+			vals[j] = is.value(typed[j], unsigned)
+		}
+	case []uint64:
+		if !unsigned {
+			return nil, errors.Errorf("unsupported type for storing in signed int64 column: %T => %+v", v, v)
+		}
+		if is.repTyp != parquet.FieldRepetitionType_REPEATED {
+			return nil, errors.Errorf("the value is not repeated but it is an array")
+		}
+		vals = make([]interface{}, len(typed))
+		for j := range typed {
 			vals[j] = typed[j]
 		}
 	default:
@@ -191,7 +187,22 @@ func (is *int64Store) getValues(v interface{}) ([]interface{}, error) {
 	return vals, nil
 }
 
+// value returns the value as it is stored in the column. Values of unsigned columns are stored
+// as uint64, so int64 values are reinterpreted as unsigned for them.
+func (*int64Store) value(v int64, unsigned bool) interface{} {
+	if unsigned {
+		return uint64(v)
+	}
+	return v
+}
+
 func (*int64Store) append(arrayIn interface{}, value interface{}) interface{} {
+	if u, ok := value.(uint64); ok {
+		if arrayIn == nil {
+			arrayIn = make([]uint64, 0, 1)
+		}
+		return append(arrayIn.([]uint64), u)
+	}
 	if arrayIn == nil {
 		arrayIn = make([]int64, 0, 1)
 	}
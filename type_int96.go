@@ -85,9 +85,6 @@ func (is *int96Store) getValues(v interface{}) ([]interface{}, error) {
 	var vals []interface{}
 	switch typed := v.(type) {
 	case [12]byte:
-		if err := is.setMinMax(typed[:]); err != nil {
-			return nil, err
-		}
 		vals = []interface{}{typed}
 	case [][12]byte:
 		if is.repTyp != parquet.FieldRepetitionType_REPEATED {
@@ -95,9 +92,6 @@ func (is *int96Store) getValues(v interface{}) ([]interface{}, error) {
 		}
 		vals = make([]interface{}, len(typed))
 		for j := range typed {
-			if err := is.setMinMax(typed[j][:]); err != nil {
-				return nil, err
-			}
 			vals[j] = typed[j]
 		}
 	default:
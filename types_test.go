@@ -124,6 +124,22 @@ var (
 				return rand.Float32()
 			},
 		},
+		{
+			name: "DoubleByteStreamSplit",
+			enc:  newDoubleByteStreamSplitEncoder(),
+			dec:  newDoubleByteStreamSplitDecoder(),
+			rand: func() interface{} {
+				return rand.Float64()
+			},
+		},
+		{
+			name: "FloatByteStreamSplit",
+			enc:  newFloatByteStreamSplitEncoder(),
+			dec:  newFloatByteStreamSplitDecoder(),
+			rand: func() interface{} {
+				return rand.Float32()
+			},
+		},
 		{
 			name: "BooleanRLE",
 			enc:  &booleanRLEEncoder{},